@@ -0,0 +1,246 @@
+package linkup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// String enum representing a Linkup API endpoint that is tracked independently by the client
+type LinkupEndpoint string
+
+const (
+	SearchEndpoint LinkupEndpoint = "search"
+	FetchEndpoint  LinkupEndpoint = "fetch"
+)
+
+// Enum representing the state of a circuit breaker
+type CircuitState int
+
+const (
+	// Requests flow normally and failures are being counted
+	CircuitClosed CircuitState = iota
+	// Requests are rejected immediately with ErrCircuitOpen
+	CircuitOpen
+	// A limited number of trial requests are let through to probe the API
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Error returned when a request is rejected because the circuit breaker
+// for its endpoint is open
+type ErrCircuitOpen struct {
+	// Endpoint whose circuit is open
+	Endpoint LinkupEndpoint
+	// Time left before the circuit lets a trial request through
+	RetryAfter time.Duration
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit breaker for the %s endpoint is open, retry after %s", e.Endpoint, e.RetryAfter)
+}
+
+// Configuration for the circuit breaker wrapped around the Linkup API
+type CircuitBreakerConfig struct {
+	// FailureRatio The ratio of failed requests in the window (between 0 and 1) above which the circuit opens.
+	FailureRatio float64
+
+	// MinRequests The minimum number of requests in the window before the failure ratio is evaluated.
+	MinRequests int
+
+	// WindowSize The number of most recent requests taken into account to compute the failure ratio.
+	WindowSize int
+
+	// CoolDown The time the circuit stays open before trial requests are let through.
+	CoolDown time.Duration
+
+	// HalfOpenRequests The number of successful trial requests needed to close the circuit again.
+	HalfOpenRequests int
+}
+
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureRatio:     0.5,
+		MinRequests:      10,
+		WindowSize:       20,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// A circuit breaker tracking the outcome of the requests sent to a single endpoint
+type circuitBreaker struct {
+	endpoint LinkupEndpoint
+	config   CircuitBreakerConfig
+	now      func() time.Time
+
+	mu        sync.Mutex
+	state     CircuitState
+	window    []bool
+	next      int
+	count     int
+	failures  int
+	openedAt  time.Time
+	trials    int
+	successes int
+	// generation is incremented at every state change, to ignore the outcome
+	// of requests allowed in a previous state
+	generation uint64
+}
+
+func newCircuitBreaker(endpoint LinkupEndpoint, config CircuitBreakerConfig) *circuitBreaker {
+	defaults := DefaultCircuitBreakerConfig()
+	if config.FailureRatio <= 0 || config.FailureRatio > 1 {
+		config.FailureRatio = defaults.FailureRatio
+	}
+	if config.WindowSize <= 0 {
+		config.WindowSize = defaults.WindowSize
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = min(defaults.MinRequests, config.WindowSize)
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = defaults.CoolDown
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = defaults.HalfOpenRequests
+	}
+	return &circuitBreaker{
+		endpoint: endpoint,
+		config:   config,
+		now:      time.Now,
+		window:   make([]bool, config.WindowSize),
+	}
+}
+
+// Returns the current state of the breaker, moving from open to half-open
+// once the cool-down has elapsed
+func (b *circuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+func (b *circuitBreaker) refresh() {
+	if b.state == CircuitOpen && !b.now().Before(b.openedAt.Add(b.config.CoolDown)) {
+		b.state = CircuitHalfOpen
+		b.generation++
+		b.trials = 0
+		b.successes = 0
+	}
+}
+
+// Checks whether a request can be sent. When it returns a nil error, the caller
+// must report the outcome of the request with `done`, passing the returned generation
+func (b *circuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	switch b.state {
+	case CircuitOpen:
+		return 0, &ErrCircuitOpen{Endpoint: b.endpoint, RetryAfter: b.openedAt.Add(b.config.CoolDown).Sub(b.now())}
+	case CircuitHalfOpen:
+		if b.trials >= b.config.HalfOpenRequests {
+			return 0, &ErrCircuitOpen{Endpoint: b.endpoint, RetryAfter: 0}
+		}
+		b.trials++
+	}
+	return b.generation, nil
+}
+
+// Records the outcome of a request that was allowed by the breaker. Outcomes of
+// requests allowed before the last state change are ignored: a slow request sent
+// while the circuit was closed does not count as a trial of the half-open state.
+func (b *circuitBreaker) done(generation uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case CircuitHalfOpen:
+		if !success {
+			b.trip()
+			return
+		}
+		b.successes++
+		b.trials--
+		if b.successes >= b.config.HalfOpenRequests {
+			b.state = CircuitClosed
+			b.generation++
+			b.reset()
+		}
+	case CircuitClosed:
+		if b.count == len(b.window) {
+			if !b.window[b.next] {
+				b.failures--
+			}
+		} else {
+			b.count++
+		}
+		b.window[b.next] = success
+		b.next = (b.next + 1) % len(b.window)
+		if !success {
+			b.failures++
+		}
+		if b.count >= b.config.MinRequests && float64(b.failures)/float64(b.count) >= b.config.FailureRatio {
+			b.trip()
+		}
+	}
+}
+
+// Releases a request that was allowed by the breaker without recording its outcome,
+// for requests abandoned by the caller: a half-open trial slot is freed for another request
+func (b *circuitBreaker) release(generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation == b.generation && b.state == CircuitHalfOpen {
+		b.trials--
+	}
+}
+
+func (b *circuitBreaker) trip() {
+	b.state = CircuitOpen
+	b.generation++
+	b.openedAt = b.now()
+	b.reset()
+}
+
+func (b *circuitBreaker) reset() {
+	b.next = 0
+	b.count = 0
+	b.failures = 0
+	b.trials = 0
+	b.successes = 0
+}
+
+// Whether a request failed only because the caller cancelled it or its deadline expired,
+// which says nothing about the health of the API
+func isCallerCancellation(ctx context.Context, err error) bool {
+	return errors.Is(err, context.Canceled) || (errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil)
+}
+
+// Whether a request outcome should count as a failure for the breaker:
+// transport errors, rate limiting and server-side errors trip the circuit,
+// other client errors do not
+func isBreakerFailure(statusCode int, err error) bool {
+	if err != nil {
+		return true
+	}
+	return statusCode == 429 || statusCode >= 500
+}
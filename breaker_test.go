@@ -0,0 +1,182 @@
+package linkup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := newCircuitBreaker(SearchEndpoint, CircuitBreakerConfig{
+		FailureRatio:     0.5,
+		MinRequests:      4,
+		WindowSize:       4,
+		CoolDown:         time.Minute,
+		HalfOpenRequests: 1,
+	})
+	breaker.now = func() time.Time { return current }
+	for _, success := range []bool{true, false, true} {
+		generation, err := breaker.allow()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+		breaker.done(generation, success)
+	}
+	if breaker.State() != CircuitClosed {
+		t.Fatalf("Expected the circuit to be closed, got %s", breaker.State())
+	}
+	generation, err := breaker.allow()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	breaker.done(generation, false)
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("Expected the circuit to be open, got %s", breaker.State())
+	}
+	_, err = breaker.allow()
+	var openErr *ErrCircuitOpen
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if openErr.Endpoint != SearchEndpoint || openErr.RetryAfter != time.Minute {
+		t.Fatalf("Unexpected error: %v", openErr)
+	}
+	current = current.Add(time.Minute)
+	if breaker.State() != CircuitHalfOpen {
+		t.Fatalf("Expected the circuit to be half-open, got %s", breaker.State())
+	}
+	generation, err = breaker.allow()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if _, err := breaker.allow(); err == nil {
+		t.Fatal("Expected only one trial request in the half-open state")
+	}
+	breaker.done(generation, true)
+	if breaker.State() != CircuitClosed {
+		t.Fatalf("Expected the circuit to be closed, got %s", breaker.State())
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := newCircuitBreaker(FetchEndpoint, CircuitBreakerConfig{FailureRatio: 1, MinRequests: 1, WindowSize: 1, CoolDown: time.Second})
+	breaker.now = func() time.Time { return current }
+	generation, _ := breaker.allow()
+	breaker.done(generation, false)
+	current = current.Add(time.Second)
+	generation, err := breaker.allow()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	breaker.done(generation, false)
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("Expected the circuit to be open, got %s", breaker.State())
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleOutcomes(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := newCircuitBreaker(SearchEndpoint, CircuitBreakerConfig{FailureRatio: 1, MinRequests: 1, WindowSize: 1, CoolDown: time.Second})
+	breaker.now = func() time.Time { return current }
+	// a slow request is allowed while the circuit is closed
+	slow, _ := breaker.allow()
+	failing, _ := breaker.allow()
+	breaker.done(failing, false)
+	current = current.Add(time.Second)
+	trial, err := breaker.allow()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	// the slow request completes during the half-open state and must not count as a trial
+	breaker.done(slow, true)
+	if breaker.State() != CircuitHalfOpen || breaker.trials != 1 {
+		t.Fatalf("Expected the stale outcome to be ignored, got %s with %d trials", breaker.State(), breaker.trials)
+	}
+	breaker.done(trial, true)
+	if breaker.State() != CircuitClosed {
+		t.Fatalf("Expected the circuit to be closed, got %s", breaker.State())
+	}
+}
+
+func TestCircuitBreakerIgnoresCancelledRequests(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := newCircuitBreaker(FetchEndpoint, CircuitBreakerConfig{FailureRatio: 1, MinRequests: 1, WindowSize: 1, CoolDown: time.Second})
+	breaker.now = func() time.Time { return current }
+	client := LinkupClient{breakers: map[LinkupEndpoint]*circuitBreaker{FetchEndpoint: breaker}}
+	send := func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelExpired()
+	for _, ctx := range []context.Context{cancelled, expired, cancelled} {
+		if _, err := client.guarded(ctx, FetchEndpoint, send); err == nil {
+			t.Fatal("Expected the error of the context")
+		}
+	}
+	if breaker.State() != CircuitClosed {
+		t.Fatalf("Expected the circuit to stay closed, got %s", breaker.State())
+	}
+
+	// a cancelled trial frees its slot in the half-open state
+	generation, _ := breaker.allow()
+	breaker.done(generation, false)
+	current = current.Add(time.Second)
+	if _, err := client.guarded(cancelled, FetchEndpoint, send); err == nil {
+		t.Fatal("Expected the error of the context")
+	}
+	if breaker.State() != CircuitHalfOpen {
+		t.Fatalf("Expected the circuit to be half-open, got %s", breaker.State())
+	}
+	if _, err := breaker.allow(); err != nil {
+		t.Fatalf("Expected the trial slot to be released, got %s", err.Error())
+	}
+}
+
+func TestLinkupClientCircuitBreaker(t *testing.T) {
+	config := CircuitBreakerConfig{FailureRatio: 1, MinRequests: 2, WindowSize: 2, CoolDown: time.Hour}
+	client := LinkupClient{
+		apiKey: "hello",
+		client: &MockClient{fails: true},
+		breakers: map[LinkupEndpoint]*circuitBreaker{
+			SearchEndpoint: newCircuitBreaker(SearchEndpoint, config),
+			FetchEndpoint:  newCircuitBreaker(FetchEndpoint, config),
+		},
+	}
+	for range 2 {
+		_, err := client.GetSourcedAnswer("lake", Standard)
+		if err == nil || err.Error() != "response returned a status code of 429: 429 Too Many Requests" {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	_, err := client.GetSearchResults("lake", Standard)
+	var openErr *ErrCircuitOpen
+	if !errors.As(err, &openErr) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if client.CircuitState(SearchEndpoint) != CircuitOpen {
+		t.Fatalf("Expected the search circuit to be open, got %s", client.CircuitState(SearchEndpoint))
+	}
+	if client.CircuitState(FetchEndpoint) != CircuitClosed {
+		t.Fatalf("Expected the fetch circuit to be closed, got %s", client.CircuitState(FetchEndpoint))
+	}
+	client.client = &MockClient{fails: false}
+	if _, err := client.Fetch("https://fetch.com"); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestCircuitStateWithoutBreaker(t *testing.T) {
+	client := LinkupClient{
+		apiKey: "hello",
+		client: &MockClient{fails: true},
+	}
+	if client.CircuitState(SearchEndpoint) != CircuitClosed {
+		t.Fatalf("Expected the circuit to be closed, got %s", client.CircuitState(SearchEndpoint))
+	}
+}
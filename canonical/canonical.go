@@ -0,0 +1,217 @@
+// URL canonicalization, to recognize the same page behind different URLs: tracking
+// parameters, letter case, default ports, http and https, trailing slashes, `www.`
+// subdomains and AMP variants (including the Google AMP viewer and cache URLs).
+// The functions of this package are pure: they never send any request.
+package canonical
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Query parameters used to track visits, which do not change the content of a page
+var trackingParameters = map[string]bool{
+	"fbclid":      true,
+	"gclid":       true,
+	"gclsrc":      true,
+	"dclid":       true,
+	"gbraid":      true,
+	"wbraid":      true,
+	"msclkid":     true,
+	"yclid":       true,
+	"twclid":      true,
+	"ttclid":      true,
+	"li_fat_id":   true,
+	"igshid":      true,
+	"mc_cid":      true,
+	"mc_eid":      true,
+	"_ga":         true,
+	"_gl":         true,
+	"_hsenc":      true,
+	"_hsmi":       true,
+	"mkt_tok":     true,
+	"oly_anon_id": true,
+	"oly_enc_id":  true,
+	"vero_id":     true,
+	"wickedid":    true,
+	"ref_src":     true,
+	"ref_url":     true,
+}
+
+// Prefixes of families of tracking parameters
+var trackingPrefixes = []string{"utm_", "hsa_", "pk_", "mtm_"}
+
+// Whether a query parameter is only used to track visits, such as `utm_source` or `fbclid`
+func IsTrackingParameter(name string) bool {
+	name = strings.ToLower(name)
+	if trackingParameters[name] {
+		return true
+	}
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize a URL while keeping it usable to fetch the page: the scheme and host are
+// lowercased, the default port, the fragment, the tracking parameters and the trailing
+// slash are removed, and the remaining query parameters are sorted.
+// URLs that cannot be parsed, or have no host, are returned trimmed.
+func Normalize(rawUrl string) string {
+	parsed, ok := parse(rawUrl)
+	if !ok {
+		return strings.TrimSpace(rawUrl)
+	}
+	return normalize(parsed).String()
+}
+
+// Get the canonical URL of a page, to deduplicate URLs: on top of `Normalize`, http is
+// replaced by https, the `www.` subdomain is removed and AMP variants are resolved to the
+// regular page. The result identifies a page but is not guaranteed to be fetchable.
+// URLs that cannot be parsed, or have no host, are returned trimmed.
+func URL(rawUrl string) string {
+	parsed, ok := parse(rawUrl)
+	if !ok {
+		return strings.TrimSpace(rawUrl)
+	}
+	if inner, ok := ampCacheTarget(parsed); ok {
+		parsed = inner
+	}
+	parsed = normalize(parsed)
+	if parsed.Scheme == "http" {
+		parsed.Scheme = "https"
+	}
+	for _, prefix := range []string{"www.", "amp."} {
+		// the prefix is kept when it is part of the registered domain, as in `amp.dev`
+		if rest, ok := strings.CutPrefix(parsed.Host, prefix); ok && strings.Contains(rest, ".") {
+			parsed.Host = rest
+		}
+	}
+	removeAmp(parsed)
+	return parsed.String()
+}
+
+// Whether two URLs have the same canonical URL
+func Equal(a string, b string) bool {
+	return URL(a) == URL(b)
+}
+
+func parse(rawUrl string) (*url.URL, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil || parsed.Host == "" {
+		return nil, false
+	}
+	return parsed, true
+}
+
+func normalize(parsed *url.URL) *url.URL {
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if strings.Contains(host, ":") {
+		// IPv6 addresses
+		host = "[" + host + "]"
+	}
+	if port := parsed.Port(); port != "" && !(parsed.Scheme == "http" && port == "80") && !(parsed.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	parsed.RawPath = strings.TrimSuffix(parsed.RawPath, "/")
+
+	query := parsed.Query()
+	for name := range query {
+		if IsTrackingParameter(name) {
+			query.Del(name)
+		}
+	}
+	// `Encode` sorts the parameters by name
+	parsed.RawQuery = query.Encode()
+	parsed.ForceQuery = false
+	return parsed
+}
+
+// Resolves the URLs of the Google AMP viewer (`https://www.google.com/amp/s/example.com/page`)
+// and of the AMP cache (`https://example-com.cdn.ampproject.org/c/s/example.com/page`)
+// to the URL of the page they serve
+func ampCacheTarget(parsed *url.URL) (*url.URL, bool) {
+	host := strings.ToLower(parsed.Hostname())
+	var rest string
+	switch {
+	case strings.HasSuffix(host, ".cdn.ampproject.org"):
+		// the first segment is the kind of content: `c` for documents, `i` for images, `r` for resources...
+		_, after, ok := strings.Cut(strings.TrimPrefix(parsed.Path, "/"), "/")
+		if !ok {
+			return nil, false
+		}
+		rest = after
+	case (host == "google.com" || strings.HasPrefix(host, "www.google.")) && strings.HasPrefix(parsed.Path, "/amp/"):
+		rest = strings.TrimPrefix(parsed.Path, "/amp/")
+	default:
+		return nil, false
+	}
+	scheme := "http"
+	if after, ok := strings.CutPrefix(rest, "s/"); ok {
+		scheme, rest = "https", after
+	}
+	target := scheme + "://" + rest
+	if parsed.RawQuery != "" {
+		target += "?" + parsed.RawQuery
+	}
+	inner, ok := parse(target)
+	return inner, ok
+}
+
+// Removes the markers of AMP variants from the path and query of a URL: an `amp` path segment
+// at the beginning or the end of the path, the `.amp` suffix of a file name, and the `amp`
+// and `outputType=amp` parameters
+func removeAmp(parsed *url.URL) {
+	segments := strings.Split(strings.TrimPrefix(parsed.Path, "/"), "/")
+	if len(segments) > 1 && strings.EqualFold(segments[len(segments)-1], "amp") {
+		segments = segments[:len(segments)-1]
+	}
+	if len(segments) > 1 && strings.EqualFold(segments[0], "amp") {
+		segments = segments[1:]
+	}
+	last := segments[len(segments)-1]
+	for _, suffix := range []string{".amp.html", ".amp.htm", ".amp"} {
+		if base, ok := strings.CutSuffix(last, suffix); ok && base != "" {
+			segments[len(segments)-1] = base + strings.TrimPrefix(suffix, ".amp")
+			break
+		}
+	}
+	path := ""
+	if joined := strings.Join(segments, "/"); joined != "" {
+		path = "/" + joined
+	}
+	if path != parsed.Path {
+		parsed.Path = path
+		parsed.RawPath = ""
+	}
+
+	query := parsed.Query()
+	changed := false
+	if values, ok := query["amp"]; ok && slices.ContainsFunc(values, isAmpFlag) {
+		query.Del("amp")
+		changed = true
+	}
+	if strings.EqualFold(query.Get("outputType"), "amp") {
+		query.Del("outputType")
+		changed = true
+	}
+	if changed {
+		parsed.RawQuery = query.Encode()
+	}
+}
+
+func isAmpFlag(value string) bool {
+	switch strings.ToLower(value) {
+	case "", "1", "true", "yes":
+		return true
+	}
+	return false
+}
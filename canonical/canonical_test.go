@@ -0,0 +1,61 @@
+package canonical
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"  HTTPS://Example.COM:443/Path/?utm_source=x&b=2&a=1#top ": "https://example.com/Path?a=1&b=2",
+		"http://example.com:80/":                                    "http://example.com",
+		"http://www.example.com:8080/a?fbclid=1&gclid=2":            "http://www.example.com:8080/a",
+		"https://example.com./a%2Fb/":                               "https://example.com/a%2Fb",
+		"https://[::1]:8443/x":                                      "https://[::1]:8443/x",
+		"not a url":                                                 "not a url",
+		"/relative/path":                                            "/relative/path",
+	}
+	for input, expected := range cases {
+		if got := Normalize(input); got != expected {
+			t.Fatalf("Unexpected normalization of %q: %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	cases := map[string]string{
+		"http://www.example.com/news/story/?utm_campaign=spring&id=3":           "https://example.com/news/story?id=3",
+		"https://example.com/news/story/amp":                                    "https://example.com/news/story",
+		"https://example.com/amp/news/story":                                    "https://example.com/news/story",
+		"https://amp.example.com/news/story.amp.html":                           "https://example.com/news/story.html",
+		"https://example.com/news/story?amp=1&page=2":                           "https://example.com/news/story?page=2",
+		"https://example.com/news/story?outputType=amp":                         "https://example.com/news/story",
+		"https://www.google.com/amp/s/www.example.com/news/story/amp":           "https://example.com/news/story",
+		"https://example-com.cdn.ampproject.org/c/s/example.com/news/story?amp": "https://example.com/news/story",
+		"https://amp.dev/documentation":                                         "https://amp.dev/documentation",
+		"https://www.com/page":                                                  "https://www.com/page",
+		"https://example.com/amp":                                               "https://example.com/amp",
+		"https://example.com/?amp=off":                                          "https://example.com?amp=off",
+	}
+	for input, expected := range cases {
+		if got := URL(input); got != expected {
+			t.Fatalf("Unexpected canonical URL of %q: %q, expected %q", input, got, expected)
+		}
+	}
+	if !Equal("http://www.example.com/a/", "https://example.com/a?utm_medium=email#section") {
+		t.Fatal("Expected the URLs to be equal")
+	}
+	if Equal("https://example.com/a", "https://example.com/b") {
+		t.Fatal("Expected the URLs to differ")
+	}
+}
+
+func TestIsTrackingParameter(t *testing.T) {
+	for _, name := range []string{"utm_source", "UTM_Medium", "fbclid", "_ga", "hsa_cam"} {
+		if !IsTrackingParameter(name) {
+			t.Fatalf("Expected %q to be a tracking parameter", name)
+		}
+	}
+	for _, name := range []string{"id", "page", "q", "ref"} {
+		if IsTrackingParameter(name) {
+			t.Fatalf("Expected %q not to be a tracking parameter", name)
+		}
+	}
+}
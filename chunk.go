@@ -0,0 +1,273 @@
+package linkup
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/AstraBert/linkup-go-sdk/fetch/markdown"
+)
+
+// Enum representing the unit used to measure the size of a chunk
+type ChunkUnit int
+
+const (
+	// Size measured in characters (runes)
+	ChunkCharacters ChunkUnit = iota
+	// Size measured in approximate tokens, counting four characters per token
+	ChunkTokens
+)
+
+// Options to split fetched pages into chunks
+type ChunkOptions struct {
+	// TargetSize The maximum size of a chunk, in Unit.
+	TargetSize int
+
+	// Overlap The amount of content from the end of a chunk repeated at the beginning of the next one, in Unit.
+	// Chunks only overlap within the same section.
+	Overlap int
+
+	// Unit The unit of TargetSize and Overlap.
+	Unit ChunkUnit
+}
+
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{
+		TargetSize: 1000,
+		Overlap:    100,
+		Unit:       ChunkCharacters,
+	}
+}
+
+// Struct type representing a chunk of a fetched page, ready to be embedded
+type Chunk struct {
+	// Text The markdown content of the chunk.
+	Text string
+
+	// SourceUrl The URL of the page the chunk comes from.
+	SourceUrl string
+
+	// HeadingPath The titles of the headings of the section the chunk belongs to, from the top level.
+	HeadingPath []string
+
+	// Index The position of the chunk among the chunks of the page.
+	Index int
+
+	// Start The byte offset of the beginning of the chunk in the markdown of the page.
+	Start int
+
+	// End The byte offset of the end of the chunk in the markdown of the page (exclusive).
+	End int
+}
+
+// Split the markdown of a fetched page into chunks. Sections that fit in the target size
+// are kept whole; larger ones are split by subsection, then by block (paragraph, list, table...),
+// then by sentence and finally by word. The text of each chunk is the slice of the markdown
+// between its byte offsets.
+func ChunkFetchOutput(sourceUrl string, output *FetchOutput, chunkOptions ...ChunkOptions) []Chunk {
+	return ChunkMarkdown(sourceUrl, output.Markdown, chunkOptions...)
+}
+
+// Split a markdown document into chunks, as described in `ChunkFetchOutput`
+func ChunkMarkdown(sourceUrl string, source string, chunkOptions ...ChunkOptions) []Chunk {
+	var options ChunkOptions
+	switch len(chunkOptions) {
+	case 0:
+		options = DefaultChunkOptions()
+	default:
+		options = chunkOptions[0]
+	}
+	if options.TargetSize <= 0 {
+		options.TargetSize = DefaultChunkOptions().TargetSize
+	}
+	if options.Overlap < 0 || options.Overlap >= options.TargetSize {
+		options.Overlap = 0
+	}
+	chunker := &chunker{source: source, options: options, sourceUrl: sourceUrl}
+	document := markdown.Parse(source)
+	root := document.Root
+	// pages usually have a single top-level heading (the title of the page),
+	// which then becomes the root of all the heading paths
+	if len(root.Blocks) == 0 && len(root.Children) == 1 {
+		root = root.Children[0]
+	}
+	chunker.chunkSection(root)
+	return chunker.chunks
+}
+
+type chunker struct {
+	source    string
+	sourceUrl string
+	options   ChunkOptions
+	chunks    []Chunk
+}
+
+func (c *chunker) size(text string) int {
+	characters := utf8.RuneCountInString(text)
+	if c.options.Unit == ChunkTokens {
+		return (characters + 3) / 4
+	}
+	return characters
+}
+
+func (c *chunker) chunkSection(section *markdown.Section) {
+	if strings.TrimSpace(c.source[section.Start:section.End]) == "" {
+		return
+	}
+	if c.size(c.source[section.Start:section.End]) <= c.options.TargetSize {
+		c.emit(section.Start, section.End, section.Path)
+		return
+	}
+	// the content of the section before its first subsection
+	end := section.End
+	if len(section.Children) > 0 {
+		end = section.Children[0].Start
+	}
+	var boundaries []int
+	if section.Heading != nil {
+		boundaries = append(boundaries, section.Heading.End)
+	}
+	for _, block := range section.Blocks {
+		boundaries = append(boundaries, block.End)
+	}
+	c.split(section.Start, end, boundaries, section.Path)
+	for _, child := range section.Children {
+		c.chunkSection(child)
+	}
+}
+
+// Splits the range [start, end) of the source into chunks, preferring the given block boundaries
+func (c *chunker) split(start int, end int, boundaries []int, path []string) {
+	// each chunk must end past the previous one, so that it does not only repeat the overlap
+	position, previous := start, start
+	for position < end {
+		if strings.TrimSpace(c.source[position:end]) == "" {
+			return
+		}
+		if c.size(c.source[position:end]) <= c.options.TargetSize {
+			c.emit(position, end, path)
+			return
+		}
+		// no chunk can span more bytes than the target size in runes times the maximum rune width
+		window := min(end, position+c.maxRunes()*utf8.UTFMax)
+		cut := c.lastFitting(position, previous, boundaries)
+		if cut < 0 {
+			cut = c.lastFitting(position, previous, c.sentenceBoundaries(position, window))
+		}
+		if cut < 0 {
+			cut = c.lastFitting(position, previous, c.wordBoundaries(position, window))
+		}
+		if cut < 0 {
+			cut = c.hardCut(position, end)
+		}
+		c.emit(position, cut, path)
+		position, previous = c.overlapStart(position, cut), cut
+	}
+}
+
+// Returns the furthest boundary such that the chunk starting at `position` fits in the target
+// size and has content after `after`, or -1
+func (c *chunker) lastFitting(position int, after int, boundaries []int) int {
+	best := -1
+	for _, boundary := range boundaries {
+		if boundary <= position {
+			continue
+		}
+		if c.size(c.source[position:boundary]) > c.options.TargetSize {
+			break
+		}
+		if boundary > after && strings.TrimSpace(c.source[after:boundary]) != "" {
+			best = boundary
+		}
+	}
+	return best
+}
+
+func (c *chunker) sentenceBoundaries(start int, end int) []int {
+	var boundaries []int
+	for i := start; i < end-1; i++ {
+		switch c.source[i] {
+		case '.', '!', '?', '\n':
+			if c.source[i] == '\n' || c.source[i+1] == ' ' || c.source[i+1] == '\n' {
+				boundaries = append(boundaries, i+1)
+			}
+		}
+	}
+	return boundaries
+}
+
+func (c *chunker) wordBoundaries(start int, end int) []int {
+	var boundaries []int
+	for i, r := range c.source[start:end] {
+		if unicode.IsSpace(r) {
+			boundaries = append(boundaries, start+i)
+		}
+	}
+	return boundaries
+}
+
+// Returns the maximum number of runes fitting in the target size
+func (c *chunker) maxRunes() int {
+	if c.options.Unit == ChunkTokens {
+		return c.options.TargetSize * 4
+	}
+	return c.options.TargetSize
+}
+
+// Cuts at the last rune that fits in the target size, when no boundary is available
+func (c *chunker) hardCut(position int, end int) int {
+	limit := c.maxRunes()
+	count := 0
+	for i := range c.source[position:end] {
+		if count == limit {
+			return position + i
+		}
+		count++
+	}
+	return end
+}
+
+// Returns where the chunk following [start, cut) begins, so that it repeats
+// the configured overlap, starting on a word boundary
+func (c *chunker) overlapStart(start int, cut int) int {
+	if c.options.Overlap == 0 {
+		return cut
+	}
+	next := cut
+	for next > start {
+		_, width := utf8.DecodeLastRuneInString(c.source[start:next])
+		if c.size(c.source[next-width:cut]) > c.options.Overlap {
+			break
+		}
+		next -= width
+	}
+	if next <= start {
+		return cut
+	}
+	for next < cut {
+		if previous, _ := utf8.DecodeLastRuneInString(c.source[:next]); unicode.IsSpace(previous) {
+			break
+		}
+		_, width := utf8.DecodeRuneInString(c.source[next:cut])
+		next += width
+	}
+	return next
+}
+
+func (c *chunker) emit(start int, end int, path []string) {
+	text := c.source[start:end]
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	start += len(text) - len(trimmed)
+	end = start + len(strings.TrimRightFunc(trimmed, unicode.IsSpace))
+	if start == end {
+		return
+	}
+	c.chunks = append(c.chunks, Chunk{
+		Text:        c.source[start:end],
+		SourceUrl:   c.sourceUrl,
+		HeadingPath: path,
+		Index:       len(c.chunks),
+		Start:       start,
+		End:         end,
+	})
+}
@@ -0,0 +1,149 @@
+package linkup
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const chunkTestPage = `# Guide
+
+Short intro.
+
+## Install
+
+Run the installer.
+
+## Usage
+
+First paragraph about usage, which is long enough to need its own chunk.
+
+Second paragraph about usage, which is also quite long and detailed.
+
+Third paragraph, the last one of the usage section.
+`
+
+func TestChunkFetchOutputKeepsSmallSectionsWhole(t *testing.T) {
+	output := &FetchOutput{Markdown: chunkTestPage}
+	chunks := ChunkFetchOutput("https://example.com/guide", output)
+	if len(chunks) != 1 {
+		t.Fatalf("Expected 1 chunk, got %d", len(chunks))
+	}
+	chunk := chunks[0]
+	if chunk.Text != strings.TrimSpace(chunkTestPage) || chunk.SourceUrl != "https://example.com/guide" || chunk.Index != 0 {
+		t.Fatalf("Unexpected chunk: %+v", chunk)
+	}
+	if !slices.Equal(chunk.HeadingPath, []string{"Guide"}) {
+		t.Fatalf("Unexpected heading path: %v", chunk.HeadingPath)
+	}
+}
+
+func TestChunkMarkdownSplitsByHeadingThenParagraph(t *testing.T) {
+	chunks := ChunkMarkdown("https://example.com/guide", chunkTestPage, ChunkOptions{TargetSize: 160})
+	expectedPaths := [][]string{
+		{"Guide"},
+		{"Guide", "Install"},
+		{"Guide", "Usage"},
+		{"Guide", "Usage"},
+	}
+	if len(chunks) != len(expectedPaths) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(expectedPaths), len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Index != i {
+			t.Fatalf("Expected index %d, got %d", i, chunk.Index)
+		}
+		if !slices.Equal(chunk.HeadingPath, expectedPaths[i]) {
+			t.Fatalf("Expected heading path %v for chunk %d, got %v", expectedPaths[i], i, chunk.HeadingPath)
+		}
+		if chunkTestPage[chunk.Start:chunk.End] != chunk.Text {
+			t.Fatalf("Offsets of chunk %d do not match its text", i)
+		}
+		if utf8.RuneCountInString(chunk.Text) > 160 {
+			t.Fatalf("Chunk %d is larger than the target size: %d", i, len(chunk.Text))
+		}
+	}
+	if chunks[0].Text != "# Guide\n\nShort intro." {
+		t.Fatalf("Unexpected first chunk: %q", chunks[0].Text)
+	}
+	if !strings.HasPrefix(chunks[2].Text, "## Usage\n\nFirst paragraph") || !strings.HasPrefix(chunks[3].Text, "Third paragraph") {
+		t.Fatalf("Unexpected usage chunks: %q, %q", chunks[2].Text, chunks[3].Text)
+	}
+}
+
+func TestChunkMarkdownOverlapAndTokens(t *testing.T) {
+	words := make([]string, 300)
+	for i := range words {
+		words[i] = "word"
+	}
+	source := strings.Join(words, " ")
+	chunks := ChunkMarkdown("", source, ChunkOptions{TargetSize: 25, Overlap: 5, Unit: ChunkTokens})
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if (utf8.RuneCountInString(chunk.Text)+3)/4 > 25 {
+			t.Fatalf("Chunk %d is larger than the target size", i)
+		}
+		if source[chunk.Start:chunk.End] != chunk.Text || strings.HasPrefix(chunk.Text, "ord") {
+			t.Fatalf("Chunk %d does not start on a word boundary: %q", i, chunk.Text)
+		}
+		if i > 0 && chunk.Start >= chunks[i-1].End {
+			t.Fatalf("Expected chunk %d to overlap with the previous one", i)
+		}
+	}
+	if chunks[len(chunks)-1].End != len(source) {
+		t.Fatal("The chunks should cover the whole source")
+	}
+}
+
+func TestChunkMarkdownOverlapMovesForward(t *testing.T) {
+	source := "First paragraph that is fairly long, about fifty seven.\n\nShort one.\n\nAnother paragraph that is long enough to matter here for sure.\n\nEnd."
+	chunks := ChunkMarkdown("", source, ChunkOptions{TargetSize: 80, Overlap: 20})
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(chunks))
+	}
+	for i := 1; i < len(chunks); i++ {
+		if chunks[i].End <= chunks[i-1].End {
+			t.Fatalf("Chunk %d %q is contained in the previous one %q", i, chunks[i].Text, chunks[i-1].Text)
+		}
+	}
+	if chunks[len(chunks)-1].End != len(source) {
+		t.Fatal("The chunks should cover the whole source")
+	}
+}
+
+func TestChunkMarkdownNonAscii(t *testing.T) {
+	source := strings.Repeat("Andiamo in città à Noël, ça déjà vu. ", 20) + "Andiamo in città"
+	for _, options := range []ChunkOptions{{TargetSize: 40}, {TargetSize: 40, Overlap: 10}} {
+		chunks := ChunkMarkdown("", source, options)
+		if len(chunks) < 2 {
+			t.Fatalf("Expected several chunks, got %d", len(chunks))
+		}
+		for i, chunk := range chunks {
+			if !utf8.ValidString(chunk.Text) || source[chunk.Start:chunk.End] != chunk.Text {
+				t.Fatalf("Chunk %d is not valid UTF-8 or does not match its offsets: %q", i, chunk.Text)
+			}
+		}
+		if last := chunks[len(chunks)-1]; !strings.HasSuffix(last.Text, "città") {
+			t.Fatalf("Unexpected last chunk: %q", last.Text)
+		}
+	}
+}
+
+func TestChunkMarkdownHardCut(t *testing.T) {
+	source := strings.Repeat("é", 25)
+	chunks := ChunkMarkdown("", source, ChunkOptions{TargetSize: 10})
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if !utf8.ValidString(chunk.Text) || utf8.RuneCountInString(chunk.Text) > 10 {
+			t.Fatalf("Unexpected chunk: %q", chunk.Text)
+		}
+	}
+	if len(ChunkMarkdown("", "   \n\n  ")) != 0 {
+		t.Fatal("Expected no chunks for blank markdown")
+	}
+}
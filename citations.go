@@ -0,0 +1,290 @@
+package linkup
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+)
+
+// Struct type representing a reference from a citation marker to a source of the answer
+type CitationRef struct {
+	// Number The number written in the marker, starting from 1.
+	Number int
+
+	// Url The URL written in the marker, for markers written as markdown links.
+	Url string
+
+	// SourceIndex The index of the cited source in the sources of the answer, or -1 for dangling citations.
+	SourceIndex int
+
+	// Source The cited source, nil for dangling citations.
+	Source *SourceDto
+}
+
+// Enum representing the kind of a segment of an answer
+type SegmentKind int
+
+const (
+	// A piece of the text of the answer
+	TextSegment SegmentKind = iota
+	// One or more consecutive citation markers
+	CitationSegment
+)
+
+// Struct type representing a segment of an answer: either text, or citation markers
+type AnswerSegment struct {
+	Kind SegmentKind
+
+	// Text The text of the segment, as written in the answer (including the markers for citation segments).
+	Text string
+
+	// Citations The citations of the markers, for citation segments.
+	Citations []CitationRef
+
+	// Start The byte offset of the beginning of the segment in the answer.
+	Start int
+
+	// End The byte offset of the end of the segment in the answer (exclusive).
+	End int
+}
+
+// Struct type representing a sourced answer whose inline citations have been parsed
+type CitedAnswer struct {
+	// Answer The answer, as returned by the API.
+	Answer string
+
+	// Sources The sources of the answer.
+	Sources []SourceDto
+
+	// Segments The text and citation segments of the answer, in order.
+	Segments []AnswerSegment
+
+	// Dangling The citations that do not match any source.
+	Dangling []CitationRef
+
+	// Unused The indexes of the sources that are never cited.
+	Unused []int
+}
+
+// Citation markers: `[1]`, `[1, 2]`, `[^1]`, optionally followed by a link destination as
+// in `[1](https://example.com)`, and `[[1]](https://example.com)`
+var citationMarker = regexp.MustCompile(`\[\[(\d+)\]\]\(([^)\s]+)\)|\[\^?(\d+(?:\s*,\s*\d+)*)\](?:\(([^)\s]+)\))?`)
+
+// Parse the inline citations of a sourced answer (requested with `IncludeInlineCitations`)
+// and resolve them against its sources
+func ParseCitations(output *SourcedAnswerOutput) *CitedAnswer {
+	answer := &CitedAnswer{Answer: output.Answer, Sources: output.Sources}
+	used := make([]bool, len(output.Sources))
+	position := 0
+	for _, match := range citationMarker.FindAllStringSubmatchIndex(output.Answer, -1) {
+		start, end := match[0], match[1]
+		numbersStart, numbersEnd, markerUrl, ok := parseCitationMarker(output.Answer, match, output.Sources)
+		if !ok {
+			continue
+		}
+		var citations []CitationRef
+		for _, number := range strings.Split(output.Answer[numbersStart:numbersEnd], ",") {
+			value, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil {
+				continue
+			}
+			citation := answer.resolve(value, markerUrl)
+			if citation.Source == nil {
+				answer.Dangling = append(answer.Dangling, citation)
+			} else {
+				used[citation.SourceIndex] = true
+			}
+			citations = append(citations, citation)
+		}
+		if start > position {
+			answer.Segments = append(answer.Segments, AnswerSegment{Kind: TextSegment, Text: output.Answer[position:start], Start: position, End: start})
+		}
+		// consecutive markers are grouped in the same segment
+		if last := len(answer.Segments) - 1; last >= 0 && answer.Segments[last].Kind == CitationSegment && answer.Segments[last].End == start {
+			answer.Segments[last].Text += output.Answer[start:end]
+			answer.Segments[last].Citations = append(answer.Segments[last].Citations, citations...)
+			answer.Segments[last].End = end
+		} else {
+			answer.Segments = append(answer.Segments, AnswerSegment{Kind: CitationSegment, Text: output.Answer[start:end], Citations: citations, Start: start, End: end})
+		}
+		position = end
+	}
+	if position < len(output.Answer) {
+		answer.Segments = append(answer.Segments, AnswerSegment{Kind: TextSegment, Text: output.Answer[position:], Start: position, End: len(output.Answer)})
+	}
+	for i, isUsed := range used {
+		if !isUsed {
+			answer.Unused = append(answer.Unused, i)
+		}
+	}
+	return answer
+}
+
+// Returns the offsets of the numbers of a citation marker match and its link destination, if any.
+// Markers are only citations when their link destination or all their numbers match one of the
+// sources, so that bracketed numbers such as years and ordinary links are left as text, and
+// markers preceded by '!' are images.
+func parseCitationMarker(text string, match []int, sources []SourceDto) (int, int, string, bool) {
+	if match[0] > 0 && text[match[0]-1] == '!' {
+		return 0, 0, "", false
+	}
+	start, end, markerUrl := match[6], match[7], ""
+	if match[2] >= 0 {
+		start, end, markerUrl = match[2], match[3], text[match[4]:match[5]]
+	} else if match[8] >= 0 {
+		markerUrl = text[match[8]:match[9]]
+	}
+	if markerUrl != "" && sourceIndex(sources, markerUrl) >= 0 {
+		return start, end, markerUrl, true
+	}
+	for _, number := range strings.Split(text[start:end], ",") {
+		if value, err := strconv.Atoi(strings.TrimSpace(number)); err != nil || value < 1 || value > len(sources) {
+			return 0, 0, "", false
+		}
+	}
+	return start, end, markerUrl, true
+}
+
+// Returns the index of the source with the same canonical URL, or -1
+func sourceIndex(sources []SourceDto, rawUrl string) int {
+	key := canonical.URL(rawUrl)
+	for i := range sources {
+		if canonical.URL(sources[i].Url) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Resolves a citation by the URL of its marker if any, or else by its number. A citation whose
+// URL matches no source is dangling, even if its number does.
+func (a *CitedAnswer) resolve(number int, markerUrl string) CitationRef {
+	citation := CitationRef{Number: number, Url: markerUrl, SourceIndex: -1}
+	index := number - 1
+	if markerUrl != "" {
+		index = sourceIndex(a.Sources, markerUrl)
+	}
+	if index >= 0 && index < len(a.Sources) {
+		citation.SourceIndex, citation.Source = index, &a.Sources[index]
+	}
+	return citation
+}
+
+// Get the sources in the order of their first citation in the answer
+func (a *CitedAnswer) CitedSources() []SourceDto {
+	var sources []SourceDto
+	for _, index := range a.citationOrder() {
+		sources = append(sources, a.Sources[index])
+	}
+	return sources
+}
+
+// Returns the indexes of the cited sources, in the order of their first citation
+func (a *CitedAnswer) citationOrder() []int {
+	var order []int
+	seen := make(map[int]bool)
+	for _, segment := range a.Segments {
+		for _, citation := range segment.Citations {
+			if citation.Source != nil && !seen[citation.SourceIndex] {
+				seen[citation.SourceIndex] = true
+				order = append(order, citation.SourceIndex)
+			}
+		}
+	}
+	return order
+}
+
+// Enum representing how citations are rendered
+type CitationStyle int
+
+const (
+	// Markdown footnotes (`[^1]`), with the list of sources at the end of the answer
+	CitationFootnotes CitationStyle = iota
+	// Markdown links to the sources (`[1](https://example.com)`)
+	CitationHyperlinks
+	// HTML superscript links to the sources (`<sup><a href="https://example.com">1</a></sup>`)
+	CitationSuperscript
+	// No citations
+	CitationNone
+)
+
+// Render the answer with its citations in the given style. Citations are numbered in the
+// order of their first appearance, repeated citations of a source within a group are merged,
+// and dangling citations are removed.
+func (a *CitedAnswer) Render(style CitationStyle) string {
+	var builder strings.Builder
+	builder.WriteString(a.render(style == CitationHyperlinks, func(number int, source *SourceDto) string {
+		switch style {
+		case CitationFootnotes:
+			return fmt.Sprintf("[^%d]", number)
+		case CitationHyperlinks:
+			return fmt.Sprintf("[%d](%s)", number, source.Url)
+		case CitationSuperscript:
+			return fmt.Sprintf(`<sup><a href="%s">%d</a></sup>`, html.EscapeString(source.Url), number)
+		}
+		return ""
+	}))
+	if style == CitationFootnotes {
+		order := a.citationOrder()
+		if len(order) > 0 {
+			builder.WriteString("\n")
+		}
+		for i, index := range order {
+			source := a.Sources[index]
+			name := source.Name
+			if name == "" {
+				name = source.Url
+			}
+			fmt.Fprintf(&builder, "\n[^%d]: [%s](%s)", i+1, name, source.Url)
+		}
+	}
+	return builder.String()
+}
+
+// Render the answer with custom citation markers: `marker` is called for every citation with
+// the number of the cited source (in the order of first appearance) and the source itself.
+// Markers are attached to the preceding word, and dangling citations are removed.
+func (a *CitedAnswer) RenderMarkers(marker func(number int, source *SourceDto) string) string {
+	return a.render(false, marker)
+}
+
+func (a *CitedAnswer) render(keepSpace bool, marker func(number int, source *SourceDto) string) string {
+	numbers := make(map[int]int)
+	for i, index := range a.citationOrder() {
+		numbers[index] = i + 1
+	}
+	var builder strings.Builder
+	for i, segment := range a.Segments {
+		if segment.Kind == TextSegment {
+			text := segment.Text
+			// the space before a removed marker is removed too
+			if i+1 < len(a.Segments) && (!keepSpace || !a.Segments[i+1].hasSources()) {
+				text = strings.TrimRight(text, " ")
+			}
+			builder.WriteString(text)
+			continue
+		}
+		seen := make(map[int]bool)
+		for _, citation := range segment.Citations {
+			if citation.Source == nil || seen[citation.SourceIndex] {
+				continue
+			}
+			seen[citation.SourceIndex] = true
+			builder.WriteString(marker(numbers[citation.SourceIndex], citation.Source))
+		}
+	}
+	return builder.String()
+}
+
+func (s AnswerSegment) hasSources() bool {
+	for _, citation := range s.Citations {
+		if citation.Source != nil {
+			return true
+		}
+	}
+	return false
+}
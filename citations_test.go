@@ -0,0 +1,120 @@
+package linkup
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func testCitedAnswer() *SourcedAnswerOutput {
+	return &SourcedAnswerOutput{
+		Answer: "Go was released in 2009 [1]. It is fast [2][1] and simple [3, 2]. See ![logo](https://go.dev/logo.png) and [[4]](https://GO.dev/doc/) or [2](https://unknown.example.com). Go 1 shipped in [2012], see [2012](https://go.dev/doc/go1).",
+		Sources: []SourceDto{
+			{Name: "Wikipedia", Url: "https://en.wikipedia.org/wiki/Go"},
+			{Name: "Blog", Url: "https://blog.example.com/go"},
+			{Name: "Tour", Url: "https://go.dev/tour"},
+			{Name: "Docs", Url: "https://go.dev/doc"},
+			{Name: "Unused", Url: "https://unused.example.com"},
+		},
+	}
+}
+
+func TestParseCitations(t *testing.T) {
+	answer := ParseCitations(testCitedAnswer())
+	var kinds []SegmentKind
+	for _, segment := range answer.Segments {
+		kinds = append(kinds, segment.Kind)
+		if answer.Answer[segment.Start:segment.End] != segment.Text {
+			t.Fatalf("Offsets do not match the text of segment %q", segment.Text)
+		}
+	}
+	expected := []SegmentKind{TextSegment, CitationSegment, TextSegment, CitationSegment, TextSegment, CitationSegment, TextSegment, CitationSegment, TextSegment, CitationSegment, TextSegment}
+	if !slices.Equal(kinds, expected) {
+		t.Fatalf("Expected segments %v, got %v", expected, kinds)
+	}
+	grouped := answer.Segments[3]
+	if grouped.Text != "[2][1]" || len(grouped.Citations) != 2 || grouped.Citations[0].Source.Name != "Blog" {
+		t.Fatalf("Unexpected grouped citations: %+v", grouped)
+	}
+	if list := answer.Segments[5].Citations; len(list) != 2 || list[0].Number != 3 || list[1].Number != 2 {
+		t.Fatalf("Unexpected citation list: %+v", list)
+	}
+	// the link citation is resolved by URL, not by number
+	if linked := answer.Segments[7].Citations[0]; linked.SourceIndex != 3 || linked.Url != "https://GO.dev/doc/" {
+		t.Fatalf("Unexpected link citation: %+v", linked)
+	}
+	if len(answer.Dangling) != 1 || answer.Dangling[0].Number != 2 || answer.Dangling[0].SourceIndex != -1 {
+		t.Fatalf("Unexpected dangling citations: %+v", answer.Dangling)
+	}
+	if !slices.Equal(answer.Unused, []int{4}) {
+		t.Fatalf("Unexpected unused sources: %v", answer.Unused)
+	}
+	var names []string
+	for _, source := range answer.CitedSources() {
+		names = append(names, source.Name)
+	}
+	if !slices.Equal(names, []string{"Wikipedia", "Blog", "Tour", "Docs"}) {
+		t.Fatalf("Unexpected cited sources: %v", names)
+	}
+}
+
+func TestRenderCitations(t *testing.T) {
+	answer := ParseCitations(&SourcedAnswerOutput{
+		Answer: "Go is fast [2][2]. It was released in 2009 [1] [1](https://unknown.example.com).",
+		Sources: []SourceDto{
+			{Name: "Wikipedia", Url: "https://en.wikipedia.org/wiki/Go"},
+			{Name: "", Url: "https://blog.example.com/go?a=1&b=2"},
+		},
+	})
+	cases := map[CitationStyle]string{
+		CitationFootnotes:   "Go is fast[^1]. It was released in 2009[^2].\n\n[^1]: [https://blog.example.com/go?a=1&b=2](https://blog.example.com/go?a=1&b=2)\n[^2]: [Wikipedia](https://en.wikipedia.org/wiki/Go)",
+		CitationHyperlinks:  "Go is fast [1](https://blog.example.com/go?a=1&b=2). It was released in 2009 [2](https://en.wikipedia.org/wiki/Go).",
+		CitationSuperscript: `Go is fast<sup><a href="https://blog.example.com/go?a=1&amp;b=2">1</a></sup>. It was released in 2009<sup><a href="https://en.wikipedia.org/wiki/Go">2</a></sup>.`,
+		CitationNone:        "Go is fast. It was released in 2009.",
+	}
+	for style, expected := range cases {
+		if got := answer.Render(style); got != expected {
+			t.Fatalf("Unexpected rendering for style %d:\n%q\nexpected:\n%q", style, got, expected)
+		}
+	}
+}
+
+func TestRenderMarkers(t *testing.T) {
+	answer := ParseCitations(&SourcedAnswerOutput{
+		Answer:  "Go is fast [2]. It is simple [2](https://unknown.example.com).",
+		Sources: []SourceDto{{Url: "https://a.com"}, {Url: "https://b.com"}},
+	})
+	got := answer.RenderMarkers(func(number int, source *SourceDto) string {
+		return fmt.Sprintf("<%d:%s>", number, source.Url)
+	})
+	if got != "Go is fast<1:https://b.com>. It is simple." {
+		t.Fatalf("Unexpected rendering: %q", got)
+	}
+}
+
+func TestCitationsKeepBracketedNumbers(t *testing.T) {
+	answer := ParseCitations(&SourcedAnswerOutput{
+		Answer:  "Go 1.22 was released in [2024] [1] and lists [1, 99] changes.",
+		Sources: []SourceDto{{Name: "Go", Url: "https://go.dev"}},
+	})
+	if len(answer.Dangling) != 0 {
+		t.Fatalf("Unexpected dangling citations: %+v", answer.Dangling)
+	}
+	if got := answer.Render(CitationNone); got != "Go 1.22 was released in [2024] and lists [1, 99] changes." {
+		t.Fatalf("Unexpected rendering: %q", got)
+	}
+}
+
+func TestCitationsLinkedMarkers(t *testing.T) {
+	answer := ParseCitations(&SourcedAnswerOutput{
+		Answer:  "See [2023](https://en.wikipedia.org/wiki/2023) and [1](https://go.dev/) but not [1](https://wrong.example.com).",
+		Sources: []SourceDto{{Name: "Go", Url: "https://go.dev"}},
+	})
+	// the ordinary link is text, the marker with a wrong URL is dangling instead of resolving by number
+	if len(answer.Dangling) != 1 || answer.Dangling[0].Url != "https://wrong.example.com" || answer.Dangling[0].Source != nil {
+		t.Fatalf("Unexpected dangling citations: %+v", answer.Dangling)
+	}
+	if got := answer.Render(CitationNone); got != "See [2023](https://en.wikipedia.org/wiki/2023) and but not." {
+		t.Fatalf("Unexpected rendering: %q", got)
+	}
+}
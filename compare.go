@@ -0,0 +1,312 @@
+package linkup
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+)
+
+// Options to compare the depths with `Compare`
+type CompareOptions struct {
+	// SearchOptions The options of both searches.
+	SearchOptions AdditionalSearchOptions
+
+	// StandardCost The estimated cost of a search at the Standard depth. Zero means the default cost.
+	StandardCost float64
+
+	// DeepCost The estimated cost of a search at the Deep depth. Zero means the default cost.
+	DeepCost float64
+
+	// SentenceThreshold The minimum similarity (Jaccard index of their significant words) for two
+	// sentences of the answers to be considered as saying the same thing.
+	SentenceThreshold float64
+}
+
+// The default costs follow the public pricing of the Linkup API at the time of writing, in euros:
+// override them if your plan differs.
+func DefaultCompareOptions() CompareOptions {
+	return CompareOptions{
+		SearchOptions:     DefaultAdditionalSearchOptions(),
+		StandardCost:      0.005,
+		DeepCost:          0.05,
+		SentenceThreshold: 0.6,
+	}
+}
+
+// Struct type representing the outcome of a query at one depth
+type DepthResult struct {
+	// Depth The depth of the search.
+	Depth SearchDepth
+
+	// Output The sourced answer, nil if the search failed.
+	Output *SourcedAnswerOutput
+
+	// Latency The time the search took, including the retries.
+	Latency time.Duration
+
+	// Cost The estimated cost of the search.
+	Cost float64
+
+	// Err The error of the search, if it failed.
+	Err error
+}
+
+// Struct type representing the comparison of the answers to the same query at the Standard and Deep depths
+type Comparison struct {
+	// Query The query.
+	Query string
+
+	// Standard The outcome at the Standard depth.
+	Standard DepthResult
+
+	// Deep The outcome at the Deep depth.
+	Deep DepthResult
+
+	// SourceOverlap The Jaccard index of the canonical URLs of the sources of both answers, from 0
+	// (no source in common) to 1 (the same sources).
+	SourceOverlap float64
+
+	// SharedSources The sources of the Standard answer that are also sources of the Deep answer.
+	SharedSources []SourceDto
+
+	// OnlyStandardSources The sources of the Standard answer only.
+	OnlyStandardSources []SourceDto
+
+	// OnlyDeepSources The sources of the Deep answer only.
+	OnlyDeepSources []SourceDto
+
+	// AnswerSimilarity The Jaccard index of the significant words of both answers, from 0 to 1.
+	AnswerSimilarity float64
+
+	// OnlyStandardSentences The sentences of the Standard answer that have no similar sentence in the Deep answer.
+	OnlyStandardSentences []string
+
+	// OnlyDeepSentences The sentences of the Deep answer that have no similar sentence in the Standard answer.
+	OnlyDeepSentences []string
+}
+
+// Run the same query at the Standard and Deep depths concurrently with `GetSourcedAnswer`,
+// and compare the answers, their sources, latencies and estimated costs, to help deciding
+// when the Deep depth is worth its cost. When only one of the searches fails, its error is
+// reported in the comparison; an error is returned when both fail.
+func (l *LinkupClient) Compare(query string, compareOptions ...CompareOptions) (*Comparison, error) {
+	var options CompareOptions
+	switch len(compareOptions) {
+	case 0:
+		options = DefaultCompareOptions()
+	default:
+		options = compareOptions[0]
+		defaults := DefaultCompareOptions()
+		if options.StandardCost <= 0 {
+			options.StandardCost = defaults.StandardCost
+		}
+		if options.DeepCost <= 0 {
+			options.DeepCost = defaults.DeepCost
+		}
+		if options.SentenceThreshold <= 0 {
+			options.SentenceThreshold = defaults.SentenceThreshold
+		}
+	}
+	comparison := &Comparison{
+		Query:    query,
+		Standard: DepthResult{Depth: Standard, Cost: options.StandardCost},
+		Deep:     DepthResult{Depth: Deep, Cost: options.DeepCost},
+	}
+	var wg sync.WaitGroup
+	for _, result := range []*DepthResult{&comparison.Standard, &comparison.Deep} {
+		wg.Add(1)
+		go func(result *DepthResult) {
+			defer wg.Done()
+			started := time.Now()
+			result.Output, result.Err = l.GetSourcedAnswer(query, result.Depth, options.SearchOptions)
+			result.Latency = time.Since(started)
+		}(result)
+	}
+	wg.Wait()
+	if comparison.Standard.Err != nil && comparison.Deep.Err != nil {
+		return nil, fmt.Errorf("both searches failed: %w", errors.Join(comparison.Standard.Err, comparison.Deep.Err))
+	}
+	if comparison.Standard.Err == nil && comparison.Deep.Err == nil {
+		comparison.compareSources()
+		comparison.compareAnswers(options.SentenceThreshold)
+	}
+	return comparison, nil
+}
+
+func (c *Comparison) compareSources() {
+	deepUrls := make(map[string]bool)
+	for _, source := range c.Deep.Output.Sources {
+		deepUrls[canonical.URL(source.Url)] = true
+	}
+	standardUrls := make(map[string]bool)
+	for _, source := range c.Standard.Output.Sources {
+		key := canonical.URL(source.Url)
+		if standardUrls[key] {
+			continue
+		}
+		standardUrls[key] = true
+		if deepUrls[key] {
+			c.SharedSources = append(c.SharedSources, source)
+		} else {
+			c.OnlyStandardSources = append(c.OnlyStandardSources, source)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, source := range c.Deep.Output.Sources {
+		key := canonical.URL(source.Url)
+		if !standardUrls[key] && !seen[key] {
+			seen[key] = true
+			c.OnlyDeepSources = append(c.OnlyDeepSources, source)
+		}
+	}
+	c.SourceOverlap = jaccard(standardUrls, deepUrls)
+}
+
+func (c *Comparison) compareAnswers(threshold float64) {
+	standardAnswer := ParseCitations(c.Standard.Output).Render(CitationNone)
+	deepAnswer := ParseCitations(c.Deep.Output).Render(CitationNone)
+	c.AnswerSimilarity = jaccard(significantWords(standardAnswer), significantWords(deepAnswer))
+
+	standardSentences, deepSentences := splitSentences(standardAnswer), splitSentences(deepAnswer)
+	c.OnlyStandardSentences = unmatchedSentences(standardSentences, deepSentences, threshold)
+	c.OnlyDeepSentences = unmatchedSentences(deepSentences, standardSentences, threshold)
+}
+
+// Returns the sentences that have no similar sentence among the others
+func unmatchedSentences(sentences []string, others []string, threshold float64) []string {
+	otherWords := make([]map[string]bool, len(others))
+	for i, other := range others {
+		otherWords[i] = significantWords(other)
+	}
+	var unmatched []string
+	for _, sentence := range sentences {
+		sentenceWords := significantWords(sentence)
+		if len(sentenceWords) == 0 {
+			continue
+		}
+		matched := false
+		for _, candidate := range otherWords {
+			if jaccard(sentenceWords, candidate) >= threshold {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, sentence)
+		}
+	}
+	return unmatched
+}
+
+// Splits a text into sentences, at sentence terminators followed by a space and at line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		end := text[i] == '\n'
+		if text[i] == '.' || text[i] == '!' || text[i] == '?' {
+			end = i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\n'
+		}
+		if end {
+			if sentence := strings.TrimSpace(text[start : i+1]); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = i + 1
+		}
+	}
+	if sentence := strings.TrimSpace(text[start:]); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+// Returns the words of a text that carry meaning, as used to match claims
+func significantWords(text string) map[string]bool {
+	significant := make(map[string]bool)
+	for _, word := range words(text) {
+		if len(word) >= 3 && !stopWords[word] {
+			significant[word] = true
+		}
+	}
+	return significant
+}
+
+// The size of the intersection of two sets divided by the size of their union, 1 for two empty sets
+func jaccard(a map[string]bool, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	intersection := 0
+	for key := range a {
+		if b[key] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// Get a summary of the comparison, laid out for a terminal
+func (c *Comparison) Summary() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Query: %s\n\n", c.Query)
+	table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "\t%s\t%s\n", Standard, Deep)
+	fmt.Fprintf(table, "Latency\t%s\t%s\n", c.Standard.Latency.Round(time.Millisecond), c.Deep.Latency.Round(time.Millisecond))
+	fmt.Fprintf(table, "Estimated cost\t%.4f\t%.4f\n", c.Standard.Cost, c.Deep.Cost)
+	fmt.Fprintf(table, "Sources\t%s\t%s\n", c.Standard.summarize(func(o *SourcedAnswerOutput) int { return len(o.Sources) }), c.Deep.summarize(func(o *SourcedAnswerOutput) int { return len(o.Sources) }))
+	fmt.Fprintf(table, "Answer words\t%s\t%s\n", c.Standard.summarize(answerWords), c.Deep.summarize(answerWords))
+	table.Flush()
+
+	for _, result := range []DepthResult{c.Standard, c.Deep} {
+		if result.Err != nil {
+			fmt.Fprintf(&builder, "\nThe %s search failed: %s\n", result.Depth, result.Err)
+		}
+	}
+	if c.Standard.Err != nil || c.Deep.Err != nil {
+		return builder.String()
+	}
+	if c.Standard.Latency > 0 {
+		fmt.Fprintf(&builder, "\nDeep took %.1fx the time and %.1fx the cost of standard.\n", float64(c.Deep.Latency)/float64(c.Standard.Latency), c.costRatio())
+	}
+	fmt.Fprintf(&builder, "\nSource overlap (Jaccard): %.2f (%d shared, %d standard only, %d deep only)\n", c.SourceOverlap, len(c.SharedSources), len(c.OnlyStandardSources), len(c.OnlyDeepSources))
+	for _, source := range c.OnlyDeepSources {
+		fmt.Fprintf(&builder, "  + %s\n", source.Url)
+	}
+	fmt.Fprintf(&builder, "Answer similarity (Jaccard): %.2f\n", c.AnswerSimilarity)
+	writeSentences(&builder, "standard", c.OnlyStandardSentences)
+	writeSentences(&builder, "deep", c.OnlyDeepSentences)
+	return builder.String()
+}
+
+func (c *Comparison) costRatio() float64 {
+	if c.Standard.Cost == 0 {
+		return 0
+	}
+	return c.Deep.Cost / c.Standard.Cost
+}
+
+func (r DepthResult) summarize(value func(*SourcedAnswerOutput) int) string {
+	if r.Output == nil {
+		return "-"
+	}
+	return fmt.Sprint(value(r.Output))
+}
+
+func answerWords(output *SourcedAnswerOutput) int {
+	return len(words(ParseCitations(output).Render(CitationNone)))
+}
+
+func writeSentences(builder *strings.Builder, depth string, sentences []string) {
+	if len(sentences) == 0 {
+		return
+	}
+	fmt.Fprintf(builder, "\nSentences only in the %s answer (%d):\n", depth, len(sentences))
+	for _, sentence := range sentences {
+		fmt.Fprintf(builder, "  - %s\n", sentence)
+	}
+}
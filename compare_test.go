@@ -0,0 +1,129 @@
+package linkup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type DepthMockClient struct {
+	MockClient
+	answers map[SearchDepth]SourcedAnswerDto
+}
+
+func (m *DepthMockClient) SearchWithResponse(ctx context.Context, body SearchJSONRequestBody, requestEditors ...RequestEditorFn) (*SearchResponse, error) {
+	answer, ok := m.answers[body.Depth]
+	if !ok || body.OutputType != SourcedAnswer {
+		return &SearchResponse{
+			Body:         []byte("an error occurred"),
+			HTTPResponse: &http.Response{Status: "500 Internal Server Error", StatusCode: 500},
+		}, nil
+	}
+	marshaled, err := json.Marshal(answer)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResponse{Body: marshaled, HTTPResponse: &http.Response{Status: "200 OK", StatusCode: 200}}, nil
+}
+
+func TestCompare(t *testing.T) {
+	mock := &DepthMockClient{answers: map[SearchDepth]SourcedAnswerDto{
+		Standard: {
+			Answer: "Go is an open source programming language [1]. It was created at Google [2].",
+			Sources: []SourceDto{
+				{Name: "Go", Url: "https://go.dev/"},
+				{Name: "Wikipedia", Url: "https://en.wikipedia.org/wiki/Go_(programming_language)"},
+			},
+		},
+		Deep: {
+			Answer: "Go is an open source programming language [1]. It was designed by Griesemer, Pike and Thompson [2]. Version 1.0 shipped in March 2012 [3].",
+			Sources: []SourceDto{
+				{Name: "Go", Url: "http://www.go.dev"},
+				{Name: "FAQ", Url: "https://go.dev/doc/faq"},
+				{Name: "Go 1", Url: "https://go.dev/blog/go1"},
+			},
+		},
+	}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	comparison, err := client.Compare("What is Go?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comparison.Standard.Err != nil || comparison.Deep.Err != nil {
+		t.Fatalf("unexpected errors: %v, %v", comparison.Standard.Err, comparison.Deep.Err)
+	}
+	if comparison.Standard.Cost != 0.005 || comparison.Deep.Cost != 0.05 {
+		t.Fatalf("unexpected costs: %v, %v", comparison.Standard.Cost, comparison.Deep.Cost)
+	}
+	// 1 shared source out of 4 distinct ones
+	if comparison.SourceOverlap != 0.25 || len(comparison.SharedSources) != 1 || len(comparison.OnlyStandardSources) != 1 || len(comparison.OnlyDeepSources) != 2 {
+		t.Fatalf("unexpected source overlap: %v, %+v", comparison.SourceOverlap, comparison)
+	}
+	if len(comparison.OnlyStandardSentences) != 1 || comparison.OnlyStandardSentences[0] != "It was created at Google." {
+		t.Fatalf("unexpected standard sentences: %q", comparison.OnlyStandardSentences)
+	}
+	if len(comparison.OnlyDeepSentences) != 2 {
+		t.Fatalf("unexpected deep sentences: %q", comparison.OnlyDeepSentences)
+	}
+	if comparison.AnswerSimilarity <= 0 || comparison.AnswerSimilarity >= 1 {
+		t.Fatalf("unexpected answer similarity: %v", comparison.AnswerSimilarity)
+	}
+
+	summary := comparison.Summary()
+	for _, expected := range []string{
+		"Query: What is Go?",
+		"Estimated cost  0.0050    0.0500",
+		"Sources         2         3",
+		"Source overlap (Jaccard): 0.25 (1 shared, 1 standard only, 2 deep only)",
+		"  + https://go.dev/blog/go1",
+		"Sentences only in the standard answer (1):\n  - It was created at Google.",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Fatalf("expected %q in the summary:\n%s", expected, summary)
+		}
+	}
+}
+
+func TestCompareFailures(t *testing.T) {
+	mock := &DepthMockClient{answers: map[SearchDepth]SourcedAnswerDto{
+		Standard: {Answer: "Go is a language.", Sources: []SourceDto{{Url: "https://go.dev"}}},
+	}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	options := DefaultCompareOptions()
+	options.DeepCost = 0.1
+	comparison, err := client.Compare("What is Go?", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comparison.Deep.Err == nil || comparison.Deep.Output != nil || comparison.Deep.Cost != 0.1 {
+		t.Fatalf("expected the deep search to fail, got %+v", comparison.Deep)
+	}
+	summary := comparison.Summary()
+	if !strings.Contains(summary, "The deep search failed") || strings.Contains(summary, "Source overlap") {
+		t.Fatalf("unexpected summary:\n%s", summary)
+	}
+
+	// unset costs fall back to the defaults
+	comparison, err = client.Compare("What is Go?", CompareOptions{SearchOptions: DefaultAdditionalSearchOptions()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comparison.Standard.Cost != 0.005 || comparison.Deep.Cost != 0.05 {
+		t.Fatalf("expected the default costs, got %v and %v", comparison.Standard.Cost, comparison.Deep.Cost)
+	}
+
+	client = &LinkupClient{apiKey: "test", client: &DepthMockClient{}}
+	if _, err := client.Compare("What is Go?"); err == nil {
+		t.Fatal("expected an error when both searches fail")
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	sentences := splitSentences("Go 1.22 is out! Is it fast? Yes.\nA list item\n\nversion 1.0")
+	expected := []string{"Go 1.22 is out!", "Is it fast?", "Yes.", "A list item", "version 1.0"}
+	if strings.Join(sentences, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %q, got %q", expected, sentences)
+	}
+}
@@ -0,0 +1,300 @@
+package linkup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Struct type representing a Linkup configuration file, holding one or more named profiles.
+// Configuration files can be written in YAML, TOML or JSON, and are told apart by their extension.
+type Config struct {
+	// DefaultProfile The profile used when LINKUP_PROFILE is not set. Defaults to `default`.
+	DefaultProfile string `json:"default_profile" yaml:"default_profile" toml:"default_profile"`
+
+	// Profiles The named profiles available in the configuration.
+	Profiles map[string]Profile `json:"profiles" yaml:"profiles" toml:"profiles"`
+}
+
+// Struct type representing the settings for a single Linkup account or environment
+type Profile struct {
+	// ApiKey The API key, inline. Prefer ApiKeyEnv or ApiKeyFile to keep secrets out of the configuration.
+	ApiKey string `json:"api_key,omitempty" yaml:"api_key,omitempty" toml:"api_key,omitempty"`
+
+	// ApiKeyEnv The environment variable holding the API key.
+	ApiKeyEnv string `json:"api_key_env,omitempty" yaml:"api_key_env,omitempty" toml:"api_key_env,omitempty"`
+
+	// ApiKeyFile The file holding the API key, read again whenever it changes.
+	ApiKeyFile string `json:"api_key_file,omitempty" yaml:"api_key_file,omitempty" toml:"api_key_file,omitempty"`
+
+	// ApiKeys Several API keys to be used as a KeyPool.
+	ApiKeys []string `json:"api_keys,omitempty" yaml:"api_keys,omitempty" toml:"api_keys,omitempty"`
+
+	// KeyStrategy How the keys in ApiKeys are used: `round_robin` (default) or `failover`.
+	KeyStrategy string `json:"key_strategy,omitempty" yaml:"key_strategy,omitempty" toml:"key_strategy,omitempty"`
+
+	// BaseUrl The server the requests are sent to. Defaults to LinkupServerUrl.
+	BaseUrl string `json:"base_url,omitempty" yaml:"base_url,omitempty" toml:"base_url,omitempty"`
+
+	// DefaultDepth The depth used when search methods are called with an empty depth: `standard` or `deep`.
+	DefaultDepth string `json:"default_depth,omitempty" yaml:"default_depth,omitempty" toml:"default_depth,omitempty"`
+
+	// SearchOptions The default search options, merged with the options of every search call (see `MergeSearchOptions`).
+	SearchOptions *ProfileSearchOptions `json:"search_options,omitempty" yaml:"search_options,omitempty" toml:"search_options,omitempty"`
+
+	// Retry The retry policy for failed requests.
+	Retry *ProfileRetry `json:"retry,omitempty" yaml:"retry,omitempty" toml:"retry,omitempty"`
+
+	// Timeout The timeout for every request, as a Go duration string (e.g. `30s`).
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty" toml:"timeout,omitempty"`
+
+	// Deduplicate Whether the results and sources of the searches are deduplicated by canonical URL.
+	Deduplicate bool `json:"deduplicate,omitempty" yaml:"deduplicate,omitempty" toml:"deduplicate,omitempty"`
+}
+
+// Struct type representing the default AdditionalSearchOptions of a profile
+type ProfileSearchOptions struct {
+	ExcludeDomains         []string `json:"exclude_domains,omitempty" yaml:"exclude_domains,omitempty" toml:"exclude_domains,omitempty"`
+	IncludeDomains         []string `json:"include_domains,omitempty" yaml:"include_domains,omitempty" toml:"include_domains,omitempty"`
+	FromDate               string   `json:"from_date,omitempty" yaml:"from_date,omitempty" toml:"from_date,omitempty"`
+	ToDate                 string   `json:"to_date,omitempty" yaml:"to_date,omitempty" toml:"to_date,omitempty"`
+	IncludeImages          bool     `json:"include_images,omitempty" yaml:"include_images,omitempty" toml:"include_images,omitempty"`
+	IncludeInlineCitations bool     `json:"include_inline_citations,omitempty" yaml:"include_inline_citations,omitempty" toml:"include_inline_citations,omitempty"`
+	IncludeSources         bool     `json:"include_sources,omitempty" yaml:"include_sources,omitempty" toml:"include_sources,omitempty"`
+	MaxResults             int      `json:"max_results,omitempty" yaml:"max_results,omitempty" toml:"max_results,omitempty"`
+}
+
+// Struct type representing the RetryPolicy of a profile, with durations as Go duration strings
+type ProfileRetry struct {
+	MaxAttempts    int    `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty" toml:"max_attempts,omitempty"`
+	InitialBackoff string `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty" toml:"initial_backoff,omitempty"`
+	MaxBackoff     string `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty" toml:"max_backoff,omitempty"`
+}
+
+// Load a configuration file, choosing the format (YAML, TOML or JSON) from its extension
+func LoadConfig(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&config)
+	case ".toml":
+		var metadata toml.MetaData
+		metadata, err = toml.Decode(string(content), &config)
+		if err == nil && len(metadata.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown configuration keys: %v", metadata.Undecoded())
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&config)
+	default:
+		return nil, fmt.Errorf("unsupported configuration file extension %q: use .yaml, .yml, .toml or .json", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse configuration file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Get a profile by name. An empty name selects the profile named by LINKUP_PROFILE,
+// then the default profile of the configuration, then the profile called `default`.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = os.Getenv("LINKUP_PROFILE")
+	}
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		name = "default"
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		available := make([]string, 0, len(c.Profiles))
+		for profileName := range c.Profiles {
+			available = append(available, profileName)
+		}
+		sort.Strings(available)
+		return nil, fmt.Errorf("profile %q not found in the configuration (available profiles: %s)", name, strings.Join(available, ", "))
+	}
+	return &profile, nil
+}
+
+// Overrides the values of the profile with the ones found in the environment:
+// LINKUP_API_KEY, LINKUP_BASE_URL, LINKUP_DEFAULT_DEPTH, LINKUP_TIMEOUT and LINKUP_MAX_ATTEMPTS
+func (p Profile) withEnvironment() (Profile, error) {
+	if key, ok := os.LookupEnv("LINKUP_API_KEY"); ok && key != "" {
+		p.ApiKey = key
+		p.ApiKeyEnv = ""
+		p.ApiKeyFile = ""
+		p.ApiKeys = nil
+	}
+	if baseUrl, ok := os.LookupEnv("LINKUP_BASE_URL"); ok && baseUrl != "" {
+		p.BaseUrl = baseUrl
+	}
+	if depth, ok := os.LookupEnv("LINKUP_DEFAULT_DEPTH"); ok && depth != "" {
+		p.DefaultDepth = depth
+	}
+	if timeout, ok := os.LookupEnv("LINKUP_TIMEOUT"); ok && timeout != "" {
+		p.Timeout = timeout
+	}
+	if attempts, ok := os.LookupEnv("LINKUP_MAX_ATTEMPTS"); ok && attempts != "" {
+		maxAttempts, err := strconv.Atoi(attempts)
+		if err != nil {
+			return p, fmt.Errorf("invalid LINKUP_MAX_ATTEMPTS: %w", err)
+		}
+		retry := ProfileRetry{}
+		if p.Retry != nil {
+			retry = *p.Retry
+		}
+		retry.MaxAttempts = maxAttempts
+		p.Retry = &retry
+	}
+	return p, nil
+}
+
+// Get the client options corresponding to the profile, after applying the environment overrides
+func (p Profile) Options() ([]LinkupClientOption, error) {
+	p, err := p.withEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	var opts []LinkupClientOption
+	keys, err := p.keyProvider()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, WithKeyProvider(keys))
+	if p.BaseUrl != "" {
+		opts = append(opts, WithServerUrl(p.BaseUrl))
+	}
+	if p.DefaultDepth != "" {
+		opts = append(opts, WithDefaultDepth(SearchDepth(p.DefaultDepth)))
+	}
+	if p.SearchOptions != nil {
+		opts = append(opts, WithDefaultSearchOptions(p.SearchOptions.toAdditionalSearchOptions()))
+	}
+	if p.Retry != nil {
+		policy := DefaultRetryPolicy()
+		if p.Retry.MaxAttempts != 0 {
+			policy.MaxAttempts = p.Retry.MaxAttempts
+		}
+		if policy.InitialBackoff, err = parseProfileDuration("retry.initial_backoff", p.Retry.InitialBackoff, policy.InitialBackoff); err != nil {
+			return nil, err
+		}
+		if policy.MaxBackoff, err = parseProfileDuration("retry.max_backoff", p.Retry.MaxBackoff, policy.MaxBackoff); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithRetry(policy))
+	}
+	if p.Timeout != "" {
+		timeout, err := parseProfileDuration("timeout", p.Timeout, 0)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithTimeout(timeout))
+	}
+	if p.Deduplicate {
+		opts = append(opts, WithDeduplication())
+	}
+	return opts, nil
+}
+
+func (p Profile) keyProvider() (KeyProvider, error) {
+	switch {
+	case len(p.ApiKeys) > 0:
+		strategy := RoundRobinKeys
+		switch p.KeyStrategy {
+		case "", "round_robin":
+		case "failover":
+			strategy = FailoverKeys
+		default:
+			return nil, fmt.Errorf("unsupported key strategy %q: use round_robin or failover", p.KeyStrategy)
+		}
+		return NewKeyPool(strategy, 0, p.ApiKeys...)
+	case p.ApiKeyFile != "":
+		return NewFileKeyProvider(p.ApiKeyFile)
+	case p.ApiKeyEnv != "":
+		return EnvKeyProvider{Name: p.ApiKeyEnv}, nil
+	case p.ApiKey != "":
+		return StaticKeyProvider(p.ApiKey), nil
+	default:
+		return nil, errors.New("the profile does not define any api key source (api_key, api_key_env, api_key_file or api_keys) and LINKUP_API_KEY is not set")
+	}
+}
+
+func (o ProfileSearchOptions) toAdditionalSearchOptions() AdditionalSearchOptions {
+	options := DefaultAdditionalSearchOptions()
+	options.ExcludeDomains = o.ExcludeDomains
+	options.IncludeDomains = o.IncludeDomains
+	options.IncludeImages = o.IncludeImages
+	options.IncludeInlineCitations = o.IncludeInlineCitations
+	options.IncludeSources = o.IncludeSources
+	if o.FromDate != "" {
+		fromDate := o.FromDate
+		options.FromDate = &fromDate
+	}
+	if o.ToDate != "" {
+		toDate := o.ToDate
+		options.ToDate = &toDate
+	}
+	if o.MaxResults > 0 {
+		maxResults := float32(o.MaxResults)
+		options.MaxResults = &maxResults
+	}
+	return options
+}
+
+func parseProfileDuration(field string, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return duration, nil
+}
+
+// Constructor to create a new LinkupClient instance from a configuration file.
+// If the path is empty, it is loaded from the LINKUP_CONFIG environment variable.
+// The profile is selected with LINKUP_PROFILE (see `Config.Profile`), and environment variables
+// override the values of the file. Additional options are applied after the ones of the profile.
+func NewLinkupClientFromConfig(path string, opts ...LinkupClientOption) (*LinkupClient, error) {
+	if path == "" {
+		configPath, ok := os.LookupEnv("LINKUP_CONFIG")
+		if !ok || configPath == "" {
+			return nil, errors.New("configuration path not provided and could not find LINKUP_CONFIG in the environment")
+		}
+		path = configPath
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := config.Profile("")
+	if err != nil {
+		return nil, err
+	}
+	profileOpts, err := profile.Options()
+	if err != nil {
+		return nil, err
+	}
+	return NewLinkupClient("", append(profileOpts, opts...)...)
+}
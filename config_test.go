@@ -0,0 +1,161 @@
+package linkup
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFormats(t *testing.T) {
+	for _, path := range []string{"testfiles/config.yaml", "testfiles/config.toml", "testfiles/config.json"} {
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("Unexpected error loading %s: %s", path, err.Error())
+		}
+		if config.DefaultProfile != "prod" || len(config.Profiles) != 2 {
+			t.Fatalf("Unexpected configuration from %s: %+v", path, config)
+		}
+		prod := config.Profiles["prod"]
+		if prod.ApiKey != "prod-key" || prod.DefaultDepth != "deep" || prod.Timeout != "30s" {
+			t.Fatalf("Unexpected prod profile from %s: %+v", path, prod)
+		}
+		if prod.SearchOptions == nil || !slices.Equal(prod.SearchOptions.ExcludeDomains, []string{"example.com", "spam.net"}) || prod.SearchOptions.MaxResults != 10 {
+			t.Fatalf("Unexpected search options from %s: %+v", path, prod.SearchOptions)
+		}
+		if prod.Retry == nil || prod.Retry.MaxAttempts != 4 || prod.Retry.InitialBackoff != "250ms" {
+			t.Fatalf("Unexpected retry settings from %s: %+v", path, prod.Retry)
+		}
+		research := config.Profiles["research"]
+		if !slices.Equal(research.ApiKeys, []string{"research-1", "research-2"}) || research.KeyStrategy != "failover" {
+			t.Fatalf("Unexpected research profile from %s: %+v", path, research)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	unknown := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(unknown, []byte(`{"profiles": {"a": {"api_kye": "typo"}}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(unknown); err == nil {
+		t.Fatal("Expected an error for an unknown configuration key")
+	}
+	unsupported := filepath.Join(dir, "config.ini")
+	if err := os.WriteFile(unsupported, []byte(""), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadConfig(unsupported)
+	if err == nil || !strings.Contains(err.Error(), "unsupported configuration file extension") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestConfigProfileSelection(t *testing.T) {
+	config, err := LoadConfig("testfiles/config.yaml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	t.Setenv("LINKUP_PROFILE", "")
+	profile, err := config.Profile("")
+	if err != nil || profile.ApiKey != "prod-key" {
+		t.Fatalf("Expected the default profile, got %+v (error: %v)", profile, err)
+	}
+	t.Setenv("LINKUP_PROFILE", "research")
+	profile, err = config.Profile("")
+	if err != nil || profile.KeyStrategy != "failover" {
+		t.Fatalf("Expected the research profile, got %+v (error: %v)", profile, err)
+	}
+	_, err = config.Profile("staging")
+	if err == nil || err.Error() != `profile "staging" not found in the configuration (available profiles: prod, research)` {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestNewLinkupClientFromConfig(t *testing.T) {
+	var attempts atomic.Int64
+	var body SearchJSONRequestBody
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"answer": "42", "sources": []}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "linkup.yaml")
+	config := `
+profiles:
+  default:
+    api_key_env: TEST_LINKUP_PROFILE_KEY
+    base_url: http://localhost:1
+    default_depth: deep
+    search_options:
+      exclude_domains: [example.com]
+    retry:
+      max_attempts: 2
+      initial_backoff: 1ms
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LINKUP_PROFILE", "")
+	t.Setenv("LINKUP_API_KEY", "")
+	t.Setenv("TEST_LINKUP_PROFILE_KEY", "from-profile-env")
+	t.Setenv("LINKUP_BASE_URL", server.URL)
+	t.Setenv("LINKUP_CONFIG", path)
+
+	client, err := NewLinkupClientFromConfig("", WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	output, err := client.GetSourcedAnswer("question", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if output.Answer != "42" {
+		t.Fatalf("Unexpected answer: %s", output.Answer)
+	}
+	if attempts.Load() != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", attempts.Load())
+	}
+	if authorization != "Bearer from-profile-env" {
+		t.Fatalf("Unexpected authorization header: %s", authorization)
+	}
+	if body.Depth != Deep || body.ExcludeDomains == nil || !slices.Equal(*body.ExcludeDomains, []string{"example.com"}) {
+		t.Fatalf("Unexpected request body: %+v", body)
+	}
+
+	t.Setenv("LINKUP_API_KEY", "from-env-override")
+	if _, err := client.GetSourcedAnswer("question", Standard); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if authorization != "Bearer from-profile-env" {
+		t.Fatal("Environment overrides should only be applied when the client is created")
+	}
+	client, err = NewLinkupClientFromConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if _, err := client.GetSourcedAnswer("question", Standard); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if authorization != "Bearer from-env-override" {
+		t.Fatalf("Unexpected authorization header: %s", authorization)
+	}
+	if body.Depth != Standard {
+		t.Fatalf("Expected the explicit depth to be used, got %s", body.Depth)
+	}
+}
@@ -0,0 +1,230 @@
+package linkup
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+)
+
+// Options to build LLM contexts with a `ContextBuilder`
+type ContextOptions struct {
+	// Budget The maximum size of the prompt block, in Unit. Zero means no limit.
+	Budget int
+
+	// Unit The unit of Budget, MaxPerSource and MinPerSource.
+	Unit ChunkUnit
+
+	// MaxPerSource The maximum size of the content of a source before the leftover budget is
+	// shared, so that the top-ranked sources do not take the whole budget. Zero means no limit.
+	MaxPerSource int
+
+	// MinPerSource The minimum size of content a source must get to be included in the prompt.
+	MinPerSource int
+}
+
+func DefaultContextOptions() ContextOptions {
+	return ContextOptions{
+		Budget:       4000,
+		Unit:         ChunkTokens,
+		MaxPerSource: 1000,
+		MinPerSource: 50,
+	}
+}
+
+// Struct type representing a context built for an LLM prompt
+type LLMContext struct {
+	// Prompt The sources, ready to be put in a prompt: each of them starts with its ID between brackets.
+	Prompt string
+
+	// Sources The sources included in the prompt, keyed by ID, with their full content.
+	Sources map[int]TextSearchResultDto
+
+	// Ids The IDs of the sources included in the prompt, in order.
+	Ids []int
+
+	// Truncated The IDs of the sources whose content was truncated to fit in the budget.
+	Truncated []int
+
+	// Omitted The IDs of the sources left out of the prompt because the budget was exhausted.
+	Omitted []int
+
+	// Size The size of the prompt, in the unit of the options.
+	Size int
+}
+
+// A builder of LLM contexts from search results and sourced answers. Sources are deduplicated
+// by URL and get stable citation IDs, starting from 1 in the order they are added: adding more
+// results later never changes the IDs of the sources already added. The order of addition is
+// also the priority of the sources when the budget is tight.
+// A ContextBuilder is not safe for concurrent use.
+type ContextBuilder struct {
+	options ContextOptions
+	sources []TextSearchResultDto
+	ids     map[string]int
+}
+
+func NewContextBuilder(contextOptions ...ContextOptions) *ContextBuilder {
+	var options ContextOptions
+	switch len(contextOptions) {
+	case 0:
+		options = DefaultContextOptions()
+	default:
+		options = contextOptions[0]
+	}
+	return &ContextBuilder{options: options, ids: make(map[string]int)}
+}
+
+// Add a source, returning its ID. A source whose URL was already added keeps the ID
+// and content of the first one, unless that one had no content.
+func (b *ContextBuilder) Add(result TextSearchResultDto) int {
+	key := canonical.URL(result.Url)
+	if id, ok := b.ids[key]; ok {
+		if strings.TrimSpace(b.sources[id-1].Content) == "" {
+			b.sources[id-1].Content = result.Content
+		}
+		return id
+	}
+	b.sources = append(b.sources, result)
+	id := len(b.sources)
+	b.ids[key] = id
+	return id
+}
+
+// Add the text results of a search, in rank order, returning their IDs
+func (b *ContextBuilder) AddSearchResults(output *SearchResultsOutput) []int {
+	ids := make([]int, len(output.TextResults))
+	for i, result := range output.TextResults {
+		ids[i] = b.Add(result)
+	}
+	return ids
+}
+
+// Add the sources of a sourced answer, using their snippets as content, and return their IDs
+func (b *ContextBuilder) AddSourcedAnswer(output *SourcedAnswerOutput) []int {
+	ids := make([]int, len(output.Sources))
+	for i, source := range output.Sources {
+		ids[i] = b.Add(TextSearchResultDto{
+			Name:    source.Name,
+			Url:     source.Url,
+			Content: source.Snippet,
+			Favicon: source.Favicon,
+			Type:    "text",
+		})
+	}
+	return ids
+}
+
+// Build the context: the sources are included in order until the budget is exhausted. Each
+// of them first gets up to MaxPerSource of content, then the leftover budget goes to the
+// truncated sources, in order. Content is truncated on a word boundary, with an ellipsis.
+func (b *ContextBuilder) Build() *LLMContext {
+	built := &LLMContext{Sources: make(map[int]TextSearchResultDto)}
+	runes := func(size int) int {
+		if b.options.Unit == ChunkTokens {
+			return size * 4
+		}
+		return size
+	}
+	limit := -1
+	if b.options.Budget > 0 {
+		limit = runes(b.options.Budget)
+	}
+
+	type entry struct {
+		id      int
+		header  string
+		content string
+		length  int
+		allowed int
+	}
+	var entries []*entry
+	used := 0
+	for i, source := range b.sources {
+		id := i + 1
+		content := strings.TrimSpace(multipleBlankLines.ReplaceAllString(source.Content, "\n\n"))
+		current := &entry{
+			id:      id,
+			header:  fmt.Sprintf("[%d] %s\nURL: %s\n", id, strings.Join(strings.Fields(source.Name), " "), source.Url),
+			content: content,
+			length:  utf8.RuneCountInString(content),
+		}
+		overhead := utf8.RuneCountInString(current.header)
+		if len(entries) > 0 {
+			// the blank line separating the sources
+			overhead += 2
+		}
+		current.allowed = current.length
+		if b.options.MaxPerSource > 0 {
+			current.allowed = min(current.allowed, runes(b.options.MaxPerSource))
+		}
+		if limit >= 0 {
+			available := limit - used - overhead
+			if available < min(runes(b.options.MinPerSource), current.length) || available < 0 {
+				built.Omitted = append(built.Omitted, id)
+				continue
+			}
+			current.allowed = min(current.allowed, available)
+		}
+		used += overhead + current.allowed
+		entries = append(entries, current)
+	}
+	// the leftover budget goes to the truncated sources, by priority
+	for _, current := range entries {
+		if current.allowed < current.length {
+			extra := current.length - current.allowed
+			if limit >= 0 {
+				extra = min(extra, limit-used)
+			}
+			current.allowed += extra
+			used += extra
+		}
+	}
+
+	parts := make([]string, len(entries))
+	for i, current := range entries {
+		content := current.content
+		if current.allowed < current.length {
+			content = truncateRunes(content, current.allowed)
+			built.Truncated = append(built.Truncated, current.id)
+		}
+		parts[i] = strings.TrimRight(current.header+content, "\n")
+		built.Ids = append(built.Ids, current.id)
+		built.Sources[current.id] = b.sources[current.id-1]
+	}
+	built.Prompt = strings.Join(parts, "\n\n")
+	built.Size = utf8.RuneCountInString(built.Prompt)
+	if b.options.Unit == ChunkTokens {
+		built.Size = (built.Size + 3) / 4
+	}
+	return built
+}
+
+// Truncates a text to at most `limit` runes, on a word boundary when possible, ending with an ellipsis
+func truncateRunes(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	if limit <= 0 {
+		return ""
+	}
+	// the byte offset of the rune following the kept ones, keeping room for the ellipsis
+	cut, count := len(text), 0
+	for i := range text {
+		if count == limit-1 {
+			cut = i
+			break
+		}
+		count++
+	}
+	kept := text[:cut]
+	// the last word is dropped, unless it ends right at the cut
+	if next, _ := utf8.DecodeRuneInString(text[cut:]); !unicode.IsSpace(next) {
+		if space := strings.LastIndexFunc(kept, unicode.IsSpace); space > 0 {
+			kept = kept[:space]
+		}
+	}
+	return strings.TrimRightFunc(kept, unicode.IsSpace) + "…"
+}
@@ -0,0 +1,103 @@
+package linkup
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestContextBuilderIds(t *testing.T) {
+	builder := NewContextBuilder(ContextOptions{})
+	ids := builder.AddSearchResults(&SearchResultsOutput{TextResults: []TextSearchResultDto{
+		{Name: "Go", Url: "https://go.dev/", Content: "The Go programming language."},
+		{Name: "Wikipedia", Url: "https://en.wikipedia.org/wiki/Go", Content: ""},
+		{Name: "Go again", Url: "https://GO.dev", Content: "Duplicate."},
+	}})
+	if !slices.Equal(ids, []int{1, 2, 1}) {
+		t.Fatalf("Unexpected IDs: %v", ids)
+	}
+	ids = builder.AddSourcedAnswer(&SourcedAnswerOutput{Sources: []SourceDto{
+		{Name: "Wiki", Url: "https://en.wikipedia.org/wiki/Go#history", Snippet: "Go was designed at Google."},
+		{Name: "Blog", Url: "https://blog.example.com", Snippet: "A blog post."},
+	}})
+	if !slices.Equal(ids, []int{2, 3}) {
+		t.Fatalf("Unexpected IDs: %v", ids)
+	}
+	built := builder.Build()
+	expected := "[1] Go\nURL: https://go.dev/\nThe Go programming language.\n\n" +
+		"[2] Wikipedia\nURL: https://en.wikipedia.org/wiki/Go\nGo was designed at Google.\n\n" +
+		"[3] Blog\nURL: https://blog.example.com\nA blog post."
+	if built.Prompt != expected {
+		t.Fatalf("Unexpected prompt:\n%s", built.Prompt)
+	}
+	if built.Sources[2].Content != "Go was designed at Google." || built.Sources[1].Content != "The Go programming language." {
+		t.Fatalf("Unexpected sources: %+v", built.Sources)
+	}
+	if !slices.Equal(built.Ids, []int{1, 2, 3}) || built.Truncated != nil || built.Omitted != nil {
+		t.Fatalf("Unexpected context: %+v", built)
+	}
+	if built.Size != utf8.RuneCountInString(expected) {
+		t.Fatalf("Unexpected size: %d", built.Size)
+	}
+}
+
+func TestContextBuilderBudget(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	results := &SearchResultsOutput{TextResults: []TextSearchResultDto{
+		{Name: "First", Url: "https://a.com", Content: long},
+		{Name: "Second", Url: "https://b.com", Content: "Short content."},
+		{Name: "Third", Url: "https://c.com", Content: long},
+		{Name: "Fourth", Url: "https://d.com", Content: long},
+	}}
+	builder := NewContextBuilder(ContextOptions{Budget: 300, Unit: ChunkCharacters, MaxPerSource: 100, MinPerSource: 40})
+	builder.AddSearchResults(results)
+	built := builder.Build()
+	if utf8.RuneCountInString(built.Prompt) > 300 || built.Size > 300 {
+		t.Fatalf("The prompt exceeds the budget: %d characters", built.Size)
+	}
+	if !slices.Equal(built.Ids, []int{1, 2, 3}) || !slices.Equal(built.Omitted, []int{4}) || !slices.Equal(built.Truncated, []int{1, 3}) {
+		t.Fatalf("Unexpected context: ids %v, omitted %v, truncated %v", built.Ids, built.Omitted, built.Truncated)
+	}
+	content := strings.TrimPrefix(strings.SplitN(built.Prompt, "\n\n", 2)[0], "[1] First\nURL: https://a.com\n")
+	if !strings.HasSuffix(content, "word…") || utf8.RuneCountInString(content) > 100 {
+		t.Fatalf("Unexpected content for the first source: %q", content)
+	}
+	if built.Sources[1].Content != long {
+		t.Fatal("Expected the full content in the mapping")
+	}
+
+	// the leftover budget goes back to the truncated sources
+	leftover := NewContextBuilder(ContextOptions{Budget: 300, Unit: ChunkCharacters, MaxPerSource: 100, MinPerSource: 40})
+	leftover.AddSearchResults(&SearchResultsOutput{TextResults: results.TextResults[:2]})
+	built = leftover.Build()
+	content = strings.TrimPrefix(strings.SplitN(built.Prompt, "\n\n", 2)[0], "[1] First\nURL: https://a.com\n")
+	if utf8.RuneCountInString(content) <= 200 || built.Size > 300 || !slices.Equal(built.Truncated, []int{1}) {
+		t.Fatalf("Unexpected content for the first source: %q", content)
+	}
+
+	tokens := NewContextBuilder(ContextOptions{Budget: 40, Unit: ChunkTokens})
+	tokens.AddSearchResults(results)
+	if built := tokens.Build(); built.Size > 40 || len(built.Ids) != 1 {
+		t.Fatalf("Unexpected context with a token budget: %+v", built)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	cases := []struct {
+		text     string
+		limit    int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"hello wonderful world", 12, "hello…"},
+		{"hello world again", 12, "hello world…"},
+		{"unbreakable", 5, "unbr…"},
+		{"héllo wörld", 8, "héllo…"},
+	}
+	for _, c := range cases {
+		if got := truncateRunes(c.text, c.limit); got != c.expected {
+			t.Fatalf("Unexpected truncation of %q to %d: %q", c.text, c.limit, got)
+		}
+	}
+}
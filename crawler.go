@@ -0,0 +1,381 @@
+package linkup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+	"github.com/AstraBert/linkup-go-sdk/fetch/markdown"
+)
+
+// Options to crawl a website with a `Crawler`
+type CrawlOptions struct {
+	// AllowedDomains The domains whose pages can be crawled, including their subdomains.
+	// By default, the domains of the seed URLs.
+	AllowedDomains []string
+
+	// AllowedPathPrefixes The path prefixes of the pages that can be crawled, e.g. `/docs/`.
+	// By default, any path is allowed. Seed URLs are always crawled.
+	AllowedPathPrefixes []string
+
+	// MaxDepth The maximum number of links followed from a seed URL: with a depth of 0, only the seeds are crawled.
+	MaxDepth int
+
+	// MaxPages The maximum number of pages fetched, including the ones fetched before resuming.
+	// Pages whose fetch failed count as fetched: they are reported with their error and not retried.
+	MaxPages int
+
+	// Fetch The options used to fetch the pages: concurrency, politeness and fetch options.
+	Fetch FetchManyOptions
+
+	// StatePath The file where the state of the crawl is saved after every page, so that an
+	// interrupted crawl can be resumed by a new `Crawler` using the same file. By default, the state is not saved.
+	StatePath string
+}
+
+func DefaultCrawlOptions() CrawlOptions {
+	return CrawlOptions{
+		MaxDepth: 2,
+		MaxPages: 50,
+		Fetch:    DefaultFetchManyOptions(),
+	}
+}
+
+// Struct type representing a page visited by a `Crawler`
+type CrawledPage struct {
+	// Url The URL of the page.
+	Url string
+
+	// Depth The number of links followed from a seed URL to reach the page.
+	Depth int
+
+	// Parent The URL of the page where the link to this page was first found, empty for seeds.
+	Parent string
+
+	// Links The normalized URLs (see `canonical.Normalize`) of the HTTP(S) links found in the page, crawled or not.
+	Links []string
+
+	// Output The fetched page, nil if the fetch failed.
+	Output *FetchOutput
+
+	// Err The error that occurred while fetching the page, if any.
+	Err error
+}
+
+// A crawler fetching pages from seed URLs and following the links found in their markdown,
+// within the allowed domains and path prefixes, breadth first
+type Crawler struct {
+	client  *LinkupClient
+	options CrawlOptions
+
+	mu    sync.Mutex
+	state *crawlState
+	err   error
+}
+
+// Persisted state of a crawl
+type crawlState struct {
+	// Visited The canonical URLs of the pages fetched, with their depth
+	Visited map[string]int `json:"visited"`
+	// Pending The pages still to fetch, in crawl order
+	Pending []crawlItem `json:"pending"`
+	// Graph The canonical URLs of the links found in every fetched page
+	Graph map[string][]string `json:"graph"`
+}
+
+type crawlItem struct {
+	Url    string `json:"url"`
+	Depth  int    `json:"depth"`
+	Parent string `json:"parent,omitempty"`
+}
+
+func NewCrawler(client *LinkupClient, options CrawlOptions) *Crawler {
+	if options.MaxPages <= 0 {
+		options.MaxPages = DefaultCrawlOptions().MaxPages
+	}
+	if options.MaxDepth < 0 {
+		options.MaxDepth = 0
+	}
+	return &Crawler{client: client, options: options}
+}
+
+// Crawl the pages reachable from the seed URLs and stream them back through the returned channel,
+// which is closed when there is nothing left to crawl, the page budget is exhausted or the context
+// is cancelled. When the state file of a previous crawl exists, the crawl resumes from it and the
+// seeds already known are skipped. Pages whose fetch failed are reported with their error and count
+// towards `MaxPages`. Errors while saving the state stop the crawl and are returned by `Err`.
+func (c *Crawler) Crawl(ctx context.Context, seeds ...string) (<-chan CrawledPage, error) {
+	state, err := c.loadState()
+	if err != nil {
+		return nil, err
+	}
+	// the domains of the seeds are derived for each crawl, without changing the options
+	domains := slices.Clone(c.options.AllowedDomains)
+	if len(domains) == 0 {
+		for _, seed := range seeds {
+			parsed, err := url.Parse(canonical.URL(seed))
+			if err != nil {
+				continue
+			}
+			if host := parsed.Hostname(); host != "" && !slices.Contains(domains, host) {
+				domains = append(domains, host)
+			}
+		}
+	}
+	known := state.known()
+	for _, seed := range seeds {
+		key := canonical.URL(seed)
+		if !known[key] {
+			known[key] = true
+			state.Pending = append(state.Pending, crawlItem{Url: seed})
+		}
+	}
+	// seeds added when resuming come before the deeper pages already pending
+	slices.SortStableFunc(state.Pending, func(a, b crawlItem) int { return a.Depth - b.Depth })
+	c.mu.Lock()
+	c.state = state
+	c.mu.Unlock()
+
+	pages := make(chan CrawledPage)
+	go func() {
+		defer close(pages)
+		c.run(ctx, known, domains, pages)
+	}()
+	return pages, nil
+}
+
+func (c *Crawler) run(ctx context.Context, known map[string]bool, domains []string, pages chan<- CrawledPage) {
+	for ctx.Err() == nil {
+		c.mu.Lock()
+		budget := c.options.MaxPages - len(c.state.Visited)
+		if len(c.state.Pending) == 0 || budget <= 0 {
+			c.mu.Unlock()
+			return
+		}
+		// fetch a whole level at once, within the remaining budget. Deeper pages
+		// queued by a previous crawl with a higher maximum depth are kept pending.
+		depth := c.state.Pending[0].Depth
+		if depth > c.options.MaxDepth {
+			c.mu.Unlock()
+			return
+		}
+		items := make(map[string]crawlItem)
+		var batch []string
+		for _, item := range c.state.Pending {
+			if item.Depth != depth || len(batch) == budget {
+				break
+			}
+			items[canonical.URL(item.Url)] = item
+			batch = append(batch, item.Url)
+		}
+		c.mu.Unlock()
+
+		if !c.fetchBatch(ctx, batch, items, known, domains, pages) {
+			return
+		}
+	}
+}
+
+// Fetches a batch of pending pages and records them, returning false when the state could not
+// be saved. The fetches still in flight are cancelled when the batch is abandoned.
+func (c *Crawler) fetchBatch(ctx context.Context, batch []string, items map[string]crawlItem, known map[string]bool, domains []string, pages chan<- CrawledPage) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for result := range c.client.FetchMany(ctx, batch, c.options.Fetch) {
+		if ctx.Err() != nil {
+			// the fetch was interrupted: the page stays pending
+			continue
+		}
+		item := items[result.CanonicalUrl]
+		page := CrawledPage{Url: item.Url, Depth: item.Depth, Parent: item.Parent, Output: result.Output, Err: result.Err}
+		if result.Output != nil {
+			page.Links = extractLinks(item.Url, result.Output.Markdown)
+		}
+		c.record(page, result.CanonicalUrl, known, domains)
+		if err := c.saveState(); err != nil {
+			c.setErr(err)
+			return false
+		}
+		select {
+		case pages <- page:
+		case <-ctx.Done():
+		}
+	}
+	return true
+}
+
+// Records a fetched page in the state and queues the links to follow
+func (c *Crawler) record(page CrawledPage, key string, known map[string]bool, domains []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.Visited[key] = page.Depth
+	c.state.Graph[key] = make([]string, len(page.Links))
+	for i, link := range page.Links {
+		c.state.Graph[key][i] = canonical.URL(link)
+	}
+	c.state.Pending = slices.DeleteFunc(c.state.Pending, func(item crawlItem) bool {
+		return canonical.URL(item.Url) == key
+	})
+	if page.Depth >= c.options.MaxDepth {
+		return
+	}
+	for _, link := range page.Links {
+		linkKey := canonical.URL(link)
+		if known[linkKey] || !c.allowed(link, domains) {
+			continue
+		}
+		known[linkKey] = true
+		c.state.Pending = append(c.state.Pending, crawlItem{Url: link, Depth: page.Depth + 1, Parent: page.Url})
+	}
+}
+
+func (c *Crawler) allowed(link string, domains []string) bool {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := parsed.Hostname()
+	domainAllowed := false
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			domainAllowed = true
+			break
+		}
+	}
+	if !domainAllowed {
+		return false
+	}
+	if len(c.options.AllowedPathPrefixes) == 0 {
+		return true
+	}
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	for _, prefix := range c.options.AllowedPathPrefixes {
+		if strings.HasPrefix(path, prefix) || path == strings.TrimSuffix(prefix, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the normalized URLs of the HTTP(S) links of a markdown page, resolving relative
+// links against the URL of the page. Links to the same canonical URL are only kept once.
+func extractLinks(pageUrl string, source string) []string {
+	base, err := url.Parse(pageUrl)
+	if err != nil {
+		return nil
+	}
+	var links []string
+	seen := make(map[string]bool)
+	for _, link := range markdown.Parse(source).Links() {
+		target, err := base.Parse(strings.TrimSpace(link.URL))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+			continue
+		}
+		key := canonical.URL(target.String())
+		if !seen[key] {
+			seen[key] = true
+			links = append(links, canonical.Normalize(target.String()))
+		}
+	}
+	return links
+}
+
+// Get the link graph of the crawl: the canonical URL of every page fetched,
+// mapped to the canonical URLs of the links found in it
+func (c *Crawler) Graph() map[string][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	graph := make(map[string][]string)
+	if c.state != nil {
+		for page, links := range c.state.Graph {
+			graph[page] = slices.Clone(links)
+		}
+	}
+	return graph
+}
+
+// Get the error that stopped the crawl, if any
+func (c *Crawler) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Crawler) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func (s *crawlState) known() map[string]bool {
+	known := make(map[string]bool, len(s.Visited)+len(s.Pending))
+	for page := range s.Visited {
+		known[page] = true
+	}
+	for _, item := range s.Pending {
+		known[canonical.URL(item.Url)] = true
+	}
+	return known
+}
+
+func (c *Crawler) loadState() (*crawlState, error) {
+	state := &crawlState{Visited: make(map[string]int), Graph: make(map[string][]string)}
+	if c.options.StatePath == "" {
+		return state, nil
+	}
+	content, err := os.ReadFile(c.options.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, err
+	}
+	if state.Visited == nil {
+		state.Visited = make(map[string]int)
+	}
+	if state.Graph == nil {
+		state.Graph = make(map[string][]string)
+	}
+	return state, nil
+}
+
+// Saves the state to a temporary file first, so that an interruption never leaves a truncated state
+func (c *Crawler) saveState() error {
+	if c.options.StatePath == "" {
+		return nil
+	}
+	c.mu.Lock()
+	content, err := json.Marshal(c.state)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	temporary, err := os.CreateTemp(filepath.Dir(c.options.StatePath), filepath.Base(c.options.StatePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := temporary.Write(content); err != nil {
+		temporary.Close()
+		os.Remove(temporary.Name())
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		os.Remove(temporary.Name())
+		return err
+	}
+	return os.Rename(temporary.Name(), c.options.StatePath)
+}
@@ -0,0 +1,148 @@
+package linkup
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// Mock client serving markdown pages from a map, and failing for unknown URLs
+type SiteMockClient struct {
+	MockClient
+	pages map[string]string
+
+	mu      sync.Mutex
+	fetched []string
+}
+
+func (m *SiteMockClient) FetchWithResponse(ctx context.Context, body FetchJSONRequestBody, requestEditors ...RequestEditorFn) (*FetchResponse, error) {
+	m.mu.Lock()
+	m.fetched = append(m.fetched, body.Url)
+	m.mu.Unlock()
+	page, ok := m.pages[body.Url]
+	if !ok {
+		return &FetchResponse{
+			Body:         []byte("not found"),
+			HTTPResponse: &http.Response{Status: "404 Not Found", StatusCode: 404},
+		}, nil
+	}
+	return &FetchResponse{
+		HTTPResponse: &http.Response{Status: "200 OK", StatusCode: 200},
+		JSON200:      &FetchResponseDto{Markdown: page},
+	}, nil
+}
+
+func testSite() map[string]string {
+	return map[string]string{
+		"https://docs.example.com/docs":                  "# Docs\n\n[Install](/docs/install) [Usage](usage#intro) [Blog](/blog) [Other](https://other.com/) [Mail](mailto:a@b.c)",
+		"https://docs.example.com/docs/install":          "# Install\n\n[Back](/docs) [Advanced](/docs/install/advanced) [Missing](/docs/missing)",
+		"https://docs.example.com/usage":                 "# Usage",
+		"https://docs.example.com/docs/install/advanced": "# Advanced",
+	}
+}
+
+func collectPages(t *testing.T, crawler *Crawler, seeds ...string) []CrawledPage {
+	t.Helper()
+	pages, err := crawler.Crawl(context.Background(), seeds...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var crawled []CrawledPage
+	for page := range pages {
+		crawled = append(crawled, page)
+	}
+	if err := crawler.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return crawled
+}
+
+func TestCrawlerFollowsAllowedLinks(t *testing.T) {
+	mock := &SiteMockClient{pages: testSite()}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	options := DefaultCrawlOptions()
+	options.AllowedPathPrefixes = []string{"/docs/"}
+	crawler := NewCrawler(client, options)
+	pages := collectPages(t, crawler, "https://docs.example.com/docs")
+
+	byUrl := map[string]CrawledPage{}
+	for _, page := range pages {
+		byUrl[page.Url] = page
+	}
+	if len(pages) != 4 {
+		t.Fatalf("Expected 4 pages, got %d: %v", len(pages), mock.fetched)
+	}
+	install := byUrl["https://docs.example.com/docs/install"]
+	if install.Depth != 1 || install.Parent != "https://docs.example.com/docs" || install.Err != nil {
+		t.Fatalf("Unexpected install page: %+v", install)
+	}
+	if missing := byUrl["https://docs.example.com/docs/missing"]; missing.Err == nil || missing.Depth != 2 {
+		t.Fatalf("Expected an error for the missing page, got %+v", missing)
+	}
+	if _, ok := byUrl["https://docs.example.com/usage"]; ok {
+		t.Fatal("The usage page is outside of the allowed path prefixes")
+	}
+	graph := crawler.Graph()
+	expected := []string{
+		"https://docs.example.com/docs/install",
+		"https://docs.example.com/usage",
+		"https://docs.example.com/blog",
+		"https://other.com",
+	}
+	if !slices.Equal(graph["https://docs.example.com/docs"], expected) {
+		t.Fatalf("Unexpected links: %v", graph["https://docs.example.com/docs"])
+	}
+}
+
+func TestCrawlerDerivesDomainsForEachCrawl(t *testing.T) {
+	site := testSite()
+	site["https://other.com/"] = "# Other\n\n[About](/about) [Docs](https://docs.example.com/docs)"
+	site["https://other.com/about"] = "# About"
+	mock := &SiteMockClient{pages: site}
+	crawler := NewCrawler(&LinkupClient{apiKey: "test", client: mock}, DefaultCrawlOptions())
+	collectPages(t, crawler, "https://docs.example.com/docs")
+
+	var urls []string
+	for _, page := range collectPages(t, crawler, "https://other.com/") {
+		urls = append(urls, page.Url)
+	}
+	if !slices.Equal(urls, []string{"https://other.com/", "https://other.com/about"}) {
+		t.Fatalf("Expected the second crawl to stay on its own domain, got %v", urls)
+	}
+	if len(crawler.options.AllowedDomains) != 0 {
+		t.Fatalf("The options should not be modified, got %v", crawler.options.AllowedDomains)
+	}
+}
+
+func TestCrawlerLimitsAndResume(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "crawl.json")
+	mock := &SiteMockClient{pages: testSite()}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	options := DefaultCrawlOptions()
+	options.MaxPages = 2
+	options.StatePath = statePath
+
+	first := collectPages(t, NewCrawler(client, options), "https://docs.example.com/docs")
+	if len(first) != 2 || first[0].Depth != 0 || first[1].Depth != 1 {
+		t.Fatalf("Unexpected first crawl: %+v", first)
+	}
+
+	options.MaxPages = 10
+	options.MaxDepth = 1
+	resumed := NewCrawler(client, options)
+	second := collectPages(t, resumed, "https://docs.example.com/docs")
+	if len(second) != 2 {
+		t.Fatalf("Expected the 2 remaining pages of depth 1, got %+v", second)
+	}
+	for _, page := range second {
+		if page.Depth != 1 || page.Url == first[1].Url {
+			t.Fatalf("Unexpected resumed page: %+v", page)
+		}
+	}
+	if len(resumed.Graph()) != 4 || len(mock.fetched) != 4 {
+		t.Fatalf("Expected 4 pages in total, fetched %v", mock.fetched)
+	}
+}
@@ -0,0 +1,131 @@
+package linkup
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+)
+
+// Option to deduplicate the results and sources returned by the search methods by canonical URL
+// (see `DedupeSearchResults`, `DedupeSourcedAnswer` and `DedupeStructuredSources`)
+func WithDeduplication() LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		s.deduplicate = true
+		return nil
+	}
+}
+
+// Get the search results without the text and image results whose canonical URL
+// (see `canonical.URL`) is the same as the one of a previous result: the first, best-ranked,
+// occurrence is kept. The output passed as argument is not modified.
+func DedupeSearchResults(output *SearchResultsOutput) *SearchResultsOutput {
+	return &SearchResultsOutput{
+		TextResults:  dedupeByUrl(output.TextResults, func(result TextSearchResultDto) string { return result.Url }),
+		ImageResults: dedupeByUrl(output.ImageResults, func(result ImageSearchResultDto) string { return result.Url }),
+	}
+}
+
+// Get the sourced answer without the sources whose canonical URL is the same as the one of
+// a previous source. The numbered inline citations of the answer are renumbered to match the
+// remaining sources, so that citations of a removed duplicate point to the source that was kept.
+// The output passed as argument is not modified.
+func DedupeSourcedAnswer(output *SourcedAnswerOutput) *SourcedAnswerOutput {
+	deduped := &SourcedAnswerOutput{Answer: output.Answer}
+	numbers := make(map[int]int, len(output.Sources))
+	positions := make(map[string]int)
+	for i, source := range output.Sources {
+		// sources without URL are kept, as they cannot be compared
+		if source.Url != "" {
+			key := canonical.URL(source.Url)
+			if position, ok := positions[key]; ok {
+				numbers[i+1] = position + 1
+				continue
+			}
+			positions[key] = len(deduped.Sources)
+		}
+		deduped.Sources = append(deduped.Sources, source)
+		numbers[i+1] = len(deduped.Sources)
+	}
+	if len(deduped.Sources) < len(output.Sources) {
+		deduped.Answer = renumberCitations(output.Answer, output.Sources, numbers)
+	}
+	return deduped
+}
+
+// Get the structured output without the sources whose canonical URL is the same as the one
+// of a previous source. Outputs without sources are returned as they are.
+// The output passed as argument is not modified.
+func DedupeStructuredSources(output *StructuredOutput) *StructuredOutput {
+	if output.SourcedOutput == nil {
+		return output
+	}
+	sourced := *output.SourcedOutput
+	sourced.Sources = dedupeByUrl(sourced.Sources, func(source sourcedOutputSource) string {
+		if source.Url == nil {
+			return ""
+		}
+		return *source.Url
+	})
+	return &StructuredOutput{RawJson: output.RawJson, SourcedOutput: &sourced}
+}
+
+// The type of the sources of a StructuredWithSourcesDto
+type sourcedOutputSource = struct {
+	Content *string `json:"content,omitempty"`
+	Name    *string `json:"name,omitempty"`
+	Type    *string `json:"type,omitempty"`
+	Url     *string `json:"url,omitempty"`
+}
+
+// Keeps the first item of each canonical URL. Items without a URL are never considered duplicates.
+func dedupeByUrl[T any](items []T, urlOf func(T) string) []T {
+	if items == nil {
+		return nil
+	}
+	deduped := make([]T, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if rawUrl := urlOf(item); rawUrl != "" {
+			key := canonical.URL(rawUrl)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		deduped = append(deduped, item)
+	}
+	return deduped
+}
+
+// Rewrites the numbers of the inline citation markers of an answer citing the given
+// sources. Numbers missing from the mapping are kept, numbers repeated in the same marker after
+// renumbering are merged, and bracketed numbers that are not citations are left untouched.
+func renumberCitations(answer string, sources []SourceDto, numbers map[int]int) string {
+	var builder strings.Builder
+	position := 0
+	for _, match := range citationMarker.FindAllStringSubmatchIndex(answer, -1) {
+		start, end, _, ok := parseCitationMarker(answer, match, sources)
+		if !ok {
+			continue
+		}
+		var renumbered []string
+		for _, number := range strings.Split(answer[start:end], ",") {
+			number = strings.TrimSpace(number)
+			if value, err := strconv.Atoi(number); err == nil {
+				if mapped, ok := numbers[value]; ok {
+					number = strconv.Itoa(mapped)
+				}
+			}
+			if !slices.Contains(renumbered, number) {
+				renumbered = append(renumbered, number)
+			}
+		}
+		builder.WriteString(answer[position:start])
+		builder.WriteString(strings.Join(renumbered, ", "))
+		position = end
+	}
+	builder.WriteString(answer[position:])
+	return builder.String()
+}
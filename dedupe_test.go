@@ -0,0 +1,159 @@
+package linkup
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDedupeSearchResults(t *testing.T) {
+	output := &SearchResultsOutput{
+		TextResults: []TextSearchResultDto{
+			textResult("https://example.com/page"),
+			textResult("https://other.com/"),
+			textResult("http://www.example.com/page/?utm_source=newsletter"),
+			textResult("https://example.com/amp/page"),
+			textResult("https://other.com/about"),
+		},
+		ImageResults: []ImageSearchResultDto{
+			{Name: "logo", Url: "https://example.com/logo.png", Type: "image"},
+			{Name: "logo", Url: "https://EXAMPLE.com/logo.png#top", Type: "image"},
+		},
+	}
+	deduped := DedupeSearchResults(output)
+	var urls []string
+	for _, result := range deduped.TextResults {
+		urls = append(urls, result.Url)
+	}
+	expected := []string{"https://example.com/page", "https://other.com/", "https://other.com/about"}
+	if !slices.Equal(urls, expected) {
+		t.Fatalf("expected text results %v, got %v", expected, urls)
+	}
+	if len(deduped.ImageResults) != 1 {
+		t.Fatalf("expected 1 image result, got %d", len(deduped.ImageResults))
+	}
+	if len(output.TextResults) != 5 || len(output.ImageResults) != 2 {
+		t.Fatal("the original output should not be modified")
+	}
+}
+
+func TestDedupeSourcedAnswer(t *testing.T) {
+	output := &SourcedAnswerOutput{
+		Answer: "Go is fast [1]. It compiles quickly [2, 3] and has goroutines [[4]](https://docs.com/goroutines). ![2](https://example.com/logo.png)",
+		Sources: []SourceDto{
+			{Name: "A", Url: "https://example.com/go"},
+			{Name: "A again", Url: "https://www.example.com/go?utm_medium=social"},
+			{Name: "B", Url: "https://blog.com/compiler"},
+			{Name: "C", Url: "https://docs.com/goroutines"},
+		},
+	}
+	deduped := DedupeSourcedAnswer(output)
+	if len(deduped.Sources) != 3 {
+		t.Fatalf("expected 3 sources, got %d", len(deduped.Sources))
+	}
+	if deduped.Sources[0].Name != "A" || deduped.Sources[1].Name != "B" || deduped.Sources[2].Name != "C" {
+		t.Fatalf("unexpected sources: %+v", deduped.Sources)
+	}
+	expected := "Go is fast [1]. It compiles quickly [1, 2] and has goroutines [[3]](https://docs.com/goroutines). ![2](https://example.com/logo.png)"
+	if deduped.Answer != expected {
+		t.Fatalf("expected answer %q, got %q", expected, deduped.Answer)
+	}
+	if len(output.Sources) != 4 {
+		t.Fatal("the original output should not be modified")
+	}
+
+	// citations still resolve to the same sources after renumbering
+	cited := ParseCitations(deduped)
+	if len(cited.Dangling) != 0 || len(cited.Unused) != 0 {
+		t.Fatalf("expected every citation to resolve, got %d dangling and %d unused", len(cited.Dangling), len(cited.Unused))
+	}
+}
+
+func TestDedupeSourcedAnswerKeepsSourcesWithoutUrl(t *testing.T) {
+	output := &SourcedAnswerOutput{
+		Answer:  "First [1], second [2], third [3].",
+		Sources: []SourceDto{{Name: "A"}, {Name: "B"}, {Name: "C", Url: "https://example.com"}},
+	}
+	deduped := DedupeSourcedAnswer(output)
+	if len(deduped.Sources) != 3 || deduped.Answer != output.Answer {
+		t.Fatalf("expected the sources without URL to be kept, got %+v", deduped)
+	}
+}
+
+func TestRenumberCitations(t *testing.T) {
+	numbers := map[int]int{1: 1, 2: 1, 3: 2}
+	sources := []SourceDto{{Url: "https://a.com"}, {Url: "https://b.com"}, {Url: "https://c.com"}}
+	cases := map[string]string{
+		"no citations":        "no citations",
+		"merged [2, 1]":       "merged [1]",
+		"footnote [^3]":       "footnote [^2]",
+		"unknown [7]":         "unknown [7]",
+		"year [2020]":         "year [2020]",
+		"not cited [2, 2020]": "not cited [2, 2020]",
+		"grouped [1,3][2]":    "grouped [1, 2][1]",
+		"link [2](https://a)": "link [1](https://a)",
+		"plain [2023](https://en.wikipedia.org/wiki/2023)": "plain [2023](https://en.wikipedia.org/wiki/2023)",
+	}
+	for answer, expected := range cases {
+		if renumbered := renumberCitations(answer, sources, numbers); renumbered != expected {
+			t.Errorf("renumberCitations(%q) = %q, expected %q", answer, renumbered, expected)
+		}
+	}
+}
+
+func TestDedupeStructuredSources(t *testing.T) {
+	urls := []string{"https://example.com/a", "https://example.com/a/", "https://example.com/b"}
+	output := &StructuredOutput{SourcedOutput: &StructuredWithSourcesDto{Data: map[string]interface{}{"answer": 42}}}
+	for i := range urls {
+		output.SourcedOutput.Sources = append(output.SourcedOutput.Sources, sourcedOutputSource{Url: &urls[i]})
+	}
+	// sources without URL are kept
+	output.SourcedOutput.Sources = append(output.SourcedOutput.Sources, sourcedOutputSource{}, sourcedOutputSource{})
+
+	deduped := DedupeStructuredSources(output)
+	if len(deduped.SourcedOutput.Sources) != 4 {
+		t.Fatalf("expected 4 sources, got %d", len(deduped.SourcedOutput.Sources))
+	}
+	if deduped.SourcedOutput.Data["answer"] != 42 {
+		t.Fatal("the data should be kept")
+	}
+	if len(output.SourcedOutput.Sources) != 5 {
+		t.Fatal("the original output should not be modified")
+	}
+
+	raw := "{}"
+	unsourced := &StructuredOutput{RawJson: &raw}
+	if DedupeStructuredSources(unsourced) != unsourced {
+		t.Fatal("outputs without sources should be returned as they are")
+	}
+}
+
+func TestWithDeduplication(t *testing.T) {
+	mock := &QueryMockClient{results: map[string][]TextSearchResultDto{
+		"go": {
+			textResult("https://example.com/go"),
+			textResult("https://www.example.com/go/"),
+			textResult("https://other.com/go"),
+		},
+	}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	output, err := client.GetSearchResults("go", Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.TextResults) != 3 {
+		t.Fatalf("expected 3 results without deduplication, got %d", len(output.TextResults))
+	}
+
+	settings := &linkupClientSettings{}
+	if err := WithDeduplication()(settings); err != nil {
+		t.Fatal(err)
+	}
+	client.deduplicate = settings.deduplicate
+	output, err = client.GetSearchResults("go", Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.TextResults) != 2 {
+		t.Fatalf("expected 2 results with deduplication, got %d", len(output.TextResults))
+	}
+}
@@ -0,0 +1,343 @@
+package linkup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Struct type representing a URL discovered in a sitemap or a feed
+type DiscoveredUrl struct {
+	// Url The URL of the page.
+	Url string
+
+	// LastModified The last modification (or publication) date of the page, if available.
+	LastModified *time.Time
+
+	// Title The title of the entry, only available for feeds.
+	Title string
+
+	// Source The URL of the sitemap or feed the page was found in.
+	Source string
+}
+
+// Options to download sitemaps and feeds
+type DiscoveryOptions struct {
+	// HttpClient The client used to download sitemaps and feeds. By default, an HTTP client with a 30 seconds timeout.
+	HttpClient HttpRequestDoer
+
+	// UserAgent The User-Agent header sent with the requests, if not empty.
+	UserAgent string
+
+	// MaxSitemaps The maximum number of sitemaps downloaded when following sitemap indexes.
+	MaxSitemaps int
+
+	// MaxBytes The maximum size of a (decompressed) sitemap or feed.
+	MaxBytes int64
+}
+
+func DefaultDiscoveryOptions() DiscoveryOptions {
+	return DiscoveryOptions{
+		HttpClient:  &http.Client{Timeout: 30 * time.Second},
+		MaxSitemaps: 50,
+		// the limit set by the sitemaps protocol
+		MaxBytes: 50 * 1024 * 1024,
+	}
+}
+
+// Struct type representing a parsed sitemap: either a list of pages, or a sitemap index
+// listing other sitemaps
+type Sitemap struct {
+	Urls     []DiscoveredUrl
+	Sitemaps []DiscoveredUrl
+}
+
+type xmlSitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type xmlSitemap struct {
+	Urls     []xmlSitemapEntry `xml:"url"`
+	Sitemaps []xmlSitemapEntry `xml:"sitemap"`
+}
+
+// Parse a sitemap or a sitemap index, gzip-compressed or not
+func ParseSitemap(r io.Reader) (*Sitemap, error) {
+	reader, err := maybeGunzip(r)
+	if err != nil {
+		return nil, err
+	}
+	var parsed xmlSitemap
+	if err := xml.NewDecoder(reader).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("invalid sitemap: %w", err)
+	}
+	sitemap := &Sitemap{}
+	for _, entry := range parsed.Urls {
+		if discovered, ok := sitemapEntry(entry); ok {
+			sitemap.Urls = append(sitemap.Urls, discovered)
+		}
+	}
+	for _, entry := range parsed.Sitemaps {
+		if discovered, ok := sitemapEntry(entry); ok {
+			sitemap.Sitemaps = append(sitemap.Sitemaps, discovered)
+		}
+	}
+	return sitemap, nil
+}
+
+func sitemapEntry(entry xmlSitemapEntry) (DiscoveredUrl, bool) {
+	loc := strings.TrimSpace(entry.Loc)
+	if loc == "" {
+		return DiscoveredUrl{}, false
+	}
+	discovered := DiscoveredUrl{Url: loc}
+	if date, ok := parseFlexibleDate(entry.LastMod); ok {
+		discovered.LastModified = &date
+	}
+	return discovered, true
+}
+
+type xmlAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type xmlFeed struct {
+	XMLName xml.Name
+	// RSS 2.0
+	Channel struct {
+		Items []xmlFeedItem `xml:"item"`
+	} `xml:"channel"`
+	// RSS 1.0 (RDF)
+	Items []xmlFeedItem `xml:"item"`
+	// Atom
+	Entries []struct {
+		Title     string        `xml:"title"`
+		Links     []xmlAtomLink `xml:"link"`
+		Updated   string        `xml:"updated"`
+		Published string        `xml:"published"`
+	} `xml:"entry"`
+}
+
+type xmlFeedItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	Guid    string `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+	Date    string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Updated string `xml:"updated"`
+}
+
+// Parse an RSS (1.0 or 2.0) or Atom feed, gzip-compressed or not
+func ParseFeed(r io.Reader) ([]DiscoveredUrl, error) {
+	reader, err := maybeGunzip(r)
+	if err != nil {
+		return nil, err
+	}
+	var parsed xmlFeed
+	if err := xml.NewDecoder(reader).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+	var urls []DiscoveredUrl
+	add := func(link string, title string, dates ...string) {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			return
+		}
+		discovered := DiscoveredUrl{Url: link, Title: strings.TrimSpace(title)}
+		if date, ok := parseFlexibleDate(firstNonEmpty(dates...)); ok {
+			discovered.LastModified = &date
+		}
+		urls = append(urls, discovered)
+	}
+	switch strings.ToLower(parsed.XMLName.Local) {
+	case "rss", "rdf":
+		items := parsed.Channel.Items
+		if len(items) == 0 {
+			items = parsed.Items
+		}
+		for _, item := range items {
+			link := item.Link
+			if link == "" && strings.HasPrefix(item.Guid, "http") {
+				link = item.Guid
+			}
+			add(link, item.Title, item.Updated, item.PubDate, item.Date)
+		}
+	case "feed":
+		for _, entry := range parsed.Entries {
+			var link string
+			for _, candidate := range entry.Links {
+				if candidate.Rel == "" || candidate.Rel == "alternate" {
+					link = candidate.Href
+					break
+				}
+			}
+			add(link, entry.Title, entry.Updated, entry.Published)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", parsed.XMLName.Local)
+	}
+	return urls, nil
+}
+
+// Returns a reader decompressing the content if it starts with the gzip magic number
+func maybeGunzip(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
+
+func resolveDiscoveryOptions(discoveryOptions []DiscoveryOptions) DiscoveryOptions {
+	defaults := DefaultDiscoveryOptions()
+	if len(discoveryOptions) == 0 {
+		return defaults
+	}
+	options := discoveryOptions[0]
+	if options.HttpClient == nil {
+		options.HttpClient = defaults.HttpClient
+	}
+	if options.MaxSitemaps <= 0 {
+		options.MaxSitemaps = defaults.MaxSitemaps
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = defaults.MaxBytes
+	}
+	return options
+}
+
+// Download a sitemap and get the URLs it lists, following sitemap indexes (up to the
+// configured maximum number of sitemaps). The sitemaps of an index that cannot be
+// downloaded are skipped; an error is only returned if no sitemap could be read.
+func DiscoverSitemap(ctx context.Context, sitemapUrl string, discoveryOptions ...DiscoveryOptions) ([]DiscoveredUrl, error) {
+	options := resolveDiscoveryOptions(discoveryOptions)
+	var urls []DiscoveredUrl
+	var errs []error
+	queue := []string{sitemapUrl}
+	seen := map[string]bool{sitemapUrl: true}
+	read := 0
+	for len(queue) > 0 && read < options.MaxSitemaps {
+		current := queue[0]
+		queue = queue[1:]
+		var sitemap *Sitemap
+		err := download(ctx, current, options, func(body io.Reader) error {
+			var err error
+			sitemap, err = ParseSitemap(body)
+			return err
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", current, err))
+			continue
+		}
+		read++
+		for _, discovered := range sitemap.Urls {
+			discovered.Source = current
+			urls = append(urls, discovered)
+		}
+		for _, child := range sitemap.Sitemaps {
+			if childUrl := resolveReference(current, child.Url); !seen[childUrl] {
+				seen[childUrl] = true
+				queue = append(queue, childUrl)
+			}
+		}
+	}
+	if read == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return urls, nil
+}
+
+// Download an RSS or Atom feed and get the URLs of its entries
+func DiscoverFeed(ctx context.Context, feedUrl string, discoveryOptions ...DiscoveryOptions) ([]DiscoveredUrl, error) {
+	options := resolveDiscoveryOptions(discoveryOptions)
+	var urls []DiscoveredUrl
+	err := download(ctx, feedUrl, options, func(body io.Reader) error {
+		var err error
+		urls, err = ParseFeed(body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range urls {
+		urls[i].Url = resolveReference(feedUrl, urls[i].Url)
+		urls[i].Source = feedUrl
+	}
+	return urls, nil
+}
+
+func download(ctx context.Context, target string, options DiscoveryOptions, parse func(io.Reader) error) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if options.UserAgent != "" {
+		request.Header.Set("User-Agent", options.UserAgent)
+	}
+	response, err := options.HttpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("response returned a status code of %d: %s", response.StatusCode, response.Status)
+	}
+	body, err := maybeGunzip(response.Body)
+	if err != nil {
+		return err
+	}
+	return parse(io.LimitReader(body, options.MaxBytes))
+}
+
+func resolveReference(base string, reference string) string {
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return reference
+	}
+	resolved, err := baseUrl.Parse(reference)
+	if err != nil {
+		return reference
+	}
+	return resolved.String()
+}
+
+// Keep the URLs modified at or after the given date. URLs without a modification date
+// are kept only if `keepUndated` is true.
+func FilterModifiedSince(urls []DiscoveredUrl, since time.Time, keepUndated bool) []DiscoveredUrl {
+	var filtered []DiscoveredUrl
+	for _, discovered := range urls {
+		if discovered.LastModified == nil {
+			if keepUndated {
+				filtered = append(filtered, discovered)
+			}
+			continue
+		}
+		if !discovered.LastModified.Before(since) {
+			filtered = append(filtered, discovered)
+		}
+	}
+	return filtered
+}
+
+// Fetch the discovered URLs with `FetchMany`
+func (l *LinkupClient) FetchDiscovered(ctx context.Context, urls []DiscoveredUrl, fetchManyOptions ...FetchManyOptions) <-chan FetchResult {
+	targets := make([]string, len(urls))
+	for i, discovered := range urls {
+		targets[i] = discovered.Url
+	}
+	return l.FetchMany(ctx, targets, fetchManyOptions...)
+}
@@ -0,0 +1,151 @@
+package linkup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSitemapIndex = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemap-docs.xml.gz</loc><lastmod>2024-05-01</lastmod></sitemap>
+  <sitemap><loc>/sitemap-blog.xml</loc></sitemap>
+  <sitemap><loc>/missing.xml</loc></sitemap>
+</sitemapindex>`
+
+const testSitemapDocs = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/docs/a</loc><lastmod>2024-05-01T10:00:00+00:00</lastmod></url>
+  <url><loc>https://example.com/docs/b</loc><lastmod>2023-01-01</lastmod></url>
+</urlset>`
+
+const testSitemapBlog = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/blog/c </loc></url>
+  <url><loc></loc></url>
+</urlset>`
+
+func TestDiscoverSitemap(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(testSitemapDocs))
+	writer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Write([]byte(testSitemapIndex))
+		case "/sitemap-docs.xml.gz":
+			w.Write(compressed.Bytes())
+		case "/sitemap-blog.xml":
+			w.Write([]byte(testSitemapBlog))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	urls, err := DiscoverSitemap(context.Background(), server.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	for _, discovered := range urls {
+		found = append(found, discovered.Url)
+	}
+	if !slices.Equal(found, []string{"https://example.com/docs/a", "https://example.com/docs/b", "https://example.com/blog/c"}) {
+		t.Fatalf("Unexpected URLs: %v", found)
+	}
+	if urls[0].Source != server.URL+"/sitemap-docs.xml.gz" || urls[0].LastModified == nil || urls[2].LastModified != nil {
+		t.Fatalf("Unexpected discovered URL: %+v", urls[0])
+	}
+	recent := FilterModifiedSince(urls, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false)
+	if len(recent) != 1 || recent[0].Url != "https://example.com/docs/a" {
+		t.Fatalf("Unexpected recent URLs: %+v", recent)
+	}
+	if withUndated := FilterModifiedSince(urls, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true); len(withUndated) != 2 {
+		t.Fatalf("Expected the undated URL to be kept, got %+v", withUndated)
+	}
+
+	if _, err := DiscoverSitemap(context.Background(), server.URL+"/missing.xml"); err == nil {
+		t.Fatal("Expected an error when no sitemap can be read")
+	}
+}
+
+func TestDiscoverSitemapW3CDates(t *testing.T) {
+	sitemap := `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/a</loc><lastmod>2024-05-01T10:30+02:00</lastmod></url>
+  <url><loc>https://example.com/b</loc><lastmod>2024-05-01T10:30Z</lastmod></url>
+  <url><loc>https://example.com/c</loc><lastmod>2025</lastmod></url>
+</urlset>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	urls, err := DiscoverSitemap(context.Background(), server.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 3 || urls[0].LastModified == nil || !urls[0].LastModified.Equal(time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected URLs: %+v", urls)
+	}
+	if recent := FilterModifiedSince(urls, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false); len(recent) != 3 {
+		t.Fatalf("Expected every URL to be dated, got %+v", recent)
+	}
+}
+
+func TestParseFeeds(t *testing.T) {
+	rss := `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Blog</title>
+  <item><title>First</title><link>https://example.com/first</link><pubDate>Mon, 06 May 2024 08:00:00 +0000</pubDate></item>
+  <item><title>Guid only</title><guid>https://example.com/guid</guid></item>
+</channel></rss>`
+	urls, err := ParseFeed(strings.NewReader(rss))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 || urls[0].Title != "First" || urls[0].LastModified == nil || urls[0].LastModified.Day() != 6 || urls[1].Url != "https://example.com/guid" {
+		t.Fatalf("Unexpected RSS entries: %+v", urls)
+	}
+
+	atom := `<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><title>Entry</title><link rel="self" href="https://example.com/self"/><link href="https://example.com/entry"/><updated>2024-05-07T12:00:00Z</updated></entry>
+</feed>`
+	urls, err = ParseFeed(strings.NewReader(atom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 1 || urls[0].Url != "https://example.com/entry" || urls[0].LastModified == nil {
+		t.Fatalf("Unexpected Atom entries: %+v", urls)
+	}
+
+	if _, err := ParseFeed(strings.NewReader("<html></html>")); err == nil {
+		t.Fatal("Expected an error for an unsupported format")
+	}
+}
+
+func TestDiscoverFeedAndFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss><channel><item><link>/docs/docs</link></item></channel></rss>`))
+	}))
+	defer server.Close()
+	urls, err := DiscoverFeed(context.Background(), server.URL+"/feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 1 || urls[0].Url != server.URL+"/docs/docs" {
+		t.Fatalf("Unexpected feed URLs: %+v", urls)
+	}
+	mock := &SiteMockClient{pages: map[string]string{urls[0].Url: "# Docs"}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	for result := range client.FetchDiscovered(context.Background(), urls) {
+		if result.Err != nil || result.Output.Markdown != "# Docs" {
+			t.Fatalf("Unexpected fetch result: %+v", result)
+		}
+	}
+}
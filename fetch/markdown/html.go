@@ -0,0 +1,156 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Get the HTML of the whole document. The output is sanitized: all the text is escaped,
+// raw HTML is dropped, and only links and images with safe URLs (http, https and mailto
+// for links, http and https for images) are kept, the others being replaced by their text.
+func (d *Document) HTML() string {
+	var builder strings.Builder
+	for _, block := range d.Blocks {
+		d.writeBlockHTML(&builder, block)
+	}
+	return builder.String()
+}
+
+func (d *Document) writeBlockHTML(builder *strings.Builder, block Block) {
+	switch block.Kind {
+	case HeadingBlock:
+		fmt.Fprintf(builder, "<h%d>%s</h%d>\n", block.Level, d.inlineHTML(block.Text), block.Level)
+	case ParagraphBlock:
+		fmt.Fprintf(builder, "<p>%s</p>\n", d.inlineHTML(block.Text))
+	case ListBlock:
+		d.writeItemsHTML(builder, block.Items, block.Ordered)
+	case CodeBlock:
+		if block.Language != "" {
+			language, _, _ := strings.Cut(block.Language, " ")
+			fmt.Fprintf(builder, "<pre><code class=\"language-%s\">", html.EscapeString(language))
+		} else {
+			builder.WriteString("<pre><code>")
+		}
+		builder.WriteString(html.EscapeString(block.Text))
+		builder.WriteString("</code></pre>\n")
+	case TableBlock:
+		if block.Table != nil {
+			d.writeTableHTML(builder, block.Table)
+		}
+	case QuoteBlock:
+		builder.WriteString("<blockquote>\n")
+		builder.WriteString(d.nested(block.Text).HTML())
+		builder.WriteString("</blockquote>\n")
+	case ThematicBreakBlock:
+		builder.WriteString("<hr>\n")
+	}
+}
+
+func (d *Document) writeItemsHTML(builder *strings.Builder, items []ListItem, ordered bool) {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	fmt.Fprintf(builder, "<%s>\n", tag)
+	for _, item := range items {
+		builder.WriteString("<li>")
+		builder.WriteString(d.inlineHTML(item.Text))
+		if len(item.Items) > 0 {
+			builder.WriteString("\n")
+			d.writeItemsHTML(builder, item.Items, item.Ordered)
+		}
+		builder.WriteString("</li>\n")
+	}
+	fmt.Fprintf(builder, "</%s>\n", tag)
+}
+
+var alignmentStyles = map[Alignment]string{
+	AlignLeft:   ` style="text-align: left"`,
+	AlignCenter: ` style="text-align: center"`,
+	AlignRight:  ` style="text-align: right"`,
+}
+
+func (d *Document) writeTableHTML(builder *strings.Builder, table *Table) {
+	writeRow := func(cells []string, tag string) {
+		builder.WriteString("<tr>")
+		for i, cell := range cells {
+			style := ""
+			if i < len(table.Alignments) {
+				style = alignmentStyles[table.Alignments[i]]
+			}
+			fmt.Fprintf(builder, "<%s%s>%s</%s>", tag, style, d.inlineHTML(cell), tag)
+		}
+		builder.WriteString("</tr>\n")
+	}
+	builder.WriteString("<table>\n<thead>\n")
+	writeRow(table.Headers, "th")
+	builder.WriteString("</thead>\n")
+	if len(table.Rows) > 0 {
+		builder.WriteString("<tbody>\n")
+		for _, row := range table.Rows {
+			writeRow(row, "td")
+		}
+		builder.WriteString("</tbody>\n")
+	}
+	builder.WriteString("</table>\n")
+}
+
+// Returns the sanitized HTML of some inline markdown
+func (d *Document) inlineHTML(text string) string {
+	var builder strings.Builder
+	writeInlinesHTML(&builder, d.parseInline(text))
+	return strings.TrimSpace(builder.String())
+}
+
+func writeInlinesHTML(builder *strings.Builder, nodes []inline) {
+	for _, node := range nodes {
+		switch node.kind {
+		case textInline:
+			builder.WriteString(html.EscapeString(node.text))
+		case codeInline:
+			fmt.Fprintf(builder, "<code>%s</code>", html.EscapeString(node.text))
+		case breakInline:
+			builder.WriteString("<br>\n")
+		case linkInline:
+			if !isSafeUrl(node.url, "http", "https", "mailto") {
+				writeInlinesHTML(builder, node.children)
+				continue
+			}
+			fmt.Fprintf(builder, "<a href=\"%s\"", html.EscapeString(node.url))
+			if node.title != "" {
+				fmt.Fprintf(builder, " title=\"%s\"", html.EscapeString(node.title))
+			}
+			builder.WriteString(">")
+			writeInlinesHTML(builder, node.children)
+			builder.WriteString("</a>")
+		case imageInline:
+			alt := strings.TrimSpace(inlinesText(node.children))
+			if !isSafeUrl(node.url, "http", "https") {
+				builder.WriteString(html.EscapeString(alt))
+				continue
+			}
+			fmt.Fprintf(builder, "<img src=\"%s\" alt=\"%s\"", html.EscapeString(node.url), html.EscapeString(alt))
+			if node.title != "" {
+				fmt.Fprintf(builder, " title=\"%s\"", html.EscapeString(node.title))
+			}
+			builder.WriteString(">")
+		}
+	}
+}
+
+// Whether a URL is relative, or uses one of the given schemes
+func isSafeUrl(url string, schemes ...string) bool {
+	url = strings.TrimSpace(url)
+	colon := strings.IndexByte(url, ':')
+	if colon < 0 || strings.ContainsAny(url[:colon], "/?#") {
+		return true
+	}
+	scheme := strings.ToLower(url[:colon])
+	for _, allowed := range schemes {
+		if scheme == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,514 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Struct type representing a link found in a markdown document
+type Link struct {
+	// Text The plain text of the link (the alt text, for links wrapping an image).
+	Text string
+
+	// URL The destination of the link.
+	URL string
+
+	// Title The title of the link, if any.
+	Title string
+}
+
+// Struct type representing an image found in a markdown document
+type Image struct {
+	Alt   string
+	URL   string
+	Title string
+}
+
+type inlineKind int
+
+const (
+	textInline inlineKind = iota
+	codeInline
+	linkInline
+	imageInline
+	breakInline
+)
+
+type inline struct {
+	kind     inlineKind
+	text     string
+	url      string
+	title    string
+	children []inline
+}
+
+var (
+	autolinkRegex = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.\-]{1,31}:[^\s<>]*)>`)
+	emailRegex    = regexp.MustCompile(`^<([^\s@<>]+@[^\s@<>]+\.[^\s@<>]+)>`)
+	htmlTagRegex  = regexp.MustCompile(`^(?:<!--.*?-->|</?[a-zA-Z][a-zA-Z0-9\-]*(?:\s[^<>]*)?/?>)`)
+	bareUrlRegex  = regexp.MustCompile(`https?://[^\s<>\]\[()"']+(?:\([^\s<>()]*\)[^\s<>\]\[()"']*)*`)
+)
+
+// Parses the inline content of a block
+func (d *Document) parseInline(text string) []inline {
+	var nodes []inline
+	var buffer strings.Builder
+	flush := func() {
+		if buffer.Len() > 0 {
+			nodes = append(nodes, inline{kind: textInline, text: html.UnescapeString(buffer.String())})
+			buffer.Reset()
+		}
+	}
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			flush()
+			nodes = append(nodes, inline{kind: breakInline})
+			i += 2
+		case c == '\\' && i+1 < len(text) && isPunctuation(text[i+1]):
+			buffer.WriteByte(text[i+1])
+			i += 2
+		case c == '\n':
+			flush()
+			if strings.HasSuffix(text[:i], "  ") {
+				nodes = append(nodes, inline{kind: breakInline})
+			} else {
+				nodes = append(nodes, inline{kind: textInline, text: " "})
+			}
+			i++
+		case c == '`':
+			run := countRun(text, i, '`')
+			end := strings.Index(text[i+run:], strings.Repeat("`", run))
+			for end >= 0 && i+run+end+run < len(text) && text[i+run+end+run] == '`' {
+				next := strings.Index(text[i+run+end+run:], strings.Repeat("`", run))
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += run + next
+			}
+			if end < 0 {
+				buffer.WriteString(text[i : i+run])
+				i += run
+				continue
+			}
+			flush()
+			code := strings.ReplaceAll(text[i+run:i+run+end], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			nodes = append(nodes, inline{kind: codeInline, text: code})
+			i += run + end + run
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if node, next, ok := d.parseLink(text, i+1, imageInline); ok {
+				flush()
+				nodes = append(nodes, node)
+				i = next
+				continue
+			}
+			buffer.WriteByte(c)
+			i++
+		case c == '[':
+			if node, next, ok := d.parseLink(text, i, linkInline); ok {
+				flush()
+				nodes = append(nodes, node)
+				i = next
+				continue
+			}
+			buffer.WriteByte(c)
+			i++
+		case c == '<':
+			if match := autolinkRegex.FindStringSubmatch(text[i:]); match != nil {
+				flush()
+				nodes = append(nodes, inline{kind: linkInline, url: match[1], children: []inline{{kind: textInline, text: match[1]}}})
+				i += len(match[0])
+				continue
+			}
+			if match := emailRegex.FindStringSubmatch(text[i:]); match != nil {
+				flush()
+				nodes = append(nodes, inline{kind: linkInline, url: "mailto:" + match[1], children: []inline{{kind: textInline, text: match[1]}}})
+				i += len(match[0])
+				continue
+			}
+			if match := htmlTagRegex.FindString(text[i:]); match != "" {
+				if strings.HasPrefix(strings.ToLower(match), "<br") {
+					flush()
+					nodes = append(nodes, inline{kind: breakInline})
+				}
+				i += len(match)
+				continue
+			}
+			buffer.WriteByte(c)
+			i++
+		case c == '*' || c == '_' || c == '~':
+			run := countRun(text, i, c)
+			if !isEmphasisRun(text, i, run, c) {
+				buffer.WriteString(text[i : i+run])
+			}
+			i += run
+		default:
+			buffer.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return nodes
+}
+
+func isPunctuation(c byte) bool {
+	return c < 128 && unicode.IsPunct(rune(c)) || c == '`' || c == '|' || c == '~' || c == '<' || c == '>' || c == '^' || c == '$' || c == '+' || c == '='
+}
+
+func countRun(text string, i int, c byte) int {
+	run := 0
+	for i+run < len(text) && text[i+run] == c {
+		run++
+	}
+	return run
+}
+
+// Whether a run of `*`, `_` or `~` delimits emphasis (and should be removed from the plain text)
+// rather than being a literal character, e.g. in `5 * 3` or `snake_case`
+func isEmphasisRun(text string, i int, run int, c byte) bool {
+	if c == '~' && run != 2 {
+		return false
+	}
+	before, after := ' ', ' '
+	if i > 0 {
+		before = rune(text[i-1])
+	}
+	if i+run < len(text) {
+		after = rune(text[i+run])
+	}
+	spaceBefore, spaceAfter := unicode.IsSpace(before), unicode.IsSpace(after)
+	if spaceBefore && spaceAfter {
+		return false
+	}
+	if c == '_' && isWordChar(before) && isWordChar(after) {
+		return false
+	}
+	return true
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Finds the `]` matching the `[` at index `open`, skipping escapes, code spans and nested brackets
+func matchingBracket(text string, open int) int {
+	depth := 0
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '`':
+			run := countRun(text, i, '`')
+			if end := strings.Index(text[i+run:], strings.Repeat("`", run)); end >= 0 {
+				i += run + end + run - 1
+			} else {
+				i += run - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Parses an inline link or image whose text starts with the `[` at index `open`.
+// It returns the index right after the link.
+func (d *Document) parseLink(text string, open int, kind inlineKind) (inline, int, bool) {
+	closing := matchingBracket(text, open)
+	if closing < 0 {
+		return inline{}, 0, false
+	}
+	label := text[open+1 : closing]
+	rest := text[closing+1:]
+	var url, title string
+	next := closing + 1
+	switch {
+	case strings.HasPrefix(rest, "("):
+		destination, destinationTitle, length, ok := parseDestination(rest)
+		if !ok {
+			return inline{}, 0, false
+		}
+		url, title = destination, destinationTitle
+		next += length
+	case strings.HasPrefix(rest, "["):
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return inline{}, 0, false
+		}
+		reference := rest[1:end]
+		if reference == "" {
+			reference = label
+		}
+		ref, ok := d.refs[normalizeLabel(reference)]
+		if !ok {
+			return inline{}, 0, false
+		}
+		url, title = ref.url, ref.title
+		next += end + 1
+	default:
+		ref, ok := d.refs[normalizeLabel(label)]
+		if !ok {
+			return inline{}, 0, false
+		}
+		url, title = ref.url, ref.title
+	}
+	// the label is parsed only once the link is known to be valid, to keep
+	// unmatched nested brackets from being parsed over and over
+	return inline{kind: kind, url: url, title: title, children: d.parseInline(label)}, next, true
+}
+
+// Parses a link destination and title between parentheses, returning the length of the whole group
+func parseDestination(text string) (string, string, int, bool) {
+	i := 1
+	skipSpaces := func() {
+		for i < len(text) && (text[i] == ' ' || text[i] == '\t' || text[i] == '\n') {
+			i++
+		}
+	}
+	skipSpaces()
+	var url string
+	if i < len(text) && text[i] == '<' {
+		end := strings.IndexAny(text[i+1:], ">\n")
+		if end < 0 || text[i+1+end] != '>' {
+			return "", "", 0, false
+		}
+		url = text[i+1 : i+1+end]
+		i += end + 2
+	} else {
+		start := i
+		depth := 0
+	destination:
+		for i < len(text) {
+			switch text[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				if depth == 0 {
+					break destination
+				}
+				depth--
+			case ' ', '\t', '\n':
+				break destination
+			}
+			i++
+		}
+		if i > len(text) {
+			return "", "", 0, false
+		}
+		url = text[start:i]
+	}
+	skipSpaces()
+	var title string
+	if i < len(text) && (text[i] == '"' || text[i] == '\'' || text[i] == '(') {
+		closing := text[i]
+		if closing == '(' {
+			closing = ')'
+		}
+		end := strings.IndexByte(text[i+1:], closing)
+		if end < 0 {
+			return "", "", 0, false
+		}
+		title = text[i+1 : i+1+end]
+		i += end + 2
+		skipSpaces()
+	}
+	if i >= len(text) || text[i] != ')' {
+		return "", "", 0, false
+	}
+	return unescape(url), unescape(title), i + 1, true
+}
+
+func unescape(text string) string {
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && isPunctuation(text[i+1]) {
+			i++
+		}
+		builder.WriteByte(text[i])
+	}
+	return html.UnescapeString(builder.String())
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+func inlinesText(nodes []inline) string {
+	var builder strings.Builder
+	for _, node := range nodes {
+		switch node.kind {
+		case textInline, codeInline:
+			builder.WriteString(node.text)
+		case breakInline:
+			builder.WriteString("\n")
+		case linkInline, imageInline:
+			builder.WriteString(inlinesText(node.children))
+		}
+	}
+	return builder.String()
+}
+
+// Returns the plain text of some inline markdown
+func (d *Document) inlineText(text string) string {
+	return strings.TrimSpace(inlinesText(d.parseInline(text)))
+}
+
+func (d *Document) collectInline(nodes []inline, links *[]Link, images *[]Image, inLink bool) {
+	for _, node := range nodes {
+		switch node.kind {
+		case linkInline:
+			*links = append(*links, Link{Text: strings.TrimSpace(inlinesText(node.children)), URL: node.url, Title: node.title})
+			d.collectInline(node.children, links, images, true)
+		case imageInline:
+			*images = append(*images, Image{Alt: strings.TrimSpace(inlinesText(node.children)), URL: node.url, Title: node.title})
+		case textInline:
+			if inLink {
+				continue
+			}
+			// bare URLs, as in GitHub flavored markdown
+			for _, url := range bareUrlRegex.FindAllString(node.text, -1) {
+				url = strings.TrimRight(url, ".,;:!?*_~")
+				*links = append(*links, Link{Text: url, URL: url})
+			}
+		}
+	}
+}
+
+// Calls `visit` with every piece of inline markdown of a block
+func (d *Document) inlineTexts(block Block, visit func(string)) {
+	switch block.Kind {
+	case HeadingBlock, ParagraphBlock:
+		visit(block.Text)
+	case ListBlock:
+		var walk func(items []ListItem)
+		walk = func(items []ListItem) {
+			for _, item := range items {
+				visit(item.Text)
+				walk(item.Items)
+			}
+		}
+		walk(block.Items)
+	case TableBlock:
+		if block.Table != nil {
+			for _, header := range block.Table.Headers {
+				visit(header)
+			}
+			for _, row := range block.Table.Rows {
+				for _, cell := range row {
+					visit(cell)
+				}
+			}
+		}
+	case QuoteBlock:
+		for _, nested := range d.nested(block.Text).Blocks {
+			d.inlineTexts(nested, visit)
+		}
+	}
+}
+
+// Parses the content of a quote as a document sharing the link references of the parent
+func (d *Document) nested(source string) *Document {
+	p := &parser{refs: d.refs}
+	return &Document{Source: source, Blocks: p.parse(splitLines(source, 0)), refs: d.refs}
+}
+
+func (d *Document) collect() ([]Link, []Image) {
+	var links []Link
+	var images []Image
+	for _, block := range d.Blocks {
+		d.inlineTexts(block, func(text string) {
+			d.collectInline(d.parseInline(text), &links, &images, false)
+		})
+	}
+	return links, images
+}
+
+// Get all the links of the document, in order, including bare URLs.
+// Links inside code are ignored.
+func (d *Document) Links() []Link {
+	links, _ := d.collect()
+	return links
+}
+
+// Get all the images of the document, in order
+func (d *Document) Images() []Image {
+	_, images := d.collect()
+	return images
+}
+
+// Get the plain text of a block of the document, without any markdown syntax.
+// List items are prefixed with `-` (or their number) and table cells are separated by tabs.
+func (d *Document) BlockText(block Block) string {
+	switch block.Kind {
+	case HeadingBlock, ParagraphBlock:
+		return d.inlineText(block.Text)
+	case CodeBlock:
+		return block.Text
+	case ListBlock:
+		var builder strings.Builder
+		d.writeItems(&builder, block.Items, block.Ordered, 0)
+		return strings.TrimRight(builder.String(), "\n")
+	case TableBlock:
+		if block.Table == nil {
+			return ""
+		}
+		rows := make([]string, 0, len(block.Table.Rows)+1)
+		rows = append(rows, d.rowText(block.Table.Headers))
+		for _, row := range block.Table.Rows {
+			rows = append(rows, d.rowText(row))
+		}
+		return strings.Join(rows, "\n")
+	case QuoteBlock:
+		return d.nested(block.Text).PlainText()
+	default:
+		return ""
+	}
+}
+
+func (d *Document) rowText(cells []string) string {
+	texts := make([]string, len(cells))
+	for i, cell := range cells {
+		texts[i] = d.inlineText(cell)
+	}
+	return strings.Join(texts, "\t")
+}
+
+func (d *Document) writeItems(builder *strings.Builder, items []ListItem, ordered bool, depth int) {
+	for i, item := range items {
+		builder.WriteString(strings.Repeat("  ", depth))
+		if ordered {
+			builder.WriteString(strconv.Itoa(i+1) + ". ")
+		} else {
+			builder.WriteString("- ")
+		}
+		builder.WriteString(strings.ReplaceAll(d.inlineText(item.Text), "\n", " "))
+		builder.WriteString("\n")
+		d.writeItems(builder, item.Items, item.Ordered, depth+1)
+	}
+}
+
+// Get the plain text of the whole document, with blocks separated by blank lines
+func (d *Document) PlainText() string {
+	texts := make([]string, 0, len(d.Blocks))
+	for _, block := range d.Blocks {
+		if text := d.BlockText(block); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
@@ -0,0 +1,545 @@
+// Markdown post-processing toolkit for the output of the Linkup `/fetch` endpoint.
+// It parses `FetchOutput.Markdown` into a document tree made of blocks (headings,
+// paragraphs, lists, code blocks, tables, quotes) and sections, and offers helpers
+// to get the outline, links, images, tables and plain text of a fetched page.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Enum representing the kind of a block of a markdown document
+type BlockKind int
+
+const (
+	HeadingBlock BlockKind = iota
+	ParagraphBlock
+	ListBlock
+	CodeBlock
+	TableBlock
+	QuoteBlock
+	ThematicBreakBlock
+)
+
+func (k BlockKind) String() string {
+	switch k {
+	case HeadingBlock:
+		return "heading"
+	case ParagraphBlock:
+		return "paragraph"
+	case ListBlock:
+		return "list"
+	case CodeBlock:
+		return "code"
+	case TableBlock:
+		return "table"
+	case QuoteBlock:
+		return "quote"
+	case ThematicBreakBlock:
+		return "thematic break"
+	default:
+		return "unknown"
+	}
+}
+
+// Struct type representing a top-level block of a markdown document
+type Block struct {
+	Kind BlockKind
+
+	// Level The level of a heading, from 1 to 6. Zero for the other blocks.
+	Level int
+
+	// Text The markdown content of the block: the text of a heading or paragraph,
+	// the content of a quote (without the `>` markers) or the code of a code block.
+	Text string
+
+	// Language The info string of a fenced code block, if any.
+	Language string
+
+	// Ordered Whether a list is ordered.
+	Ordered bool
+
+	// Items The items of a list.
+	Items []ListItem
+
+	// Table The content of a table, with cells as raw markdown.
+	Table *Table
+
+	// Start The byte offset of the beginning of the block in the source.
+	Start int
+
+	// End The byte offset of the end of the block in the source (exclusive).
+	End int
+}
+
+// Struct type representing an item of a list, possibly containing nested lists
+type ListItem struct {
+	// Text The markdown text of the item, without its marker and nested lists.
+	Text string
+
+	// Ordered Whether the nested items form an ordered list.
+	Ordered bool
+
+	// Items The nested items.
+	Items []ListItem
+}
+
+// Enum representing the alignment of a table column
+type Alignment int
+
+const (
+	AlignDefault Alignment = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// Struct type representing a markdown pipe table
+type Table struct {
+	Headers    []string
+	Alignments []Alignment
+	Rows       [][]string
+}
+
+// Struct type representing a parsed markdown document
+type Document struct {
+	// Source The markdown the document was parsed from.
+	Source string
+
+	// Blocks The top-level blocks of the document, in order.
+	Blocks []Block
+
+	// Root The section holding the whole document: its blocks are the ones
+	// appearing before the first heading, and its children are the top-level sections.
+	Root *Section
+
+	refs map[string]linkReference
+}
+
+type linkReference struct {
+	url   string
+	title string
+}
+
+// Parse a markdown document, such as the `Markdown` field of a `FetchOutput`
+func Parse(source string) *Document {
+	p := &parser{refs: make(map[string]linkReference)}
+	blocks := p.parse(splitLines(source, 0))
+	document := &Document{
+		Source: source,
+		Blocks: blocks,
+		refs:   p.refs,
+	}
+	document.Root = buildSections(document)
+	return document
+}
+
+// Get the blocks of the given kind, in document order
+func (d *Document) BlocksOf(kind BlockKind) []Block {
+	var blocks []Block
+	for _, block := range d.Blocks {
+		if block.Kind == kind {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// Get the tables of the document, with inline formatting stripped from the cells
+func (d *Document) Tables() []Table {
+	var tables []Table
+	for _, block := range d.Blocks {
+		if block.Kind != TableBlock || block.Table == nil {
+			continue
+		}
+		table := Table{
+			Headers:    make([]string, len(block.Table.Headers)),
+			Alignments: block.Table.Alignments,
+			Rows:       make([][]string, len(block.Table.Rows)),
+		}
+		for i, header := range block.Table.Headers {
+			table.Headers[i] = d.inlineText(header)
+		}
+		for i, row := range block.Table.Rows {
+			table.Rows[i] = make([]string, len(row))
+			for j, cell := range row {
+				table.Rows[i][j] = d.inlineText(cell)
+			}
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+type line struct {
+	text  string
+	start int
+	end   int
+}
+
+// Splits the source in lines, keeping track of their byte offsets
+// (shifted by `offset`) including the line terminator
+func splitLines(source string, offset int) []line {
+	var lines []line
+	start := 0
+	for start < len(source) {
+		end := strings.IndexByte(source[start:], '\n')
+		if end < 0 {
+			lines = append(lines, line{text: strings.TrimSuffix(source[start:], "\r"), start: offset + start, end: offset + len(source)})
+			break
+		}
+		lines = append(lines, line{text: strings.TrimSuffix(source[start:start+end], "\r"), start: offset + start, end: offset + start + end + 1})
+		start += end + 1
+	}
+	return lines
+}
+
+var (
+	atxHeadingRegex     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	fenceRegex          = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+	thematicBreakRegex  = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	setextRegex         = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	listItemRegex       = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])(?:([ \t]+)(.*))?$`)
+	quoteRegex          = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	tableDelimiterRegex = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	referenceRegex      = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ \t]*<?([^\s>]+)>?(?:[ \t]+(?:"([^"]*)"|'([^']*)'|\(([^)]*)\)))?[ \t]*$`)
+)
+
+type parser struct {
+	refs map[string]linkReference
+}
+
+func isBlank(text string) bool {
+	return strings.TrimSpace(text) == ""
+}
+
+// Returns the indentation of a line, counting tabs as four spaces
+func indentation(text string) int {
+	indent := 0
+	for _, r := range text {
+		switch r {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4 - indent%4
+		default:
+			return indent
+		}
+	}
+	return indent
+}
+
+// Removes up to `n` columns of indentation from a line
+func dedent(text string, n int) string {
+	column := 0
+	for i, r := range text {
+		if column >= n {
+			return text[i:]
+		}
+		switch r {
+		case ' ':
+			column++
+		case '\t':
+			column += 4 - column%4
+		default:
+			return text[i:]
+		}
+	}
+	return ""
+}
+
+// Whether the line at index `i` starts a block that can interrupt a paragraph
+func (p *parser) interruptsParagraph(lines []line, i int) bool {
+	text := lines[i].text
+	if atxHeadingRegex.MatchString(text) || fenceRegex.MatchString(text) || thematicBreakRegex.MatchString(text) || quoteRegex.MatchString(text) {
+		return true
+	}
+	// only bullets and ordered lists starting with 1 interrupt a paragraph, so that
+	// a line starting with a number (e.g. a year) does not start a list
+	if match := listItemRegex.FindStringSubmatch(text); match != nil && len(match[1]) < 4 && match[4] != "" {
+		return !isOrderedMarker(match[2]) || match[2][:len(match[2])-1] == "1"
+	}
+	return isTableStart(lines, i)
+}
+
+func isTableStart(lines []line, i int) bool {
+	return i+1 < len(lines) &&
+		strings.Contains(lines[i].text, "|") &&
+		strings.Contains(lines[i+1].text, "-") &&
+		tableDelimiterRegex.MatchString(lines[i+1].text) &&
+		len(splitTableRow(lines[i].text)) == len(splitTableRow(lines[i+1].text))
+}
+
+func (p *parser) parse(lines []line) []Block {
+	var blocks []Block
+	i := 0
+	for i < len(lines) {
+		text := lines[i].text
+		if isBlank(text) {
+			i++
+			continue
+		}
+		var block Block
+		switch {
+		case indentation(text) >= 4:
+			block, i = p.parseIndentedCode(lines, i)
+		case fenceRegex.MatchString(text):
+			block, i = p.parseFencedCode(lines, i)
+		case atxHeadingRegex.MatchString(text):
+			match := atxHeadingRegex.FindStringSubmatch(text)
+			block = Block{Kind: HeadingBlock, Level: len(match[1]), Text: strings.TrimSpace(match[2]), Start: lines[i].start, End: lines[i].end}
+			i++
+		case thematicBreakRegex.MatchString(text):
+			block = Block{Kind: ThematicBreakBlock, Start: lines[i].start, End: lines[i].end}
+			i++
+		case quoteRegex.MatchString(text):
+			block, i = p.parseQuote(lines, i)
+		case listItemRegex.MatchString(text) && listItemRegex.FindStringSubmatch(text)[4] != "":
+			block, i = p.parseList(lines, i)
+		case isTableStart(lines, i):
+			block, i = p.parseTable(lines, i)
+		case referenceRegex.MatchString(text):
+			match := referenceRegex.FindStringSubmatch(text)
+			label := normalizeLabel(match[1])
+			if _, ok := p.refs[label]; !ok {
+				p.refs[label] = linkReference{url: match[2], title: match[3] + match[4] + match[5]}
+			}
+			i++
+			continue
+		default:
+			block, i = p.parseParagraph(lines, i)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func (p *parser) parseIndentedCode(lines []line, i int) (Block, int) {
+	start := i
+	var code []string
+	lastNonBlank := i
+	for i < len(lines) && (isBlank(lines[i].text) || indentation(lines[i].text) >= 4) {
+		if !isBlank(lines[i].text) {
+			lastNonBlank = i
+		}
+		code = append(code, dedent(lines[i].text, 4))
+		i++
+	}
+	code = code[:lastNonBlank-start+1]
+	return Block{Kind: CodeBlock, Text: strings.Join(code, "\n"), Start: lines[start].start, End: lines[lastNonBlank].end}, lastNonBlank + 1
+}
+
+func (p *parser) parseFencedCode(lines []line, i int) (Block, int) {
+	match := fenceRegex.FindStringSubmatch(lines[i].text)
+	indent, fence := len(match[1]), match[2]
+	block := Block{Kind: CodeBlock}
+	if fields := strings.Fields(match[3]); len(fields) > 0 {
+		block.Language = fields[0]
+	}
+	start := i
+	var code []string
+	i++
+	for i < len(lines) {
+		trimmed := strings.TrimSpace(lines[i].text)
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" && indentation(lines[i].text) < 4 {
+			i++
+			break
+		}
+		code = append(code, dedent(lines[i].text, indent))
+		i++
+	}
+	block.Text = strings.Join(code, "\n")
+	block.Start = lines[start].start
+	block.End = lines[i-1].end
+	return block, i
+}
+
+func (p *parser) parseQuote(lines []line, i int) (Block, int) {
+	start := i
+	var content []string
+	for i < len(lines) {
+		if match := quoteRegex.FindStringSubmatch(lines[i].text); match != nil {
+			content = append(content, match[1])
+			i++
+			continue
+		}
+		// lazy continuation of a paragraph inside the quote
+		if !isBlank(lines[i].text) && len(content) > 0 && !isBlank(content[len(content)-1]) && !p.interruptsParagraph(lines, i) {
+			content = append(content, lines[i].text)
+			i++
+			continue
+		}
+		break
+	}
+	return Block{Kind: QuoteBlock, Text: strings.Join(content, "\n"), Start: lines[start].start, End: lines[i-1].end}, i
+}
+
+func isOrderedMarker(marker string) bool {
+	return marker[len(marker)-1] == '.' || marker[len(marker)-1] == ')'
+}
+
+func (p *parser) parseList(lines []line, i int) (Block, int) {
+	start := i
+	first := listItemRegex.FindStringSubmatch(lines[i].text)
+	baseIndent := len(first[1])
+	ordered := isOrderedMarker(first[2])
+	var items [][]string
+	contentIndent := 0
+	lastNonBlank := i
+	for i < len(lines) {
+		text := lines[i].text
+		if isBlank(text) {
+			// the list continues only if the next non-blank line belongs to it
+			next := i + 1
+			for next < len(lines) && isBlank(lines[next].text) {
+				next++
+			}
+			if next == len(lines) {
+				break
+			}
+			nextText := lines[next].text
+			match := listItemRegex.FindStringSubmatch(nextText)
+			sameList := match != nil && len(match[1]) < contentIndent && isOrderedMarker(match[2]) == ordered
+			if indentation(nextText) < contentIndent && !sameList {
+				break
+			}
+			items[len(items)-1] = append(items[len(items)-1], "")
+			i++
+			continue
+		}
+		match := listItemRegex.FindStringSubmatch(text)
+		if match != nil && len(match[1]) < baseIndent+2 && (len(items) == 0 || len(match[1]) < contentIndent) {
+			if isOrderedMarker(match[2]) != ordered || thematicBreakRegex.MatchString(text) {
+				break
+			}
+			spacing := len(match[3])
+			if spacing == 0 || spacing > 4 {
+				spacing = 1
+			}
+			contentIndent = len(match[1]) + len(match[2]) + spacing
+			items = append(items, []string{match[4]})
+			lastNonBlank = i
+			i++
+			continue
+		}
+		if indentation(text) >= contentIndent {
+			items[len(items)-1] = append(items[len(items)-1], dedent(text, contentIndent))
+			lastNonBlank = i
+			i++
+			continue
+		}
+		// lazy continuation of the paragraph of the last item
+		previous := items[len(items)-1]
+		if !isBlank(previous[len(previous)-1]) && !p.interruptsParagraph(lines, i) {
+			items[len(items)-1] = append(previous, strings.TrimSpace(text))
+			lastNonBlank = i
+			i++
+			continue
+		}
+		break
+	}
+	block := Block{Kind: ListBlock, Ordered: ordered, Start: lines[start].start, End: lines[lastNonBlank].end}
+	for _, item := range items {
+		block.Items = append(block.Items, p.parseListItem(item))
+	}
+	return block, lastNonBlank + 1
+}
+
+func (p *parser) parseListItem(content []string) ListItem {
+	blocks := p.parse(splitLines(strings.Join(content, "\n"), 0))
+	item := ListItem{}
+	var texts []string
+	for _, block := range blocks {
+		if block.Kind == ListBlock {
+			item.Ordered = block.Ordered
+			item.Items = append(item.Items, block.Items...)
+			continue
+		}
+		texts = append(texts, block.Text)
+	}
+	item.Text = strings.Join(texts, "\n")
+	return item
+}
+
+// Splits a table row into its cells, ignoring the pipes escaped or inside code spans
+func splitTableRow(text string) []string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "|")
+	if strings.HasSuffix(text, "|") && !strings.HasSuffix(text, "\\|") {
+		text = text[:len(text)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && text[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case text[i] == '`':
+			inCode = !inCode
+			cell.WriteByte('`')
+		case text[i] == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(text[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func (p *parser) parseTable(lines []line, i int) (Block, int) {
+	start := i
+	table := &Table{Headers: splitTableRow(lines[i].text)}
+	for _, delimiter := range splitTableRow(lines[i+1].text) {
+		left, right := strings.HasPrefix(delimiter, ":"), strings.HasSuffix(delimiter, ":")
+		switch {
+		case left && right:
+			table.Alignments = append(table.Alignments, AlignCenter)
+		case left:
+			table.Alignments = append(table.Alignments, AlignLeft)
+		case right:
+			table.Alignments = append(table.Alignments, AlignRight)
+		default:
+			table.Alignments = append(table.Alignments, AlignDefault)
+		}
+	}
+	i += 2
+	for i < len(lines) && !isBlank(lines[i].text) && strings.Contains(lines[i].text, "|") {
+		row := splitTableRow(lines[i].text)
+		// rows are padded or truncated to the number of columns of the header
+		normalized := make([]string, len(table.Headers))
+		copy(normalized, row)
+		table.Rows = append(table.Rows, normalized)
+		i++
+	}
+	return Block{Kind: TableBlock, Table: table, Start: lines[start].start, End: lines[i-1].end}, i
+}
+
+func (p *parser) parseParagraph(lines []line, i int) (Block, int) {
+	start := i
+	var content []string
+	for i < len(lines) {
+		text := lines[i].text
+		if isBlank(text) {
+			break
+		}
+		if len(content) > 0 {
+			if match := setextRegex.FindStringSubmatch(text); match != nil {
+				level := 1
+				if match[1][0] == '-' {
+					level = 2
+				}
+				return Block{Kind: HeadingBlock, Level: level, Text: strings.Join(content, "\n"), Start: lines[start].start, End: lines[i].end}, i + 1
+			}
+			if p.interruptsParagraph(lines, i) {
+				break
+			}
+		}
+		content = append(content, strings.TrimSpace(text))
+		i++
+	}
+	return Block{Kind: ParagraphBlock, Text: strings.Join(content, "\n"), Start: lines[start].start, End: lines[i-1].end}, i
+}
@@ -0,0 +1,208 @@
+package markdown
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testPage = `Intro paragraph with a [home link](https://example.com "Home").
+
+# Product
+
+Our **product** is _great_ and costs 5 * 3 euros.
+See https://docs.example.com/start.
+
+## Features
+
+- Fast
+- Reliable with [docs][ref]
+  - Nested item
+
+### Code
+
+` + "```go\nfunc main() {\n\tfmt.Println(\"# not a heading\")\n}\n```" + `
+
+## Pricing
+
+| Plan | Price |
+|:-----|------:|
+| Free | $0 |
+| **Pro** | [$10](https://example.com/pro) |
+
+> A quote with ![logo](https://example.com/logo.png)
+
+Setext Heading
+==============
+
+Last paragraph.
+
+[ref]: https://example.com/docs
+`
+
+func TestParseBlocks(t *testing.T) {
+	document := Parse(testPage)
+	var kinds []string
+	for _, block := range document.Blocks {
+		kinds = append(kinds, block.Kind.String())
+	}
+	expected := []string{"paragraph", "heading", "paragraph", "heading", "list", "heading", "code", "heading", "table", "quote", "heading", "paragraph"}
+	if !slices.Equal(kinds, expected) {
+		t.Fatalf("Expected blocks %v, got %v", expected, kinds)
+	}
+	for _, block := range document.Blocks {
+		if block.Start < 0 || block.End > len(testPage) || block.Start >= block.End {
+			t.Fatalf("Unexpected offsets for block %v: [%d, %d)", block.Kind, block.Start, block.End)
+		}
+	}
+	heading := document.Blocks[1]
+	if heading.Level != 1 || heading.Text != "Product" || testPage[heading.Start:heading.End] != "# Product\n" {
+		t.Fatalf("Unexpected heading: %+v", heading)
+	}
+	code := document.BlocksOf(CodeBlock)[0]
+	if code.Language != "go" || !strings.Contains(code.Text, "# not a heading") {
+		t.Fatalf("Unexpected code block: %+v", code)
+	}
+	list := document.BlocksOf(ListBlock)[0]
+	if list.Ordered || len(list.Items) != 2 {
+		t.Fatalf("Unexpected list: %+v", list)
+	}
+	if list.Items[1].Text != "Reliable with [docs][ref]" {
+		t.Fatalf("Unexpected list item: %q", list.Items[1].Text)
+	}
+	if len(list.Items[1].Items) == 0 || list.Items[1].Items[0].Text != "Nested item" {
+		t.Fatalf("Unexpected nested items: %+v", list.Items[1].Items)
+	}
+	setext := document.Blocks[10]
+	if setext.Level != 1 || setext.Text != "Setext Heading" {
+		t.Fatalf("Unexpected setext heading: %+v", setext)
+	}
+}
+
+func TestParseListMarkerChange(t *testing.T) {
+	document := Parse("- bullet\n- another\n1. first\n2. second\n")
+	lists := document.BlocksOf(ListBlock)
+	if len(lists) != 2 || lists[0].Ordered || !lists[1].Ordered || len(lists[1].Items) != 2 {
+		t.Fatalf("Expected a bullet list followed by an ordered list, got %+v", lists)
+	}
+	paragraph := Parse("Founded in\n2019. Since then")
+	if len(paragraph.Blocks) != 1 || paragraph.Blocks[0].Kind != ParagraphBlock {
+		t.Fatalf("Expected a single paragraph, got %+v", paragraph.Blocks)
+	}
+}
+
+func TestSectionsAndOutline(t *testing.T) {
+	document := Parse(testPage)
+	outline := document.Outline()
+	expected := []OutlineEntry{{1, "Product"}, {2, "Features"}, {3, "Code"}, {2, "Pricing"}, {1, "Setext Heading"}}
+	if !slices.Equal(outline, expected) {
+		t.Fatalf("Expected outline %v, got %v", expected, outline)
+	}
+	sections := document.Sections()
+	if len(sections) != 6 || sections[0] != document.Root || len(document.Root.Blocks) != 1 {
+		t.Fatalf("Unexpected sections: %d", len(sections))
+	}
+	code := document.Section("Code")
+	if code == nil || !slices.Equal(code.Path, []string{"Product", "Features", "Code"}) {
+		t.Fatalf("Unexpected section: %+v", code)
+	}
+	product := document.Section("Product")
+	if len(product.Children) != 2 || product.End != document.Section("Setext Heading").Start {
+		t.Fatalf("Unexpected product section: %+v", product)
+	}
+	if !strings.HasPrefix(testPage[product.Start:product.End], "# Product") || !strings.Contains(testPage[product.Start:product.End], "A quote") {
+		t.Fatalf("Unexpected product section content: %q", testPage[product.Start:product.End])
+	}
+	pricing := document.SectionText(document.Section("Pricing"))
+	if !strings.HasPrefix(pricing, "Pricing\n\nPlan\tPrice\nFree\t$0\nPro\t$10") {
+		t.Fatalf("Unexpected section text: %q", pricing)
+	}
+}
+
+func TestLinksAndImages(t *testing.T) {
+	document := Parse(testPage)
+	links := document.Links()
+	expected := []Link{
+		{Text: "home link", URL: "https://example.com", Title: "Home"},
+		{Text: "https://docs.example.com/start", URL: "https://docs.example.com/start"},
+		{Text: "docs", URL: "https://example.com/docs"},
+		{Text: "$10", URL: "https://example.com/pro"},
+	}
+	if !slices.Equal(links, expected) {
+		t.Fatalf("Expected links %+v, got %+v", expected, links)
+	}
+	images := document.Images()
+	if len(images) != 1 || images[0].Alt != "logo" || images[0].URL != "https://example.com/logo.png" {
+		t.Fatalf("Unexpected images: %+v", images)
+	}
+	nested := Parse("[![badge](https://img.shields.io/x.svg)](https://ci.example.com) and <https://auto.example.com>")
+	links = nested.Links()
+	if len(links) != 2 || links[0].URL != "https://ci.example.com" || links[0].Text != "badge" || links[1].URL != "https://auto.example.com" {
+		t.Fatalf("Unexpected links: %+v", links)
+	}
+	if images := nested.Images(); len(images) != 1 || images[0].URL != "https://img.shields.io/x.svg" {
+		t.Fatalf("Unexpected images: %+v", images)
+	}
+}
+
+func TestTables(t *testing.T) {
+	tables := Parse(testPage).Tables()
+	if len(tables) != 1 {
+		t.Fatalf("Expected 1 table, got %d", len(tables))
+	}
+	table := tables[0]
+	if !slices.Equal(table.Headers, []string{"Plan", "Price"}) || !slices.Equal(table.Alignments, []Alignment{AlignLeft, AlignRight}) {
+		t.Fatalf("Unexpected table header: %+v", table)
+	}
+	if len(table.Rows) != 2 || !slices.Equal(table.Rows[1], []string{"Pro", "$10"}) {
+		t.Fatalf("Unexpected table rows: %+v", table.Rows)
+	}
+	escaped := Parse("| a | b |\n|---|---|\n| `x|y` | c \\| d |\n| short |\n").Tables()[0]
+	if !slices.Equal(escaped.Rows[0], []string{"x|y", "c | d"}) || !slices.Equal(escaped.Rows[1], []string{"short", ""}) {
+		t.Fatalf("Unexpected rows: %+v", escaped.Rows)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	text := Parse(testPage).PlainText()
+	for _, expected := range []string{
+		"Intro paragraph with a home link.",
+		"Our product is great and costs 5 * 3 euros.",
+		"- Fast\n- Reliable with docs",
+		"  - Nested item",
+		"\tfmt.Println(\"# not a heading\")",
+		"A quote with logo",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Fatalf("Expected plain text to contain %q, got:\n%s", expected, text)
+		}
+	}
+	for _, unexpected := range []string{"**", "](", "[ref]:", "|:-"} {
+		if strings.Contains(text, unexpected) {
+			t.Fatalf("Expected plain text not to contain %q, got:\n%s", unexpected, text)
+		}
+	}
+	if got := Parse("snake_case and `code *span*` &amp; \\*literal\\*").PlainText(); got != "snake_case and code *span* & *literal*" {
+		t.Fatalf("Unexpected plain text: %q", got)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	output := Parse(testPage).HTML()
+	for _, expected := range []string{
+		`<p>Intro paragraph with a <a href="https://example.com" title="Home">home link</a>.</p>`,
+		"<h1>Product</h1>",
+		"<li>Reliable with <a href=\"https://example.com/docs\">docs</a>\n<ul>\n<li>Nested item</li>\n</ul>\n</li>",
+		"<pre><code class=\"language-go\">func main() {\n\tfmt.Println(&#34;# not a heading&#34;)\n}</code></pre>",
+		`<th style="text-align: left">Plan</th><th style="text-align: right">Price</th>`,
+		"<blockquote>\n<p>",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Fatalf("Expected HTML to contain %q, got:\n%s", expected, output)
+		}
+	}
+	unsafe := Parse("<script>alert(1)</script> [click](javascript:alert(1)) ![x](data:image/png;base64,AA) 1 < 2").HTML()
+	if unsafe != "<p>alert(1) click x 1 &lt; 2</p>\n" {
+		t.Fatalf("Unexpected sanitized HTML: %q", unsafe)
+	}
+}
@@ -0,0 +1,131 @@
+package markdown
+
+// Struct type representing a section of a markdown document: a heading with
+// the blocks following it, up to the next heading of the same or a higher level
+type Section struct {
+	// Level The level of the heading of the section, or zero for the root section.
+	Level int
+
+	// Title The plain text of the heading of the section.
+	Title string
+
+	// Path The titles of the headings leading to the section, including its own.
+	Path []string
+
+	// Heading The heading block of the section, nil for the root section.
+	Heading *Block
+
+	// Blocks The blocks belonging directly to the section, excluding the ones of its subsections.
+	Blocks []Block
+
+	// Children The subsections.
+	Children []*Section
+
+	// Start The byte offset of the beginning of the section in the source.
+	Start int
+
+	// End The byte offset of the end of the section, including its subsections (exclusive).
+	End int
+}
+
+// Struct type representing an entry of the outline of a document
+type OutlineEntry struct {
+	Level int
+	Title string
+}
+
+func buildSections(d *Document) *Section {
+	root := &Section{End: len(d.Source)}
+	stack := []*Section{root}
+	for i := range d.Blocks {
+		block := d.Blocks[i]
+		if block.Kind != HeadingBlock {
+			top := stack[len(stack)-1]
+			top.Blocks = append(top.Blocks, block)
+			continue
+		}
+		for len(stack) > 1 && stack[len(stack)-1].Level >= block.Level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		title := d.inlineText(block.Text)
+		path := make([]string, len(parent.Path), len(parent.Path)+1)
+		copy(path, parent.Path)
+		section := &Section{
+			Level:   block.Level,
+			Title:   title,
+			Path:    append(path, title),
+			Heading: &d.Blocks[i],
+			Start:   block.Start,
+		}
+		parent.Children = append(parent.Children, section)
+		stack = append(stack, section)
+	}
+	setSectionEnds(root, len(d.Source))
+	return root
+}
+
+// Each section ends where the next section of the same or a higher level starts
+func setSectionEnds(section *Section, end int) {
+	section.End = end
+	for i, child := range section.Children {
+		childEnd := end
+		if i+1 < len(section.Children) {
+			childEnd = section.Children[i+1].Start
+		}
+		setSectionEnds(child, childEnd)
+	}
+}
+
+// Get all the sections of the document in document order, flattening the tree.
+// The root section comes first if some content appears before the first heading.
+func (d *Document) Sections() []*Section {
+	var sections []*Section
+	if len(d.Root.Blocks) > 0 {
+		sections = append(sections, d.Root)
+	}
+	var walk func(children []*Section)
+	walk = func(children []*Section) {
+		for _, child := range children {
+			sections = append(sections, child)
+			walk(child.Children)
+		}
+	}
+	walk(d.Root.Children)
+	return sections
+}
+
+// Get the outline of the document, i.e. the level and title of every heading
+func (d *Document) Outline() []OutlineEntry {
+	var outline []OutlineEntry
+	for _, section := range d.Sections() {
+		if section.Heading != nil {
+			outline = append(outline, OutlineEntry{Level: section.Level, Title: section.Title})
+		}
+	}
+	return outline
+}
+
+// Find the first section whose title matches the given one
+func (d *Document) Section(title string) *Section {
+	for _, section := range d.Sections() {
+		if section.Heading != nil && section.Title == title {
+			return section
+		}
+	}
+	return nil
+}
+
+// Get the plain text of the section, including its heading and subsections
+func (d *Document) SectionText(section *Section) string {
+	return Parse(d.Source[section.Start:section.End]).withRefs(d.refs).PlainText()
+}
+
+func (d *Document) withRefs(refs map[string]linkReference) *Document {
+	for label, ref := range refs {
+		if _, ok := d.refs[label]; !ok {
+			d.refs[label] = ref
+		}
+	}
+	return d
+}
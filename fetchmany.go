@@ -0,0 +1,225 @@
+package linkup
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+)
+
+// Options to fetch many URLs concurrently with `FetchMany`
+type FetchManyOptions struct {
+	// Concurrency The maximum number of requests in flight.
+	Concurrency int
+
+	// PerHostConcurrency The maximum number of requests in flight for the same host.
+	PerHostConcurrency int
+
+	// PerHostDelay The minimum time between the start of two requests for the same host.
+	PerHostDelay time.Duration
+
+	// FetchOptions The fetch options applied to every URL.
+	FetchOptions AdditionalFetchOptions
+
+	// PerUrlOptions Fetch options applied to specific URLs instead of FetchOptions,
+	// keyed by URL (either as given or in canonical form).
+	PerUrlOptions map[string]AdditionalFetchOptions
+}
+
+func DefaultFetchManyOptions() FetchManyOptions {
+	return FetchManyOptions{
+		Concurrency:        8,
+		PerHostConcurrency: 2,
+		PerHostDelay:       0,
+		FetchOptions:       DefaultAdditionalFetchOptions(),
+	}
+}
+
+// Struct type representing the result of fetching one of the URLs passed to `FetchMany`
+type FetchResult struct {
+	// Url The URL as given, for its first occurrence.
+	Url string
+
+	// CanonicalUrl The canonical form of the URL, used to deduplicate the URLs.
+	CanonicalUrl string
+
+	// Duplicates The other URLs given that have the same canonical form, and were not fetched again.
+	Duplicates []string
+
+	// Index The position of the first occurrence of the URL in the given list.
+	Index int
+
+	// Output The fetched page, nil if the fetch failed.
+	Output *FetchOutput
+
+	// Err The error that occurred while fetching the URL, if any.
+	Err error
+}
+
+// Fetch many URLs concurrently and stream the results back, in completion order, through
+// the returned channel, which is closed once every URL has been fetched. URLs with the same
+// canonical form are only fetched once, and the number of requests in flight is limited
+// both globally and per host. Failing URLs are reported in the `Err` field of their result
+// and do not stop the other fetches.
+//
+// The caller must either drain the channel or cancel the context: once the context is
+// cancelled, pending fetches stop and their results may not be delivered.
+func (l *LinkupClient) FetchMany(ctx context.Context, urls []string, fetchManyOptions ...FetchManyOptions) <-chan FetchResult {
+	var options FetchManyOptions
+	switch len(fetchManyOptions) {
+	case 0:
+		options = DefaultFetchManyOptions()
+	default:
+		options = fetchManyOptions[0]
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultFetchManyOptions().Concurrency
+	}
+	if options.PerHostConcurrency <= 0 {
+		options.PerHostConcurrency = DefaultFetchManyOptions().PerHostConcurrency
+	}
+
+	jobs := dedupeUrls(urls)
+	scheduler := newFetchScheduler(jobs, options.PerHostConcurrency, options.PerHostDelay)
+	stop := context.AfterFunc(ctx, scheduler.wake)
+	results := make(chan FetchResult)
+	var wg sync.WaitGroup
+	for range min(options.Concurrency, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				result, release, ok := scheduler.next(ctx)
+				if !ok {
+					return
+				}
+				fetchOptions := options.FetchOptions
+				if perUrl, ok := options.PerUrlOptions[result.Url]; ok {
+					fetchOptions = perUrl
+				} else if perUrl, ok := options.PerUrlOptions[result.CanonicalUrl]; ok {
+					fetchOptions = perUrl
+				}
+				result.Output, result.Err = l.fetchUrl(ctx, result.Url, fetchOptions)
+				release()
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		stop()
+		close(results)
+	}()
+	return results
+}
+
+// Returns the URLs to fetch, keeping only the first occurrence of each canonical form
+func dedupeUrls(urls []string) []FetchResult {
+	var jobs []FetchResult
+	positions := make(map[string]int)
+	for i, rawUrl := range urls {
+		key := canonical.URL(rawUrl)
+		if position, ok := positions[key]; ok {
+			jobs[position].Duplicates = append(jobs[position].Duplicates, rawUrl)
+			continue
+		}
+		positions[key] = len(jobs)
+		jobs = append(jobs, FetchResult{Url: rawUrl, CanonicalUrl: key, Index: i})
+	}
+	return jobs
+}
+
+func hostOf(rawUrl string) string {
+	if parsed, err := url.Parse(rawUrl); err == nil {
+		return parsed.Host
+	}
+	return ""
+}
+
+// Hands out the URLs to fetch to a fixed pool of workers. Each worker takes the first pending
+// URL whose host is below its concurrency limit, so that workers do not wait for a busy host
+// while URLs of other hosts are pending, and the requests to a host are spaced by the delay.
+type fetchScheduler struct {
+	perHost int
+	delay   time.Duration
+
+	mu        sync.Mutex
+	ready     *sync.Cond
+	pending   []FetchResult
+	inFlight  map[string]int
+	nextStart map[string]time.Time
+}
+
+func newFetchScheduler(jobs []FetchResult, perHost int, delay time.Duration) *fetchScheduler {
+	scheduler := &fetchScheduler{
+		perHost:   perHost,
+		delay:     delay,
+		pending:   jobs,
+		inFlight:  make(map[string]int),
+		nextStart: make(map[string]time.Time),
+	}
+	scheduler.ready = sync.NewCond(&scheduler.mu)
+	return scheduler
+}
+
+// Returns the next URL to fetch once its host can be requested, with the function to call when
+// its fetch is over, or false when no URL is left or the context is cancelled
+func (s *fetchScheduler) next(ctx context.Context) (FetchResult, func(), bool) {
+	s.mu.Lock()
+	index := -1
+	for index < 0 {
+		if ctx.Err() != nil || len(s.pending) == 0 {
+			s.mu.Unlock()
+			return FetchResult{}, nil, false
+		}
+		index = slices.IndexFunc(s.pending, func(job FetchResult) bool {
+			return s.inFlight[hostOf(job.CanonicalUrl)] < s.perHost
+		})
+		if index < 0 {
+			s.ready.Wait()
+		}
+	}
+	job := s.pending[index]
+	s.pending = slices.Delete(s.pending, index, index+1)
+	host := hostOf(job.CanonicalUrl)
+	s.inFlight[host]++
+	now := time.Now()
+	start := now
+	if s.nextStart[host].After(now) {
+		start = s.nextStart[host]
+	}
+	s.nextStart[host] = start.Add(s.delay)
+	s.mu.Unlock()
+
+	release := func() {
+		s.mu.Lock()
+		s.inFlight[host]--
+		s.mu.Unlock()
+		s.ready.Broadcast()
+	}
+	if wait := start.Sub(now); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return FetchResult{}, nil, false
+		}
+	}
+	return job, release, true
+}
+
+// Wakes up the workers waiting for a host, for them to notice the cancellation of the context
+func (s *fetchScheduler) wake() {
+	s.mu.Lock()
+	s.mu.Unlock()
+	s.ready.Broadcast()
+}
@@ -0,0 +1,145 @@
+package linkup
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Mock client recording the fetch requests it received and the maximum
+// number of concurrent requests, globally and per host
+type ConcurrencyMockClient struct {
+	MockClient
+	delay time.Duration
+
+	mu          sync.Mutex
+	inFlight    map[string]int
+	maxPerHost  int
+	fetched     []FetchJSONRequestBody
+	current     atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (m *ConcurrencyMockClient) FetchWithResponse(ctx context.Context, body FetchJSONRequestBody, requestEditors ...RequestEditorFn) (*FetchResponse, error) {
+	host := hostOf(body.Url)
+	m.mu.Lock()
+	m.fetched = append(m.fetched, body)
+	m.inFlight[host]++
+	m.maxPerHost = max(m.maxPerHost, m.inFlight[host])
+	m.mu.Unlock()
+	current := m.current.Add(1)
+	for {
+		maxInFlight := m.maxInFlight.Load()
+		if current <= maxInFlight || m.maxInFlight.CompareAndSwap(maxInFlight, current) {
+			break
+		}
+	}
+	defer func() {
+		m.current.Add(-1)
+		m.mu.Lock()
+		m.inFlight[host]--
+		m.mu.Unlock()
+	}()
+	time.Sleep(m.delay)
+	if strings.Contains(body.Url, "broken") {
+		return &FetchResponse{
+			Body:         []byte("not found"),
+			HTTPResponse: &http.Response{Status: "404 Not Found", StatusCode: 404},
+		}, nil
+	}
+	return m.MockClient.FetchWithResponse(ctx, body, requestEditors...)
+}
+
+func TestFetchManyDeduplicatesAndLimitsConcurrency(t *testing.T) {
+	mock := &ConcurrencyMockClient{delay: 20 * time.Millisecond, inFlight: map[string]int{}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	urls := []string{
+		"https://a.com/1", "https://a.com/2", "https://a.com/3", "https://a.com/4",
+		"https://b.com/1", "https://b.com/2", "https://c.com/broken",
+		"HTTPS://A.com:443/1/#top",
+	}
+	options := DefaultFetchManyOptions()
+	options.Concurrency = 3
+	options.PerHostConcurrency = 1
+	options.PerUrlOptions = map[string]AdditionalFetchOptions{"https://b.com/2": {IncludeRawHtml: true}}
+
+	var results []FetchResult
+	for result := range client.FetchMany(context.Background(), urls, options) {
+		results = append(results, result)
+	}
+	if len(results) != 7 {
+		t.Fatalf("Expected 7 results, got %d", len(results))
+	}
+	slices.SortFunc(results, func(a, b FetchResult) int { return a.Index - b.Index })
+	if !slices.Equal(results[0].Duplicates, []string{"HTTPS://A.com:443/1/#top"}) || results[0].CanonicalUrl != "https://a.com/1" {
+		t.Fatalf("Unexpected first result: %+v", results[0])
+	}
+	for _, result := range results {
+		failed := strings.Contains(result.Url, "broken")
+		if failed != (result.Err != nil) || failed != (result.Output == nil) {
+			t.Fatalf("Unexpected result for %s: %+v", result.Url, result)
+		}
+	}
+	if results[5].Output.RawHtml == nil || results[4].Output.RawHtml != nil {
+		t.Fatal("Expected the per-URL options to apply to b.com/2 only")
+	}
+	if mock.maxPerHost != 1 || mock.maxInFlight.Load() > 3 {
+		t.Fatalf("Concurrency limits not honored: %d per host, %d in flight", mock.maxPerHost, mock.maxInFlight.Load())
+	}
+	if len(mock.fetched) != 7 {
+		t.Fatalf("Expected 7 fetches, got %d", len(mock.fetched))
+	}
+}
+
+func TestFetchManyPartialOptions(t *testing.T) {
+	mock := &ConcurrencyMockClient{delay: 20 * time.Millisecond, inFlight: map[string]int{}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	urls := []string{"https://a.com/1", "https://a.com/2", "https://a.com/3", "https://a.com/4", "https://a.com/5", "https://b.com/1"}
+	count := 0
+	for range client.FetchMany(context.Background(), urls, FetchManyOptions{Concurrency: 8}) {
+		count++
+	}
+	// the per-host limit falls back to its default, not to the global concurrency
+	if count != 6 || mock.maxPerHost != DefaultFetchManyOptions().PerHostConcurrency {
+		t.Fatalf("Expected 6 results with the default per-host limit, got %d with %d per host", count, mock.maxPerHost)
+	}
+}
+
+func TestFetchManyPerHostDelay(t *testing.T) {
+	mock := &ConcurrencyMockClient{inFlight: map[string]int{}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	options := DefaultFetchManyOptions()
+	options.PerHostDelay = 30 * time.Millisecond
+	start := time.Now()
+	count := 0
+	for range client.FetchMany(context.Background(), []string{"https://a.com/1", "https://a.com/2", "https://a.com/3"}, options) {
+		count++
+	}
+	if count != 3 || time.Since(start) < 60*time.Millisecond {
+		t.Fatalf("Expected 3 results spaced by the per-host delay, got %d in %v", count, time.Since(start))
+	}
+}
+
+func TestFetchManyCancelled(t *testing.T) {
+	mock := &ConcurrencyMockClient{delay: 50 * time.Millisecond, inFlight: map[string]int{}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	ctx, cancel := context.WithCancel(context.Background())
+	results := client.FetchMany(ctx, []string{"https://a.com/1", "https://a.com/2", "https://a.com/3"}, FetchManyOptions{Concurrency: 1})
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the results channel to be closed after cancellation")
+	}
+}
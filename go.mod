@@ -3,8 +3,11 @@ module github.com/AstraBert/linkup-go-sdk
 go 1.22
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/invopop/jsonschema v0.13.0
 	github.com/oapi-codegen/runtime v1.1.2
+	golang.org/x/net v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -14,5 +17,4 @@ require (
 	github.com/google/uuid v1.5.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/wk8/go-ordered-map/v2 v2.1.8 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,195 @@
+package linkup
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy for hedged search requests: when the first request has not responded
+// within a delay derived from the observed latencies, an identical second request
+// is sent, and the first successful response is used while the other is cancelled.
+// Hedging only applies to `standard` depth searches with `searchResults` or `sourcedAnswer`
+// as output type, since it can double the credits spent on a query.
+type HedgingPolicy struct {
+	// Percentile The latency percentile (between 0 and 1) used as hedging delay, e.g. 0.95 to hedge the slowest 5% of requests.
+	Percentile float64
+
+	// InitialDelay The hedging delay used until enough latency samples have been collected.
+	InitialDelay time.Duration
+
+	// MinDelay The lower bound for the hedging delay.
+	MinDelay time.Duration
+
+	// MaxDelay The upper bound for the hedging delay.
+	MaxDelay time.Duration
+
+	// MinSamples The number of latency samples needed before the percentile is used.
+	MinSamples int
+
+	// SampleSize The number of most recent latency samples kept to compute the percentile.
+	SampleSize int
+}
+
+func DefaultHedgingPolicy() HedgingPolicy {
+	return HedgingPolicy{
+		Percentile:   0.95,
+		InitialDelay: 2 * time.Second,
+		MinDelay:     100 * time.Millisecond,
+		MaxDelay:     10 * time.Second,
+		MinSamples:   20,
+		SampleSize:   200,
+	}
+}
+
+// Struct type representing a snapshot of the counters collected by a LinkupClient
+type ClientMetrics struct {
+	// Number of search requests for which a hedged request was sent
+	HedgedRequests int64
+	// Number of hedged requests whose response was used instead of the original one
+	HedgeWins int64
+}
+
+type clientMetrics struct {
+	hedgedRequests atomic.Int64
+	hedgeWins      atomic.Int64
+}
+
+// Get a snapshot of the metrics collected by the client
+func (l *LinkupClient) Metrics() ClientMetrics {
+	if l.metrics == nil {
+		return ClientMetrics{}
+	}
+	return ClientMetrics{
+		HedgedRequests: l.metrics.hedgedRequests.Load(),
+		HedgeWins:      l.metrics.hedgeWins.Load(),
+	}
+}
+
+// Rolling record of search latencies used to derive the hedging delay
+type latencyTracker struct {
+	policy HedgingPolicy
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func newLatencyTracker(policy HedgingPolicy) *latencyTracker {
+	defaults := DefaultHedgingPolicy()
+	if policy.Percentile <= 0 || policy.Percentile > 1 {
+		policy.Percentile = defaults.Percentile
+	}
+	if policy.InitialDelay <= 0 {
+		policy.InitialDelay = defaults.InitialDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaults.MaxDelay
+	}
+	if policy.MinDelay > policy.MaxDelay {
+		policy.MinDelay = policy.MaxDelay
+	}
+	if policy.SampleSize <= 0 {
+		policy.SampleSize = defaults.SampleSize
+	}
+	if policy.MinSamples <= 0 {
+		policy.MinSamples = min(defaults.MinSamples, policy.SampleSize)
+	}
+	return &latencyTracker{
+		policy:  policy,
+		samples: make([]time.Duration, 0, policy.SampleSize),
+	}
+}
+
+func (t *latencyTracker) record(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.samples) < t.policy.SampleSize {
+		t.samples = append(t.samples, latency)
+		return
+	}
+	t.samples[t.next] = latency
+	t.next = (t.next + 1) % t.policy.SampleSize
+}
+
+// Returns the delay after which a hedged request should be sent
+func (t *latencyTracker) delay() time.Duration {
+	t.mu.Lock()
+	if len(t.samples) < t.policy.MinSamples {
+		t.mu.Unlock()
+		return t.policy.InitialDelay
+	}
+	sorted := slices.Clone(t.samples)
+	t.mu.Unlock()
+	slices.Sort(sorted)
+	index := int(math.Ceil(t.policy.Percentile*float64(len(sorted)))) - 1
+	delay := sorted[max(index, 0)]
+	return min(max(delay, t.policy.MinDelay), t.policy.MaxDelay)
+}
+
+// Whether a search request is eligible for hedging
+func isHedgeable(body SearchJSONRequestBody) bool {
+	return body.Depth == Standard && (body.OutputType == SearchResults || body.OutputType == SourcedAnswer)
+}
+
+type searchAttempt struct {
+	response *SearchResponse
+	err      error
+	hedge    bool
+}
+
+func (a searchAttempt) succeeded() bool {
+	return a.err == nil && a.response != nil && 200 <= a.response.StatusCode() && a.response.StatusCode() <= 299
+}
+
+// Sends a search request, hedging it with a second identical request if the first one
+// is slower than the current hedging delay
+func (l *LinkupClient) hedgedSearch(ctx context.Context, body SearchJSONRequestBody) (*SearchResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	attempts := make(chan searchAttempt, 2)
+	launch := func(hedge bool) {
+		go func() {
+			response, err := l.client.SearchWithResponse(ctx, body)
+			attempts <- searchAttempt{response: response, err: err, hedge: hedge}
+		}()
+	}
+	// the latency is the one seen by the caller, from the start of the original request,
+	// even when the hedge wins
+	started := time.Now()
+	launch(false)
+	timer := time.NewTimer(l.latencies.delay())
+	defer timer.Stop()
+	hedged := false
+	inFlight := 1
+	var last searchAttempt
+	for inFlight > 0 {
+		select {
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				inFlight++
+				l.metrics.hedgedRequests.Add(1)
+				launch(true)
+			}
+		case attempt := <-attempts:
+			inFlight--
+			if attempt.succeeded() {
+				l.latencies.record(time.Since(started))
+				if attempt.hedge {
+					l.metrics.hedgeWins.Add(1)
+				}
+				return attempt.response, nil
+			}
+			last = attempt
+			// hedging is meant to cut tail latency, not to retry failed requests
+			if !hedged {
+				return attempt.response, attempt.err
+			}
+		}
+	}
+	return last.response, last.err
+}
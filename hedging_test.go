@@ -0,0 +1,132 @@
+package linkup
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Mock client whose n-th search call is delayed by delays[n]
+type DelayedMockClient struct {
+	MockClient
+	delays []time.Duration
+	calls  atomic.Int64
+}
+
+func (m *DelayedMockClient) SearchWithResponse(ctx context.Context, body SearchJSONRequestBody, requestEditors ...RequestEditorFn) (*SearchResponse, error) {
+	call := int(m.calls.Add(1)) - 1
+	if call < len(m.delays) {
+		select {
+		case <-time.After(m.delays[call]):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return m.MockClient.SearchWithResponse(ctx, body, requestEditors...)
+}
+
+func newHedgedTestClient(mock LinkupHttpClient, policy HedgingPolicy) *LinkupClient {
+	return &LinkupClient{
+		apiKey:    "hello",
+		client:    mock,
+		latencies: newLatencyTracker(policy),
+		metrics:   &clientMetrics{},
+	}
+}
+
+func TestLatencyTrackerDelay(t *testing.T) {
+	tracker := newLatencyTracker(HedgingPolicy{
+		Percentile:   0.9,
+		InitialDelay: time.Second,
+		MinDelay:     5 * time.Millisecond,
+		MaxDelay:     80 * time.Millisecond,
+		MinSamples:   10,
+		SampleSize:   10,
+	})
+	if tracker.delay() != time.Second {
+		t.Fatalf("Expected the initial delay, got %s", tracker.delay())
+	}
+	for i := 1; i <= 10; i++ {
+		tracker.record(time.Duration(i) * time.Millisecond)
+	}
+	if tracker.delay() != 9*time.Millisecond {
+		t.Fatalf("Expected a delay of 9ms, got %s", tracker.delay())
+	}
+	for range 10 {
+		tracker.record(time.Second)
+	}
+	if tracker.delay() != 80*time.Millisecond {
+		t.Fatalf("Expected the delay to be capped at 80ms, got %s", tracker.delay())
+	}
+}
+
+func TestHedgedSearchHedgeWins(t *testing.T) {
+	mock := &DelayedMockClient{delays: []time.Duration{time.Second, 0}}
+	client := newHedgedTestClient(mock, HedgingPolicy{InitialDelay: 10 * time.Millisecond})
+	start := time.Now()
+	output, err := client.GetSourcedAnswer("lake", Standard)
+	if err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err.Error())
+	}
+	if output.Answer != "This is a lake" {
+		t.Fatalf("Unexpected answer: %s", output.Answer)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Expected the hedged request to answer first, took %s", elapsed)
+	}
+	metrics := client.Metrics()
+	if metrics.HedgedRequests != 1 || metrics.HedgeWins != 1 {
+		t.Fatalf("Unexpected metrics: %+v", metrics)
+	}
+	// the latency is measured from the start of the original request, not of the hedge
+	if len(client.latencies.samples) != 1 || client.latencies.samples[0] < 10*time.Millisecond {
+		t.Fatalf("Unexpected latency samples: %v", client.latencies.samples)
+	}
+}
+
+func TestHedgedSearchNotTriggered(t *testing.T) {
+	mock := &DelayedMockClient{}
+	client := newHedgedTestClient(mock, HedgingPolicy{InitialDelay: time.Second})
+	if _, err := client.GetSearchResults("lake", Standard); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err.Error())
+	}
+	if mock.calls.Load() != 1 {
+		t.Fatalf("Expected 1 call, got %d", mock.calls.Load())
+	}
+	if metrics := client.Metrics(); metrics.HedgedRequests != 0 || metrics.HedgeWins != 0 {
+		t.Fatalf("Unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestHedgedSearchSkipsDeepAndStructured(t *testing.T) {
+	mock := &DelayedMockClient{delays: []time.Duration{50 * time.Millisecond, 50 * time.Millisecond}}
+	client := newHedgedTestClient(mock, HedgingPolicy{InitialDelay: time.Millisecond})
+	if _, err := client.GetSourcedAnswer("lake", Deep); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err.Error())
+	}
+	schema, err := GenerateJSONSchema[MockStructuredStruct]()
+	if err != nil {
+		t.Fatalf("An error occurred while generating the JSON schema: %s", err.Error())
+	}
+	if _, err := client.GetStructuredResults("lake", Standard, schema); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err.Error())
+	}
+	if mock.calls.Load() != 2 {
+		t.Fatalf("Expected 2 calls, got %d", mock.calls.Load())
+	}
+	if metrics := client.Metrics(); metrics.HedgedRequests != 0 {
+		t.Fatalf("Unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestHedgedSearchFailureIsNotRetried(t *testing.T) {
+	client := newHedgedTestClient(&MockClient{fails: true}, HedgingPolicy{InitialDelay: time.Second})
+	_, err := client.GetSearchResults("lake", Standard)
+	if err == nil || err.Error() != "response returned a status code of 429: 429 Too Many Requests" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if metrics := client.Metrics(); metrics.HedgedRequests != 0 {
+		t.Fatalf("Unexpected metrics: %+v", metrics)
+	}
+}
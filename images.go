@@ -0,0 +1,359 @@
+package linkup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Struct type representing an image found by the Linkup API, with the metadata of its origin
+type ImageSource struct {
+	// Url The URL of the image.
+	Url string `json:"url"`
+
+	// Alt The alt text of the image, for images extracted from a fetched page.
+	Alt string `json:"alt,omitempty"`
+
+	// Name The name of the image, for image search results.
+	Name string `json:"name,omitempty"`
+
+	// PageUrl The URL of the page the image was extracted from, if known.
+	PageUrl string `json:"page_url,omitempty"`
+}
+
+// Get the images extracted from a fetched page (with `ExtractImages` set to true)
+func ImagesFromFetch(pageUrl string, output *FetchOutput) []ImageSource {
+	if output.Images == nil {
+		return nil
+	}
+	var images []ImageSource
+	for _, fetched := range *output.Images {
+		source := ImageSource{Url: fetched.Url, PageUrl: pageUrl}
+		if fetched.Alt != nil {
+			source.Alt = *fetched.Alt
+		}
+		images = append(images, source)
+	}
+	return images
+}
+
+// Get the image results of a search (with `IncludeImages` set to true)
+func ImagesFromSearch(results *SearchResultsOutput) []ImageSource {
+	var images []ImageSource
+	for _, result := range results.ImageResults {
+		images = append(images, ImageSource{Url: result.Url, Name: result.Name})
+	}
+	return images
+}
+
+// Options to download images with `DownloadImages`
+type ImageDownloadOptions struct {
+	// HttpClient The client used to download the images. By default, an HTTP client with a 30 seconds timeout.
+	HttpClient HttpRequestDoer
+
+	// UserAgent The User-Agent header sent with the requests, if not empty.
+	UserAgent string
+
+	// Concurrency The maximum number of downloads in flight.
+	Concurrency int
+
+	// MaxBytes The maximum size of an image.
+	MaxBytes int64
+
+	// AllowedTypes The allowed MIME types, as sniffed from the content. By default, common web image formats.
+	AllowedTypes []string
+
+	// Dir The directory where the images are written as they are downloaded, along with a JSON file
+	// holding their metadata. When empty, the images are kept in memory.
+	Dir string
+}
+
+func DefaultImageDownloadOptions() ImageDownloadOptions {
+	return ImageDownloadOptions{
+		HttpClient:   &http.Client{Timeout: 30 * time.Second},
+		Concurrency:  4,
+		MaxBytes:     10 * 1024 * 1024,
+		AllowedTypes: []string{"image/png", "image/jpeg", "image/gif", "image/webp", "image/svg+xml", "image/bmp"},
+	}
+}
+
+// Struct type representing a downloaded image
+type DownloadedImage struct {
+	// Source The image, as found by the Linkup API.
+	Source ImageSource `json:"source"`
+
+	// Data The content of the image, nil when it has been written to a directory.
+	Data []byte `json:"-"`
+
+	// Path The file the image has been written to, empty when it has been kept in memory.
+	Path string `json:"path,omitempty"`
+
+	// MimeType The MIME type sniffed from the content.
+	MimeType string `json:"mime_type"`
+
+	// Width The width of the image in pixels, zero if it cannot be determined (e.g. for SVG).
+	Width int `json:"width,omitempty"`
+
+	// Height The height of the image in pixels, zero if it cannot be determined.
+	Height int `json:"height,omitempty"`
+
+	// Size The size of the image in bytes.
+	Size int64 `json:"size"`
+
+	// Sha256 The hex-encoded SHA-256 hash of the content.
+	Sha256 string `json:"sha256"`
+
+	// DuplicateOf The URL of a previous image with the same content, in which case Data and Path are the ones of that image.
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+
+	// Err The error that occurred while downloading or writing the image, if any.
+	Err error `json:"-"`
+}
+
+// Download images concurrently, within the size and type limits, and return them in the
+// order of the given sources. Images with the same content are only kept (or written) once.
+func DownloadImages(ctx context.Context, sources []ImageSource, downloadOptions ...ImageDownloadOptions) []DownloadedImage {
+	options := DefaultImageDownloadOptions()
+	if len(downloadOptions) > 0 {
+		defaults := options
+		options = downloadOptions[0]
+		if options.HttpClient == nil {
+			options.HttpClient = defaults.HttpClient
+		}
+		if options.Concurrency <= 0 {
+			options.Concurrency = defaults.Concurrency
+		}
+		if options.MaxBytes <= 0 {
+			options.MaxBytes = defaults.MaxBytes
+		}
+		if len(options.AllowedTypes) == 0 {
+			options.AllowedTypes = defaults.AllowedTypes
+		}
+	}
+
+	images := make([]DownloadedImage, len(sources))
+	if options.Dir != "" {
+		if err := os.MkdirAll(options.Dir, 0o755); err != nil {
+			for i, source := range sources {
+				images[i] = DownloadedImage{Source: source, Err: err}
+			}
+			return images
+		}
+	}
+	semaphore := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		images[i].Source = source
+		wg.Add(1)
+		go func(downloaded *DownloadedImage) {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				downloaded.Err = ctx.Err()
+				return
+			}
+			defer func() { <-semaphore }()
+			downloaded.Err = downloadImage(ctx, downloaded, options)
+		}(&images[i])
+	}
+	wg.Wait()
+
+	// images are deduplicated in the order of the sources, the first one being the original.
+	// Duplicates share the content, path and error of their original.
+	first := make(map[string]*DownloadedImage)
+	for i := range images {
+		downloaded := &images[i]
+		if downloaded.Sha256 == "" {
+			continue
+		}
+		if original, ok := first[downloaded.Sha256]; ok {
+			downloaded.DuplicateOf = original.Source.Url
+			downloaded.Data = original.Data
+			downloaded.Path = original.Path
+			downloaded.Err = original.Err
+			continue
+		}
+		first[downloaded.Sha256] = downloaded
+		if options.Dir != "" && downloaded.Err == nil {
+			downloaded.Err = writeImageMetadata(downloaded)
+		}
+	}
+	return images
+}
+
+// Downloads an image, hashing it as it streams. In directory mode the content is written to a
+// temporary file as it arrives, then renamed after its hash: duplicates downloaded concurrently
+// are renamed to the same file. The hash is only set once the whole content has been checked.
+func downloadImage(ctx context.Context, downloaded *DownloadedImage, options ImageDownloadOptions) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, downloaded.Source.Url, nil)
+	if err != nil {
+		return err
+	}
+	if options.UserAgent != "" {
+		request.Header.Set("User-Agent", options.UserAgent)
+	}
+	response, err := options.HttpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("response returned a status code of %d: %s", response.StatusCode, response.Status)
+	}
+	if response.ContentLength > options.MaxBytes {
+		return fmt.Errorf("image too large: %d bytes, the limit is %d", response.ContentLength, options.MaxBytes)
+	}
+	hash := sha256.New()
+	body := io.TeeReader(io.LimitReader(response.Body, options.MaxBytes+1), hash)
+	// the head of the content is enough to sniff its type, before anything is written
+	head := make([]byte, 1024)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	head = head[:n]
+	mimeType := sniffImageType(head)
+	if !slices.Contains(options.AllowedTypes, mimeType) {
+		return fmt.Errorf("unsupported content type %q", mimeType)
+	}
+
+	if options.Dir == "" {
+		rest, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		data := append(head, rest...)
+		if int64(len(data)) > options.MaxBytes {
+			return fmt.Errorf("image too large: more than %d bytes", options.MaxBytes)
+		}
+		downloaded.Data = data
+		describeImage(downloaded, hash, head, bytes.NewReader(data), int64(len(data)), mimeType)
+		return nil
+	}
+
+	temporary, err := os.CreateTemp(options.Dir, ".download-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		temporary.Close()
+		if downloaded.Path == "" {
+			os.Remove(temporary.Name())
+		}
+	}()
+	if _, err := temporary.Write(head); err != nil {
+		return err
+	}
+	written, err := io.Copy(temporary, body)
+	if err != nil {
+		return err
+	}
+	size := int64(len(head)) + written
+	if size > options.MaxBytes {
+		return fmt.Errorf("image too large: more than %d bytes", options.MaxBytes)
+	}
+	if _, err := temporary.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	describeImage(downloaded, hash, head, temporary, size, mimeType)
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	path := filepath.Join(options.Dir, downloaded.Sha256[:16]+imageExtensions[mimeType])
+	if err := os.Rename(temporary.Name(), path); err != nil {
+		return err
+	}
+	downloaded.Path = path
+	return nil
+}
+
+// Sets the type, size, hash and dimensions of a downloaded image
+func describeImage(downloaded *DownloadedImage, digest hash.Hash, head []byte, content io.Reader, size int64, mimeType string) {
+	downloaded.MimeType = mimeType
+	downloaded.Size = size
+	downloaded.Sha256 = hex.EncodeToString(digest.Sum(nil))
+	downloaded.Width, downloaded.Height = imageDimensions(head, content, mimeType)
+}
+
+// Returns the MIME type of the content, recognizing SVG images which `http.DetectContentType` does not
+func sniffImageType(data []byte) string {
+	mimeType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if strings.HasPrefix(mimeType, "text/") {
+		head := bytes.ToLower(data[:min(len(data), 1024)])
+		if bytes.Contains(head, []byte("<svg")) {
+			return "image/svg+xml"
+		}
+	}
+	return mimeType
+}
+
+// Returns the dimensions of PNG, JPEG, GIF and WebP images, or zeros. WebP dimensions are read
+// from the head of the content, the others from the content itself.
+func imageDimensions(head []byte, content io.Reader, mimeType string) (int, int) {
+	if mimeType == "image/webp" {
+		return webpDimensions(head)
+	}
+	config, _, err := image.DecodeConfig(content)
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
+
+// Reads the dimensions from the header of a WebP image (lossy, lossless or extended format)
+func webpDimensions(data []byte) (int, int) {
+	if len(data) < 30 {
+		return 0, 0
+	}
+	switch string(data[12:16]) {
+	case "VP8 ":
+		width := binary.LittleEndian.Uint16(data[26:28]) & 0x3fff
+		height := binary.LittleEndian.Uint16(data[28:30]) & 0x3fff
+		return int(width), int(height)
+	case "VP8L":
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		width := uint32(data[24]) | uint32(data[25])<<8 | uint32(data[26])<<16
+		height := uint32(data[27]) | uint32(data[28])<<8 | uint32(data[29])<<16
+		return int(width) + 1, int(height) + 1
+	}
+	return 0, 0
+}
+
+var imageExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+	"image/bmp":     ".bmp",
+}
+
+// Writes the metadata of a written image next to it
+func writeImageMetadata(downloaded *DownloadedImage) error {
+	metadata, err := json.MarshalIndent(downloaded, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := strings.TrimSuffix(downloaded.Path, filepath.Ext(downloaded.Path)) + ".json"
+	return os.WriteFile(path, metadata, 0o644)
+}
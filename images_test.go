@@ -0,0 +1,140 @@
+package linkup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testPng(t *testing.T, width int, height int) []byte {
+	t.Helper()
+	picture := image.NewRGBA(image.Rect(0, 0, width, height))
+	picture.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, picture); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func imageServer(t *testing.T) *httptest.Server {
+	pngData := testPng(t, 3, 2)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.png", "/copy.png":
+			w.Write(pngData)
+		case "/logo.svg":
+			w.Write([]byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`))
+		case "/page.html":
+			w.Write([]byte("<html><body>not an image</body></html>"))
+		case "/big.png":
+			w.Write(append(pngData, make([]byte, 4096)...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestDownloadImagesInMemory(t *testing.T) {
+	server := imageServer(t)
+	defer server.Close()
+	alt := "first"
+	output := &FetchOutput{Images: &[]FetchImageDto{{Url: server.URL + "/a.png", Alt: &alt}, {Url: server.URL + "/copy.png"}}}
+	sources := ImagesFromFetch("https://example.com", output)
+	sources = append(sources, ImagesFromSearch(&SearchResultsOutput{ImageResults: []ImageSearchResultDto{
+		{Name: "logo", Url: server.URL + "/logo.svg"},
+		{Name: "page", Url: server.URL + "/page.html"},
+		{Name: "big", Url: server.URL + "/big.png"},
+		{Name: "missing", Url: server.URL + "/missing.png"},
+	}})...)
+	options := DefaultImageDownloadOptions()
+	options.MaxBytes = 1024
+	images := DownloadImages(context.Background(), sources, options)
+	if len(images) != 6 {
+		t.Fatalf("Expected 6 images, got %d", len(images))
+	}
+	first := images[0]
+	if first.Err != nil || first.MimeType != "image/png" || first.Width != 3 || first.Height != 2 || first.Source.Alt != "first" || first.Source.PageUrl != "https://example.com" {
+		t.Fatalf("Unexpected first image: %+v", first)
+	}
+	if images[1].DuplicateOf != first.Source.Url || !bytes.Equal(images[1].Data, first.Data) {
+		t.Fatalf("Expected the second image to be a duplicate, got %+v", images[1])
+	}
+	if images[2].Err != nil || images[2].MimeType != "image/svg+xml" || images[2].Source.Name != "logo" {
+		t.Fatalf("Unexpected SVG image: %+v", images[2])
+	}
+	for i, expected := range []string{"unsupported content type", "image too large", "404"} {
+		if err := images[3+i].Err; err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Expected an error containing %q for image %d, got %v", expected, 3+i, err)
+		}
+	}
+}
+
+func TestDownloadImagesToDirectory(t *testing.T) {
+	server := imageServer(t)
+	defer server.Close()
+	dir := filepath.Join(t.TempDir(), "images")
+	images := DownloadImages(context.Background(), []ImageSource{{Url: server.URL + "/a.png"}, {Url: server.URL + "/copy.png"}}, ImageDownloadOptions{Dir: dir})
+	if images[0].Err != nil || images[0].Data != nil || images[0].Path == "" || images[1].Path != images[0].Path {
+		t.Fatalf("Unexpected images: %+v", images)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected an image and its metadata, got %v (%v)", entries, err)
+	}
+	metadata, err := os.ReadFile(strings.TrimSuffix(images[0].Path, ".png") + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written DownloadedImage
+	if err := json.Unmarshal(metadata, &written); err != nil || written.Source.Url != server.URL+"/a.png" || written.Width != 3 {
+		t.Fatalf("Unexpected metadata: %s", metadata)
+	}
+}
+
+func TestWebpDimensions(t *testing.T) {
+	header := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00")
+	header = append(header, 0x1f, 0x00, 0x00, 0x0f, 0x00, 0x00)
+	if width, height := webpDimensions(header); width != 32 || height != 16 {
+		t.Fatalf("Unexpected dimensions: %dx%d", width, height)
+	}
+}
+
+func TestDownloadImagesToDirectoryErrors(t *testing.T) {
+	server := imageServer(t)
+	defer server.Close()
+	dir := t.TempDir()
+	hash := sha256.Sum256(testPng(t, 3, 2))
+	// a directory in place of the metadata file of the original makes its write fail
+	if err := os.Mkdir(filepath.Join(dir, hex.EncodeToString(hash[:])[:16]+".json"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	options := ImageDownloadOptions{Dir: dir, MaxBytes: 1024}
+	images := DownloadImages(context.Background(), []ImageSource{{Url: server.URL + "/a.png"}, {Url: server.URL + "/copy.png"}, {Url: server.URL + "/big.png"}}, options)
+	if images[0].Err == nil || images[1].DuplicateOf != images[0].Source.Url || images[1].Err != images[0].Err {
+		t.Fatalf("Expected the duplicate to share the error of the original, got %+v", images[:2])
+	}
+	if err := images[2].Err; err == nil || !strings.Contains(err.Error(), "image too large") {
+		t.Fatalf("Expected the big image to be rejected, got %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".download-") {
+			t.Fatalf("Expected no temporary file left, got %s", entry.Name())
+		}
+	}
+}
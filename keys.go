@@ -0,0 +1,238 @@
+package linkup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interface for the sources of the API key used by a LinkupClient.
+// The provider is consulted for every request, so keys can be rotated
+// without rebuilding the client.
+type KeyProvider interface {
+	Key(ctx context.Context) (string, error)
+}
+
+// Optional interface for key providers that want to be notified when the API
+// rejects one of their keys (401 Unauthorized or 402 Payment Required, i.e. insufficient credits)
+type KeyFeedback interface {
+	ReportRejectedKey(key string, statusCode int)
+}
+
+// Key provider always returning the same API key
+type StaticKeyProvider string
+
+func (p StaticKeyProvider) Key(ctx context.Context) (string, error) {
+	if p == "" {
+		return "", errors.New("the static api key is empty")
+	}
+	return string(p), nil
+}
+
+// Key provider reading the API key from an environment variable at every request
+type EnvKeyProvider struct {
+	// Name of the environment variable holding the key. Defaults to LINKUP_API_KEY
+	Name string
+}
+
+func (p EnvKeyProvider) Key(ctx context.Context) (string, error) {
+	name := p.Name
+	if name == "" {
+		name = "LINKUP_API_KEY"
+	}
+	key, ok := os.LookupEnv(name)
+	if !ok || key == "" {
+		return "", fmt.Errorf("could not find %s in the environment", name)
+	}
+	return key, nil
+}
+
+// Key provider reading the API key from a file, which is read again whenever
+// its modification time or size changes
+type FileKeyProvider struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// Constructor to create a new FileKeyProvider instance. The file is read once
+// to make sure that it contains a key.
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	provider := &FileKeyProvider{path: path}
+	if _, err := provider.Key(context.Background()); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func (p *FileKeyProvider) Key(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return "", err
+	}
+	if p.key != "" && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.key, nil
+	}
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", err
+	}
+	key := strings.TrimSpace(string(content))
+	if key == "" {
+		return "", fmt.Errorf("the api key file %s is empty", p.path)
+	}
+	p.key = key
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	return key, nil
+}
+
+// Enum representing how a KeyPool picks the key for the next request
+type KeyPoolStrategy int
+
+const (
+	// Use the keys in turn, spreading the requests across all of them
+	RoundRobinKeys KeyPoolStrategy = iota
+	// Always use the first usable key, moving to the next one only when it gets rejected
+	FailoverKeys
+)
+
+// Key provider holding several API keys, skipping the ones that the API rejected
+// with 401 Unauthorized or 402 Payment Required.
+// Rejected keys become usable again after the recovery interval (e.g. once credits are topped up).
+type KeyPool struct {
+	keys     []string
+	strategy KeyPoolStrategy
+	recovery time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	next     int
+	rejected map[string]time.Time
+}
+
+// Constructor to create a new KeyPool instance.
+// A recovery interval of zero means that rejected keys are never used again.
+func NewKeyPool(strategy KeyPoolStrategy, recovery time.Duration, keys ...string) (*KeyPool, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one api key must be provided to the key pool")
+	}
+	for i, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("the %d-th api key of the pool is empty", i)
+		}
+	}
+	return &KeyPool{
+		keys:     keys,
+		strategy: strategy,
+		recovery: recovery,
+		now:      time.Now,
+		rejected: make(map[string]time.Time),
+	}, nil
+}
+
+func (p *KeyPool) Key(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	start := 0
+	if p.strategy == RoundRobinKeys {
+		start = p.next
+	}
+	for i := range p.keys {
+		index := (start + i) % len(p.keys)
+		key := p.keys[index]
+		if !p.usable(key) {
+			continue
+		}
+		if p.strategy == RoundRobinKeys {
+			p.next = (index + 1) % len(p.keys)
+		}
+		return key, nil
+	}
+	return "", errors.New("all the api keys in the pool have been rejected")
+}
+
+func (p *KeyPool) usable(key string) bool {
+	rejectedAt, ok := p.rejected[key]
+	if !ok {
+		return true
+	}
+	if p.recovery > 0 && !p.now().Before(rejectedAt.Add(p.recovery)) {
+		delete(p.rejected, key)
+		return true
+	}
+	return false
+}
+
+func (p *KeyPool) ReportRejectedKey(key string, statusCode int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rejected[key] = p.now()
+}
+
+// Whether the API rejected the request because of the key it was sent with
+func isKeyRejection(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusPaymentRequired
+}
+
+// Request editor setting the Authorization header with the key returned by the provider
+func keyRequestEditor(keys KeyProvider) RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		key, err := keys.Key(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+key)
+		return nil
+	}
+}
+
+// HTTP doer reporting rejected keys to the provider and retrying the request
+// with the next key it returns, if any
+type keyRotatingDoer struct {
+	doer HttpRequestDoer
+	keys KeyProvider
+}
+
+func (d *keyRotatingDoer) Do(req *http.Request) (*http.Response, error) {
+	tried := make(map[string]bool)
+	for {
+		response, err := d.doer.Do(req)
+		if err != nil || !isKeyRejection(response.StatusCode) {
+			return response, err
+		}
+		key := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		tried[key] = true
+		if feedback, ok := d.keys.(KeyFeedback); ok {
+			feedback.ReportRejectedKey(key, response.StatusCode)
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return response, nil
+		}
+		next, keyErr := d.keys.Key(req.Context())
+		if keyErr != nil || tried[next] {
+			return response, nil
+		}
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return response, nil
+			}
+			retry.Body = body
+		}
+		retry.Header.Set("Authorization", "Bearer "+next)
+		response.Body.Close()
+		req = retry
+	}
+}
@@ -0,0 +1,166 @@
+package linkup
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStaticAndEnvKeyProviders(t *testing.T) {
+	key, err := StaticKeyProvider("static").Key(context.Background())
+	if err != nil || key != "static" {
+		t.Fatalf("Unexpected key %q (error: %v)", key, err)
+	}
+	if _, err := StaticKeyProvider("").Key(context.Background()); err == nil {
+		t.Fatal("Expected an error for an empty static key")
+	}
+	t.Setenv("TEST_LINKUP_KEY", "from-env")
+	key, err = EnvKeyProvider{Name: "TEST_LINKUP_KEY"}.Key(context.Background())
+	if err != nil || key != "from-env" {
+		t.Fatalf("Unexpected key %q (error: %v)", key, err)
+	}
+	t.Setenv("TEST_LINKUP_KEY", "rotated")
+	key, _ = EnvKeyProvider{Name: "TEST_LINKUP_KEY"}.Key(context.Background())
+	if key != "rotated" {
+		t.Fatalf("Expected the rotated key, got %q", key)
+	}
+	_, err = EnvKeyProvider{Name: "TEST_LINKUP_MISSING_KEY"}.Key(context.Background())
+	if err == nil || err.Error() != "could not find TEST_LINKUP_MISSING_KEY in the environment" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestFileKeyProviderReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	key, _ := provider.Key(context.Background())
+	if key != "first" {
+		t.Fatalf("Expected key %q, got %q", "first", key)
+	}
+	if err := os.WriteFile(path, []byte("second-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, _ = provider.Key(context.Background())
+	if key != "second-key" {
+		t.Fatalf("Expected key %q, got %q", "second-key", key)
+	}
+	if err := os.WriteFile(path, []byte("  "), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Key(context.Background()); err == nil {
+		t.Fatal("Expected an error for an empty key file")
+	}
+}
+
+func TestKeyPoolStrategies(t *testing.T) {
+	pool, err := NewKeyPool(RoundRobinKeys, 0, "a", "b", "c")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	var got []string
+	for range 4 {
+		key, _ := pool.Key(context.Background())
+		got = append(got, key)
+	}
+	if strings.Join(got, ",") != "a,b,c,a" {
+		t.Fatalf("Unexpected round robin order: %v", got)
+	}
+	pool.ReportRejectedKey("b", http.StatusUnauthorized)
+	got = nil
+	for range 3 {
+		key, _ := pool.Key(context.Background())
+		got = append(got, key)
+	}
+	if strings.Join(got, ",") != "c,a,c" {
+		t.Fatalf("Unexpected round robin order after rejection: %v", got)
+	}
+
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	failover, _ := NewKeyPool(FailoverKeys, time.Hour, "a", "b")
+	failover.now = func() time.Time { return current }
+	for range 2 {
+		if key, _ := failover.Key(context.Background()); key != "a" {
+			t.Fatalf("Expected key %q, got %q", "a", key)
+		}
+	}
+	failover.ReportRejectedKey("a", http.StatusPaymentRequired)
+	if key, _ := failover.Key(context.Background()); key != "b" {
+		t.Fatalf("Expected key %q, got %q", "b", key)
+	}
+	failover.ReportRejectedKey("b", http.StatusUnauthorized)
+	if _, err := failover.Key(context.Background()); err == nil {
+		t.Fatal("Expected an error when all the keys are rejected")
+	}
+	current = current.Add(time.Hour)
+	if key, _ := failover.Key(context.Background()); key != "a" {
+		t.Fatalf("Expected key %q to recover, got %q", "a", key)
+	}
+
+	if _, err := NewKeyPool(FailoverKeys, 0); err == nil {
+		t.Fatal("Expected an error for an empty pool")
+	}
+}
+
+func TestKeyPoolClientSkipsRejectedKeys(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if r.Body != nil {
+			_, _ = io.ReadAll(r.Body)
+		}
+		mu.Lock()
+		seen = append(seen, key)
+		mu.Unlock()
+		switch key {
+		case "revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "empty":
+			w.WriteHeader(http.StatusPaymentRequired)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/v1/fetch" {
+				_, _ = w.Write([]byte(`{"markdown": "# Hello"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"balance": 42}`))
+		}
+	}))
+	defer server.Close()
+
+	pool, _ := NewKeyPool(FailoverKeys, 0, "revoked", "empty", "valid")
+	client, err := NewLinkupClient("", WithKeyProvider(pool), WithServerUrl(server.URL))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	balance, err := client.GetBalance()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if balance != 42 {
+		t.Fatalf("Expected a balance of 42, got %f", balance)
+	}
+	output, err := client.Fetch("https://fetch.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if output.Markdown != "# Hello" {
+		t.Fatalf("Unexpected markdown: %s", output.Markdown)
+	}
+	if strings.Join(seen, ",") != "revoked,empty,valid,valid" {
+		t.Fatalf("Unexpected keys sent to the server: %v", seen)
+	}
+}
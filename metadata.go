@@ -0,0 +1,363 @@
+package linkup
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Struct type representing the metadata of a fetched page, extracted from its raw HTML
+type PageMetadata struct {
+	// Title The title of the page: the `<title>` element, or the OpenGraph, Twitter, JSON-LD title or the first `<h1>`.
+	Title string
+
+	// Description The description of the page, from the meta tags or JSON-LD.
+	Description string
+
+	// CanonicalUrl The canonical URL of the page, as found in the page (it may be relative).
+	CanonicalUrl string
+
+	// Language The language of the page, e.g. `en` or `en-US`.
+	Language string
+
+	// Author The author of the page.
+	Author string
+
+	// SiteName The name of the website the page belongs to.
+	SiteName string
+
+	// Image The main image of the page (OpenGraph or Twitter card image).
+	Image string
+
+	// Keywords The keywords of the page.
+	Keywords []string
+
+	// PublishedTime The publication date of the page, if it can be found and parsed.
+	PublishedTime *time.Time
+
+	// ModifiedTime The last modification date of the page, if it can be found and parsed.
+	ModifiedTime *time.Time
+
+	// OpenGraph The OpenGraph properties of the page (`og:*` and `article:*`), keyed by property.
+	OpenGraph map[string]string
+
+	// Twitter The Twitter card properties of the page (`twitter:*`), keyed by name.
+	Twitter map[string]string
+
+	// JsonLd The JSON-LD objects embedded in the page, with `@graph` arrays flattened.
+	JsonLd []map[string]any
+}
+
+// Extract the metadata of a fetched page from its raw HTML.
+// The page must be fetched with `IncludeRawHtml` set to true.
+func ExtractMetadata(output *FetchOutput) (*PageMetadata, error) {
+	if output.RawHtml == nil {
+		return nil, errors.New("the RawHtml field is null: fetch the page with IncludeRawHtml set to true")
+	}
+	return ExtractMetadataFromHtml(strings.NewReader(*output.RawHtml))
+}
+
+// Extract the metadata of a page from its HTML
+func ExtractMetadataFromHtml(r io.Reader) (*PageMetadata, error) {
+	collector := &metadataCollector{
+		meta: make(map[string]string),
+		metadata: &PageMetadata{
+			OpenGraph: make(map[string]string),
+			Twitter:   make(map[string]string),
+		},
+	}
+	tokenizer := html.NewTokenizer(r)
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				break
+			}
+			return nil, tokenizer.Err()
+		}
+		collector.handle(tokenizer, tokenType)
+	}
+	return collector.finish(), nil
+}
+
+type metadataCollector struct {
+	metadata *PageMetadata
+	// lowercased meta names (and http-equiv, itemprop) mapped to their first content
+	meta map[string]string
+
+	canonical string
+	title     strings.Builder
+	inTitle   bool
+	titleDone bool
+	inSvg     int
+	h1        strings.Builder
+	inH1      bool
+	h1Done    bool
+	inJsonLd  bool
+	jsonLd    strings.Builder
+	firstTime string
+	htmlLang  string
+}
+
+func (c *metadataCollector) handle(tokenizer *html.Tokenizer, tokenType html.TokenType) {
+	switch tokenType {
+	case html.StartTagToken, html.SelfClosingTagToken:
+		token := tokenizer.Token()
+		attrs := attributes(token)
+		switch token.DataAtom {
+		case atom.Html:
+			c.htmlLang = attrs["lang"]
+		case atom.Svg:
+			// svg elements have their own <title>
+			if tokenType == html.StartTagToken {
+				c.inSvg++
+			}
+		case atom.Title:
+			c.inTitle = c.inSvg == 0 && !c.titleDone && tokenType == html.StartTagToken
+		case atom.H1:
+			c.inH1 = !c.h1Done && tokenType == html.StartTagToken
+		case atom.Meta:
+			c.handleMeta(attrs)
+		case atom.Link:
+			for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+				if rel == "canonical" && c.canonical == "" {
+					c.canonical = strings.TrimSpace(attrs["href"])
+				}
+			}
+		case atom.Script:
+			c.inJsonLd = strings.EqualFold(strings.TrimSpace(attrs["type"]), "application/ld+json") && tokenType == html.StartTagToken
+			c.jsonLd.Reset()
+		case atom.Time:
+			if c.firstTime == "" {
+				c.firstTime = attrs["datetime"]
+			}
+		}
+	case html.TextToken:
+		switch {
+		case c.inTitle:
+			c.title.Write(tokenizer.Text())
+		case c.inJsonLd:
+			c.jsonLd.Write(tokenizer.Text())
+		case c.inH1:
+			c.h1.Write(tokenizer.Text())
+		}
+	case html.EndTagToken:
+		name, _ := tokenizer.TagName()
+		switch atom.Lookup(name) {
+		case atom.Svg:
+			c.inSvg = max(c.inSvg-1, 0)
+		case atom.Title:
+			if c.inTitle {
+				c.inTitle = false
+				c.titleDone = true
+			}
+		case atom.H1:
+			if c.inH1 {
+				c.inH1 = false
+				c.h1Done = true
+			}
+		case atom.Script:
+			if c.inJsonLd {
+				c.inJsonLd = false
+				c.addJsonLd(c.jsonLd.String())
+			}
+		}
+	}
+}
+
+func attributes(token html.Token) map[string]string {
+	attrs := make(map[string]string, len(token.Attr))
+	for _, attr := range token.Attr {
+		attrs[strings.ToLower(attr.Key)] = attr.Val
+	}
+	return attrs
+}
+
+func (c *metadataCollector) handleMeta(attrs map[string]string) {
+	content := strings.TrimSpace(attrs["content"])
+	if content == "" {
+		return
+	}
+	if property := strings.ToLower(strings.TrimSpace(attrs["property"])); property != "" {
+		if strings.HasPrefix(property, "og:") || strings.HasPrefix(property, "article:") {
+			if _, ok := c.metadata.OpenGraph[property]; !ok {
+				c.metadata.OpenGraph[property] = content
+			}
+		}
+		// some sites use `property` for Twitter cards too
+		if strings.HasPrefix(property, "twitter:") {
+			if _, ok := c.metadata.Twitter[property]; !ok {
+				c.metadata.Twitter[property] = content
+			}
+		}
+	}
+	if name := strings.ToLower(strings.TrimSpace(attrs["name"])); name != "" {
+		if strings.HasPrefix(name, "twitter:") {
+			if _, ok := c.metadata.Twitter[name]; !ok {
+				c.metadata.Twitter[name] = content
+			}
+		} else if _, ok := c.meta[name]; !ok {
+			c.meta[name] = content
+		}
+	}
+	for _, key := range []string{"http-equiv", "itemprop"} {
+		if name := strings.ToLower(strings.TrimSpace(attrs[key])); name != "" {
+			if _, ok := c.meta[name]; !ok {
+				c.meta[name] = content
+			}
+		}
+	}
+}
+
+func (c *metadataCollector) addJsonLd(content string) {
+	var value any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &value); err != nil {
+		return
+	}
+	var flatten func(value any)
+	flatten = func(value any) {
+		switch typed := value.(type) {
+		case []any:
+			for _, item := range typed {
+				flatten(item)
+			}
+		case map[string]any:
+			if graph, ok := typed["@graph"]; ok {
+				flatten(graph)
+				return
+			}
+			c.metadata.JsonLd = append(c.metadata.JsonLd, typed)
+		}
+	}
+	flatten(value)
+}
+
+// Returns the first value found in the JSON-LD objects for the given key,
+// as a string (for objects, their `name` or `@id`)
+func (c *metadataCollector) jsonLdValue(key string) string {
+	for _, object := range c.metadata.JsonLd {
+		if value := jsonLdString(object[key]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func jsonLdString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return strings.TrimSpace(typed)
+	case []any:
+		var values []string
+		for _, item := range typed {
+			if text := jsonLdString(item); text != "" {
+				values = append(values, text)
+			}
+		}
+		return strings.Join(values, ", ")
+	case map[string]any:
+		if name := jsonLdString(typed["name"]); name != "" {
+			return name
+		}
+		return jsonLdString(typed["@id"])
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (c *metadataCollector) finish() *PageMetadata {
+	m := c.metadata
+	og, twitter := m.OpenGraph, m.Twitter
+	m.Title = firstNonEmpty(collapseSpaces(c.title.String()), og["og:title"], twitter["twitter:title"], c.jsonLdValue("headline"), c.jsonLdValue("name"), collapseSpaces(c.h1.String()))
+	m.Description = firstNonEmpty(c.meta["description"], og["og:description"], twitter["twitter:description"], c.jsonLdValue("description"))
+	m.CanonicalUrl = firstNonEmpty(c.canonical, og["og:url"], c.jsonLdValue("url"))
+	m.Language = firstNonEmpty(c.htmlLang, c.meta["content-language"], c.meta["language"], c.jsonLdValue("inLanguage"), strings.ReplaceAll(og["og:locale"], "_", "-"))
+	m.Author = firstNonEmpty(c.meta["author"], og["article:author"], c.meta["dc.creator"], c.jsonLdValue("author"), twitter["twitter:creator"])
+	m.SiteName = firstNonEmpty(og["og:site_name"], c.meta["application-name"], twitter["twitter:site"])
+	m.Image = firstNonEmpty(og["og:image"], og["og:image:url"], twitter["twitter:image"], twitter["twitter:image:src"])
+	if keywords := c.meta["keywords"]; keywords != "" {
+		for _, keyword := range strings.Split(keywords, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				m.Keywords = append(m.Keywords, keyword)
+			}
+		}
+	}
+	m.PublishedTime = firstDate(
+		og["article:published_time"], og["og:published_time"], c.meta["datepublished"], c.jsonLdValue("datePublished"),
+		c.meta["date"], c.meta["pubdate"], c.meta["publish_date"], c.meta["dc.date"], c.meta["dcterms.created"], c.firstTime,
+	)
+	m.ModifiedTime = firstDate(
+		og["article:modified_time"], og["og:updated_time"], c.meta["datemodified"], c.jsonLdValue("dateModified"),
+		c.meta["dcterms.modified"], c.meta["last-modified"],
+	)
+	return m
+}
+
+// Returns the first of the values that can be parsed as a date, so that a malformed
+// date does not hide the valid ones that follow it
+func firstDate(values ...string) *time.Time {
+	for _, value := range values {
+		if date, ok := parseFlexibleDate(value); ok {
+			return &date
+		}
+	}
+	return nil
+}
+
+// Collapses the whitespace of a text already unescaped by the tokenizer
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+var flexibleDateLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"2006-01",
+	"2006",
+}
+
+// Parses a date written in one of the formats commonly found in web pages and feeds
+func parseFlexibleDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range flexibleDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
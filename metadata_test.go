@@ -0,0 +1,105 @@
+package linkup
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+const metadataTestPage = `<!DOCTYPE html>
+<html lang="en-US">
+<head>
+  <title>  Pricing &amp; Plans | Example  </title>
+  <meta name="description" content="Compare our plans.">
+  <meta name="keywords" content="pricing, plans, ,saas">
+  <meta property="og:title" content="Pricing and Plans">
+  <meta property="og:site_name" content="Example">
+  <meta property="og:image" content="https://example.com/og.png">
+  <meta property="article:published_time" content="2024-03-01T10:00:00Z">
+  <meta name="twitter:card" content="summary_large_image">
+  <meta name="twitter:creator" content="@example">
+  <link rel="alternate canonical" href="https://example.com/pricing">
+  <script type="application/ld+json">
+  {"@context": "https://schema.org", "@graph": [
+    {"@type": "WebSite", "name": "Example"},
+    {"@type": "Article", "headline": "Pricing", "author": [{"@type": "Person", "name": "Jane Doe"}], "dateModified": "2024-04-02"}
+  ]}
+  </script>
+  <script type="application/ld+json">not json</script>
+</head>
+<body>
+  <svg><title>icon</title></svg>
+  <h1>Our pricing</h1>
+</body>
+</html>`
+
+func TestExtractMetadata(t *testing.T) {
+	page := metadataTestPage
+	metadata, err := ExtractMetadata(&FetchOutput{RawHtml: &page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Title != "Pricing & Plans | Example" {
+		t.Fatalf("Unexpected title: %q", metadata.Title)
+	}
+	if metadata.Description != "Compare our plans." || metadata.CanonicalUrl != "https://example.com/pricing" || metadata.Language != "en-US" {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+	if metadata.Author != "Jane Doe" || metadata.SiteName != "Example" || metadata.Image != "https://example.com/og.png" {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+	if !slices.Equal(metadata.Keywords, []string{"pricing", "plans", "saas"}) {
+		t.Fatalf("Unexpected keywords: %v", metadata.Keywords)
+	}
+	if metadata.PublishedTime == nil || !metadata.PublishedTime.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected published time: %v", metadata.PublishedTime)
+	}
+	if metadata.ModifiedTime == nil || !metadata.ModifiedTime.Equal(time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected modified time: %v", metadata.ModifiedTime)
+	}
+	if metadata.OpenGraph["og:title"] != "Pricing and Plans" || metadata.Twitter["twitter:card"] != "summary_large_image" {
+		t.Fatalf("Unexpected OpenGraph or Twitter tags: %v, %v", metadata.OpenGraph, metadata.Twitter)
+	}
+	if len(metadata.JsonLd) != 2 || metadata.JsonLd[1]["@type"] != "Article" {
+		t.Fatalf("Unexpected JSON-LD: %v", metadata.JsonLd)
+	}
+}
+
+func TestExtractMetadataFallbacks(t *testing.T) {
+	page := `<html><body><svg><title>icon</title></svg><h1>Release <em>notes</em></h1><time datetime="March 5, 2023">last spring</time></body></html>`
+	metadata, err := ExtractMetadata(&FetchOutput{RawHtml: &page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Title != "Release notes" {
+		t.Fatalf("Unexpected title: %q", metadata.Title)
+	}
+	if metadata.PublishedTime == nil || metadata.PublishedTime.Year() != 2023 || metadata.ModifiedTime != nil {
+		t.Fatalf("Unexpected dates: %v, %v", metadata.PublishedTime, metadata.ModifiedTime)
+	}
+	if _, err := ExtractMetadata(&FetchOutput{Markdown: "# Title"}); err == nil {
+		t.Fatal("Expected an error when the raw HTML is missing")
+	}
+}
+
+func TestExtractMetadataEscapesAndMalformedDates(t *testing.T) {
+	page := `<html><head><title>Fish &amp;amp; Chips</title>
+<meta property="article:published_time" content="last Tuesday">
+<meta name="date" content="2024-02-01">
+<meta property="article:modified_time" content="soon">
+<meta name="dcterms.modified" content="2024-03-01T10:00:00Z">
+</head></html>`
+	metadata, err := ExtractMetadata(&FetchOutput{RawHtml: &page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Title != "Fish &amp; Chips" {
+		t.Fatalf("Expected the title to be unescaped once, got %q", metadata.Title)
+	}
+	if metadata.PublishedTime == nil || metadata.PublishedTime.Month() != 2 {
+		t.Fatalf("Expected the first date that parses to be published, got %v", metadata.PublishedTime)
+	}
+	if metadata.ModifiedTime == nil || metadata.ModifiedTime.Month() != 3 {
+		t.Fatalf("Expected the first date that parses to be modified, got %v", metadata.ModifiedTime)
+	}
+}
@@ -0,0 +1,183 @@
+package linkup
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+)
+
+// Options to run several queries with `MultiSearch`
+type MultiSearchOptions struct {
+	// Concurrency The maximum number of searches in flight.
+	Concurrency int
+
+	// RankConstant The constant k of reciprocal rank fusion: a result at rank r (starting from 1)
+	// in the results of a query scores 1/(k+r). Higher values flatten the differences between ranks.
+	RankConstant float64
+
+	// MaxResults The maximum number of fused results. Zero means no limit.
+	MaxResults int
+
+	// SearchOptions The options of every search, merged with the defaults of the client.
+	SearchOptions AdditionalSearchOptions
+}
+
+func DefaultMultiSearchOptions() MultiSearchOptions {
+	return MultiSearchOptions{
+		Concurrency:  4,
+		RankConstant: 60,
+	}
+}
+
+// Struct type representing a result of several searches, fused by reciprocal rank fusion
+type FusedResult struct {
+	// Result The result, as returned by the first query that found it.
+	Result TextSearchResultDto
+
+	// CanonicalUrl The canonical URL of the result, used to deduplicate the results.
+	CanonicalUrl string
+
+	// Score The sum of the reciprocal ranks of the result in the results of each query.
+	Score float64
+
+	// Queries The queries that returned the result, in the order they were given.
+	Queries []string
+
+	// Ranks The rank (starting from 1) of the result in the results of each of the Queries.
+	Ranks []int
+}
+
+// Struct type representing the output of `MultiSearch`
+type MultiSearchOutput struct {
+	// Results The deduplicated text results, from the highest score to the lowest.
+	Results []FusedResult
+
+	// ImageResults The deduplicated image results, in the order of the queries.
+	ImageResults []ImageSearchResultDto
+
+	// Errors The errors of the queries that failed, keyed by query.
+	Errors map[string]error
+}
+
+// Run several variants of a query concurrently with `GetSearchResults`, and merge their results
+// using reciprocal rank fusion: results found by several queries, or ranked high, come first.
+// Results are deduplicated by canonical URL. An error is returned only when every query fails;
+// otherwise the errors of the failed queries are reported in the output.
+func (l *LinkupClient) MultiSearch(queries []string, depth SearchDepth, multiSearchOptions ...MultiSearchOptions) (*MultiSearchOutput, error) {
+	var options MultiSearchOptions
+	switch len(multiSearchOptions) {
+	case 0:
+		options = DefaultMultiSearchOptions()
+	default:
+		options = multiSearchOptions[0]
+		defaults := DefaultMultiSearchOptions()
+		if options.Concurrency <= 0 {
+			options.Concurrency = defaults.Concurrency
+		}
+		if options.RankConstant <= 0 {
+			options.RankConstant = defaults.RankConstant
+		}
+	}
+	var unique []string
+	for _, query := range queries {
+		if !slices.Contains(unique, query) {
+			unique = append(unique, query)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, errors.New("no query to run")
+	}
+
+	outputs := make([]*SearchResultsOutput, len(unique))
+	errs := make([]error, len(unique))
+	semaphore := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+	for i, query := range unique {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			outputs[i], errs[i] = l.GetSearchResults(query, depth, options.SearchOptions)
+		}(i, query)
+	}
+	wg.Wait()
+
+	return fuseResults(unique, outputs, errs, options)
+}
+
+func fuseResults(queries []string, outputs []*SearchResultsOutput, errs []error, options MultiSearchOptions) (*MultiSearchOutput, error) {
+	output := &MultiSearchOutput{Errors: make(map[string]error)}
+	fused := make(map[string]*FusedResult)
+	var order []*FusedResult
+	seenImages := make(map[string]bool)
+	for i, query := range queries {
+		if errs[i] != nil {
+			output.Errors[query] = errs[i]
+			continue
+		}
+		rank := 0
+		seen := make(map[string]bool)
+		for _, result := range outputs[i].TextResults {
+			key := canonical.URL(result.Url)
+			// duplicates within the results of a query keep their first rank
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			rank++
+			current, ok := fused[key]
+			if !ok {
+				current = &FusedResult{Result: result, CanonicalUrl: key}
+				fused[key] = current
+				order = append(order, current)
+			}
+			current.Score += 1 / (options.RankConstant + float64(rank))
+			current.Queries = append(current.Queries, query)
+			current.Ranks = append(current.Ranks, rank)
+		}
+		for _, image := range outputs[i].ImageResults {
+			key := canonical.URL(image.Url)
+			if !seenImages[key] {
+				seenImages[key] = true
+				output.ImageResults = append(output.ImageResults, image)
+			}
+		}
+	}
+	if len(output.Errors) == len(queries) {
+		return nil, fmt.Errorf("every query failed: %w", errors.Join(errs...))
+	}
+	// ties keep the order in which the results were first found
+	slices.SortStableFunc(order, func(a *FusedResult, b *FusedResult) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	if options.MaxResults > 0 && len(order) > options.MaxResults {
+		order = order[:options.MaxResults]
+	}
+	output.Results = make([]FusedResult, len(order))
+	for i, result := range order {
+		output.Results[i] = *result
+	}
+	return output, nil
+}
+
+// Get the fused results as search results, to use them wherever search results are expected
+func (o *MultiSearchOutput) SearchResults() *SearchResultsOutput {
+	results := &SearchResultsOutput{
+		TextResults:  make([]TextSearchResultDto, len(o.Results)),
+		ImageResults: o.ImageResults,
+	}
+	for i, result := range o.Results {
+		results.TextResults[i] = result.Result
+	}
+	return results
+}
@@ -0,0 +1,109 @@
+package linkup
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type QueryMockClient struct {
+	MockClient
+	results map[string][]TextSearchResultDto
+	images  map[string][]ImageSearchResultDto
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (m *QueryMockClient) SearchWithResponse(ctx context.Context, body SearchJSONRequestBody, requestEditors ...RequestEditorFn) (*SearchResponse, error) {
+	m.mu.Lock()
+	m.queries = append(m.queries, body.Q)
+	m.mu.Unlock()
+	results, ok := m.results[body.Q]
+	if !ok {
+		return &SearchResponse{
+			Body:         []byte("bad request"),
+			HTTPResponse: &http.Response{Status: "400 Bad Request", StatusCode: 400},
+		}, nil
+	}
+	items := []SearchResultsDto_Results_Item{}
+	for _, result := range results {
+		item := SearchResultsDto_Results_Item{}
+		_ = item.FromTextSearchResultDto(result)
+		items = append(items, item)
+	}
+	for _, image := range m.images[body.Q] {
+		item := SearchResultsDto_Results_Item{}
+		_ = item.FromImageSearchResultDto(image)
+		items = append(items, item)
+	}
+	marshaled, err := json.Marshal(SearchResultsDto{Results: items})
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResponse{Body: marshaled, HTTPResponse: &http.Response{Status: "200 OK", StatusCode: 200}}, nil
+}
+
+func textResult(url string) TextSearchResultDto {
+	return TextSearchResultDto{Name: url, Url: url, Content: "content of " + url, Type: "text"}
+}
+
+func TestMultiSearch(t *testing.T) {
+	mock := &QueryMockClient{
+		results: map[string][]TextSearchResultDto{
+			"go language": {textResult("https://go.dev"), textResult("https://a.com"), textResult("https://b.com")},
+			"golang":      {textResult("https://b.com"), textResult("https://GO.dev/"), textResult("https://b.com/"), textResult("https://c.com")},
+		},
+		images: map[string][]ImageSearchResultDto{
+			"go language": {{Name: "gopher", Url: "https://go.dev/gopher.png", Type: "image"}},
+			"golang":      {{Name: "gopher", Url: "https://go.dev/gopher.png", Type: "image"}},
+		},
+	}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	output, err := client.MultiSearch([]string{"go language", "golang", "broken", "golang"}, Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.queries) != 3 {
+		t.Fatalf("Expected the duplicate query to run once, got %v", mock.queries)
+	}
+	var urls []string
+	for _, result := range output.Results {
+		urls = append(urls, result.CanonicalUrl)
+	}
+	if !slices.Equal(urls, []string{"https://go.dev", "https://b.com", "https://a.com", "https://c.com"}) {
+		t.Fatalf("Unexpected order: %v", urls)
+	}
+	first := output.Results[0]
+	if math.Abs(first.Score-(1.0/61+1.0/62)) > 1e-12 || !slices.Equal(first.Queries, []string{"go language", "golang"}) || !slices.Equal(first.Ranks, []int{1, 2}) {
+		t.Fatalf("Unexpected fused result: %+v", first)
+	}
+	if first.Result.Url != "https://go.dev" {
+		t.Fatalf("Expected the result of the first query, got %+v", first.Result)
+	}
+	// the duplicate of b.com in the results of "golang" does not shift the rank of c.com
+	if last := output.Results[3]; !slices.Equal(last.Ranks, []int{3}) {
+		t.Fatalf("Unexpected ranks: %+v", last)
+	}
+	if len(output.ImageResults) != 1 || len(output.Errors) != 1 || output.Errors["broken"] == nil {
+		t.Fatalf("Unexpected output: %+v", output)
+	}
+	if results := output.SearchResults(); len(results.TextResults) != 4 || results.TextResults[1].Url != "https://b.com" {
+		t.Fatalf("Unexpected search results: %+v", results)
+	}
+
+	limited, err := client.MultiSearch([]string{"go language", "golang"}, Standard, MultiSearchOptions{MaxResults: 2})
+	if err != nil || len(limited.Results) != 2 {
+		t.Fatalf("Unexpected limited output: %+v, %v", limited, err)
+	}
+	if _, err := client.MultiSearch([]string{"broken", "also broken"}, Standard); err == nil {
+		t.Fatal("Expected an error when every query fails")
+	}
+	if _, err := client.MultiSearch(nil, Standard); err == nil {
+		t.Fatal("Expected an error without queries")
+	}
+}
@@ -0,0 +1,644 @@
+package linkup
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/AstraBert/linkup-go-sdk/fetch/markdown"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Options to convert HTML into markdown
+type HtmlConversionOptions struct {
+	// BaseUrl The URL of the page, used to resolve relative links and images.
+	BaseUrl string
+
+	// MainContentOnly Whether to keep only the main content of the page, removing navigation,
+	// footers, sidebars, ads and other boilerplate.
+	MainContentOnly bool
+
+	// IncludeLinks Whether to keep links; otherwise only their text is kept.
+	IncludeLinks bool
+
+	// IncludeImages Whether to keep images.
+	IncludeImages bool
+}
+
+func DefaultHtmlConversionOptions() HtmlConversionOptions {
+	return HtmlConversionOptions{
+		MainContentOnly: true,
+		IncludeLinks:    true,
+		IncludeImages:   true,
+	}
+}
+
+// Struct type representing a page converted from HTML
+type ConvertedPage struct {
+	// Title The title of the page.
+	Title string
+
+	// Markdown The content of the page as markdown.
+	Markdown string
+
+	// Text The content of the page as plain text.
+	Text string
+}
+
+// Convert the raw HTML of a fetched page into markdown and plain text.
+// The page must be fetched with `IncludeRawHtml` set to true.
+func ConvertRawHtml(output *FetchOutput, conversionOptions ...HtmlConversionOptions) (*ConvertedPage, error) {
+	if output.RawHtml == nil {
+		return nil, errors.New("the RawHtml field is null: fetch the page with IncludeRawHtml set to true")
+	}
+	return ConvertHtml(strings.NewReader(*output.RawHtml), conversionOptions...)
+}
+
+// Convert an HTML document into markdown and plain text
+func ConvertHtml(r io.Reader, conversionOptions ...HtmlConversionOptions) (*ConvertedPage, error) {
+	var options HtmlConversionOptions
+	switch len(conversionOptions) {
+	case 0:
+		options = DefaultHtmlConversionOptions()
+	default:
+		options = conversionOptions[0]
+	}
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	converter := &htmlConverter{options: options}
+	if options.BaseUrl != "" {
+		if base, err := url.Parse(options.BaseUrl); err == nil {
+			converter.base = base
+		}
+	}
+	page := &ConvertedPage{Title: documentTitle(root)}
+	removeBoilerplate(root, options.MainContentOnly)
+	content := findElement(root, atom.Body)
+	if content == nil {
+		content = root
+	}
+	if options.MainContentOnly {
+		content = mainContent(content)
+	}
+	page.Markdown = strings.Join(converter.blocks(content), "\n\n")
+	page.Text = markdown.Parse(page.Markdown).PlainText()
+	return page, nil
+}
+
+// Struct type representing the comparison of the markdown returned by the API
+// with the markdown converted locally from the raw HTML of the same page
+type RepresentationComparison struct {
+	// Converted The page converted from the raw HTML.
+	Converted *ConvertedPage
+
+	// ApiWords The number of words of the markdown returned by the API.
+	ApiWords int
+
+	// ConvertedWords The number of words of the converted page.
+	ConvertedWords int
+
+	// Coverage The fraction of the distinct words of the converted page that appear in the markdown returned by the API.
+	Coverage float64
+
+	// PreferConverted Whether the converted page should be preferred: the API markdown misses more than
+	// a fifth of its words.
+	PreferConverted bool
+}
+
+// Compare the markdown returned by the API with the markdown converted from the raw HTML of the page,
+// to detect when the API dropped content
+func CompareRepresentations(output *FetchOutput, conversionOptions ...HtmlConversionOptions) (*RepresentationComparison, error) {
+	converted, err := ConvertRawHtml(output, conversionOptions...)
+	if err != nil {
+		return nil, err
+	}
+	apiWords := words(markdown.Parse(output.Markdown).PlainText())
+	convertedWords := words(converted.Text)
+	comparison := &RepresentationComparison{
+		Converted:      converted,
+		ApiWords:       len(apiWords),
+		ConvertedWords: len(convertedWords),
+		Coverage:       1,
+	}
+	apiSet := make(map[string]bool, len(apiWords))
+	for _, word := range apiWords {
+		apiSet[word] = true
+	}
+	distinct := make(map[string]bool, len(convertedWords))
+	covered := 0
+	for _, word := range convertedWords {
+		if distinct[word] {
+			continue
+		}
+		distinct[word] = true
+		if apiSet[word] {
+			covered++
+		}
+	}
+	if len(distinct) > 0 {
+		comparison.Coverage = float64(covered) / float64(len(distinct))
+	}
+	comparison.PreferConverted = comparison.Coverage < 0.8
+	return comparison, nil
+}
+
+// Returns the lowercased words of a text
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func findElement(node *html.Node, element atom.Atom) *html.Node {
+	if node.Type == html.ElementNode && node.DataAtom == element {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, element); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func documentTitle(root *html.Node) string {
+	if title := findElement(root, atom.Title); title != nil {
+		if text := nodeText(title); text != "" {
+			return text
+		}
+	}
+	if h1 := findElement(root, atom.H1); h1 != nil {
+		return nodeText(h1)
+	}
+	return ""
+}
+
+var (
+	alwaysRemoved = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Iframe: true,
+		atom.Svg: true, atom.Canvas: true, atom.Object: true, atom.Embed: true, atom.Form: true,
+		atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Head: true,
+	}
+	boilerplateElements = map[atom.Atom]bool{
+		atom.Nav: true, atom.Footer: true, atom.Aside: true, atom.Dialog: true,
+	}
+	boilerplateRoles    = regexp.MustCompile(`(?i)^(navigation|banner|contentinfo|complementary|search|dialog|alert)$`)
+	negativeAttributes  = regexp.MustCompile(`(?i)(^|[\s_-])(nav|navbar|menu|footer|sidebar|side-bar|ad|ads|advert|advertisement|banner|sponsor|promo|cookie|cookies|consent|social|share|sharing|related|recommended|comment|comments|breadcrumbs?|popup|modal|newsletter|subscribe|widget|masthead|skip)([\s_-]|$)`)
+	positiveAttributes  = regexp.MustCompile(`(?i)(article|content|main|post|entry|body|text|story|blog)`)
+	hiddenStyle         = regexp.MustCompile(`(?i)display\s*:\s*none|visibility\s*:\s*hidden`)
+	containerElements   = map[atom.Atom]bool{atom.Html: true, atom.Body: true, atom.Article: true, atom.Main: true}
+	paragraphCandidates = map[atom.Atom]bool{atom.P: true, atom.Pre: true, atom.Td: true, atom.Blockquote: true, atom.Li: true}
+)
+
+func attribute(node *html.Node, name string) string {
+	value, _ := attributeValue(node, name)
+	return value
+}
+
+func classAndId(node *html.Node) string {
+	return attribute(node, "class") + " " + attribute(node, "id")
+}
+
+// Removes the elements that are never content and, when keeping only the main content,
+// the navigation, footers, sidebars and the elements whose class or id suggests boilerplate
+func removeBoilerplate(node *html.Node, mainContentOnly bool) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.CommentNode:
+			node.RemoveChild(child)
+		case child.Type == html.ElementNode && isBoilerplate(child, mainContentOnly):
+			node.RemoveChild(child)
+		default:
+			removeBoilerplate(child, mainContentOnly)
+		}
+		child = next
+	}
+}
+
+func isBoilerplate(node *html.Node, mainContentOnly bool) bool {
+	if alwaysRemoved[node.DataAtom] {
+		return true
+	}
+	_, hidden := attributeValue(node, "hidden")
+	if hidden || attribute(node, "aria-hidden") == "true" || hiddenStyle.MatchString(attribute(node, "style")) {
+		return true
+	}
+	if !mainContentOnly || containerElements[node.DataAtom] {
+		return false
+	}
+	if boilerplateElements[node.DataAtom] || boilerplateRoles.MatchString(attribute(node, "role")) {
+		return true
+	}
+	if node.DataAtom == atom.Header && !hasAncestor(node, atom.Article, atom.Main) {
+		return true
+	}
+	attributes := classAndId(node)
+	return negativeAttributes.MatchString(attributes) && !positiveAttributes.MatchString(attributes)
+}
+
+func attributeValue(node *html.Node, name string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+func hasAncestor(node *html.Node, elements ...atom.Atom) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		for _, element := range elements {
+			if parent.DataAtom == element {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Finds the element holding the main content of the page: the largest `article`
+// or the `main` element when they hold enough text, or else the element whose
+// paragraphs score best, readability-style
+func mainContent(body *html.Node) *html.Node {
+	const minimumText = 200
+	var best *html.Node
+	bestLength := 0
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && (node.DataAtom == atom.Article || node.DataAtom == atom.Main || attribute(node, "role") == "main") {
+			if length := len(nodeText(node)); length > bestLength {
+				best, bestLength = node, length
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(body)
+	if best != nil && bestLength >= minimumText {
+		return best
+	}
+
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			order = append(order, node)
+			scores[node] = classWeight(node)
+		}
+		scores[node] += score
+	}
+	var score func(node *html.Node)
+	score = func(node *html.Node) {
+		if node.Type == html.ElementNode && paragraphCandidates[node.DataAtom] {
+			text := nodeText(node)
+			if len(text) >= 25 {
+				points := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+				addScore(node.Parent, points)
+				if node.Parent != nil {
+					addScore(node.Parent.Parent, points/2)
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			score(child)
+		}
+	}
+	score(body)
+	var top *html.Node
+	topScore := 0.0
+	for _, candidate := range order {
+		adjusted := scores[candidate] * (1 - linkDensity(candidate))
+		if top == nil || adjusted > topScore {
+			top, topScore = candidate, adjusted
+		}
+	}
+	if top == nil {
+		return body
+	}
+	return top
+}
+
+func classWeight(node *html.Node) float64 {
+	attributes := classAndId(node)
+	weight := 0.0
+	if negativeAttributes.MatchString(attributes) {
+		weight -= 25
+	}
+	if positiveAttributes.MatchString(attributes) {
+		weight += 25
+	}
+	return weight
+}
+
+// Returns the fraction of the text of a node that is inside links
+func linkDensity(node *html.Node) float64 {
+	total := len(nodeText(node))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.A {
+			linked += len(nodeText(node))
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return float64(linked) / float64(total)
+}
+
+type htmlConverter struct {
+	options HtmlConversionOptions
+	base    *url.URL
+}
+
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Body: true,
+	atom.Dd: true, atom.Details: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Fieldset: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true, atom.Hr: true, atom.Li: true,
+	atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Summary: true, atom.Table: true, atom.Ul: true, atom.Html: true,
+}
+
+// Converts the children of a node into markdown blocks
+func (c *htmlConverter) blocks(node *html.Node) []string {
+	var blocks []string
+	var paragraph strings.Builder
+	flush := func() {
+		if text := cleanInline(paragraph.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+		paragraph.Reset()
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && blockElements[child.DataAtom] {
+			flush()
+			blocks = append(blocks, c.block(child)...)
+			continue
+		}
+		paragraph.WriteString(c.inline(child))
+	}
+	flush()
+	return blocks
+}
+
+func (c *htmlConverter) block(node *html.Node) []string {
+	switch node.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(node.Data[1] - '0')
+		if text := cleanInline(c.inlineChildren(node)); text != "" {
+			return []string{strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "\n", " ")}
+		}
+		return nil
+	case atom.Hr:
+		return []string{"---"}
+	case atom.Pre:
+		code := strings.Trim(textContent(node), "\n")
+		if code == "" {
+			return nil
+		}
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return []string{fence + codeLanguage(node) + "\n" + code + "\n" + fence}
+	case atom.Ul, atom.Ol:
+		if list := c.list(node); list != "" {
+			return []string{list}
+		}
+		return nil
+	case atom.Blockquote:
+		inner := strings.Join(c.blocks(node), "\n\n")
+		if inner == "" {
+			return nil
+		}
+		return []string{prefixLines(inner, "> ", ">")}
+	case atom.Table:
+		if table, ok := parseHtmlTable(node); ok {
+			if markdown := markdownTable(table); markdown != "" {
+				return []string{markdown}
+			}
+		}
+		return nil
+	case atom.Dt:
+		if text := cleanInline(c.inlineChildren(node)); text != "" {
+			return []string{"**" + text + "**"}
+		}
+		return nil
+	}
+	return c.blocks(node)
+}
+
+func codeLanguage(pre *html.Node) string {
+	for _, node := range []*html.Node{pre, pre.FirstChild} {
+		if node == nil || node.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attribute(node, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if language, ok := strings.CutPrefix(class, prefix); ok {
+					return language
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func (c *htmlConverter) list(node *html.Node) string {
+	var items []string
+	number := 1
+	if start, err := strconv.Atoi(attribute(node, "start")); err == nil {
+		number = start
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if node.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		blocks := c.blocks(child)
+		if len(blocks) == 0 {
+			continue
+		}
+		indent := strings.Repeat(" ", len(marker))
+		item := marker + prefixLines(blocks[0], indent, "")[len(indent):]
+		for _, block := range blocks[1:] {
+			item += "\n" + prefixLines(block, indent, "")
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, "\n")
+}
+
+func prefixLines(text string, prefix string, blankPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = blankPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func markdownTable(table PageTable) string {
+	headers := table.Headers
+	rows := table.Rows
+	if len(headers) == 0 {
+		if len(rows) == 0 {
+			return ""
+		}
+		headers, rows = rows[0], rows[1:]
+	}
+	escape := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for i, cell := range cells {
+			escaped[i] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+		return "| " + strings.Join(escaped, " | ") + " |"
+	}
+	lines := []string{escape(headers), "|" + strings.Repeat(" --- |", len(headers))}
+	for _, row := range rows {
+		lines = append(lines, escape(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *htmlConverter) inlineChildren(node *html.Node) string {
+	var builder strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(c.inline(child))
+	}
+	return builder.String()
+}
+
+var markdownSpecial = strings.NewReplacer("\\", "\\\\", "*", "\\*", "`", "\\`", "[", "\\[", "]", "\\]", "<", "\\<")
+
+func (c *htmlConverter) inline(node *html.Node) string {
+	switch node.Type {
+	case html.TextNode:
+		return markdownSpecial.Replace(collapseWhitespace(node.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	switch node.DataAtom {
+	case atom.Br:
+		return "\n"
+	case atom.Strong, atom.B:
+		return wrapInline(c.inlineChildren(node), "**")
+	case atom.Em, atom.I:
+		return wrapInline(c.inlineChildren(node), "_")
+	case atom.Del, atom.S:
+		return wrapInline(c.inlineChildren(node), "~~")
+	case atom.Code, atom.Kbd, atom.Samp:
+		code := collapseWhitespace(textContent(node))
+		if strings.TrimSpace(code) == "" {
+			return code
+		}
+		fence := "`"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return fence + code + fence
+	case atom.A:
+		text := c.inlineChildren(node)
+		href := strings.TrimSpace(attribute(node, "href"))
+		if !c.options.IncludeLinks || href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return text
+		}
+		if strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "[" + strings.TrimSpace(text) + "](" + c.resolve(href) + ")"
+	case atom.Img:
+		src := strings.TrimSpace(attribute(node, "src"))
+		if !c.options.IncludeImages || src == "" || strings.HasPrefix(src, "data:") {
+			return ""
+		}
+		return "![" + markdownSpecial.Replace(collapseWhitespace(attribute(node, "alt"))) + "](" + c.resolve(src) + ")"
+	}
+	if blockElements[node.DataAtom] {
+		// block elements nested in inline ones are flattened
+		return " " + c.inlineChildren(node) + " "
+	}
+	return c.inlineChildren(node)
+}
+
+func (c *htmlConverter) resolve(reference string) string {
+	resolved := reference
+	if c.base != nil {
+		if parsed, err := c.base.Parse(reference); err == nil {
+			resolved = parsed.String()
+		}
+	}
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(resolved)
+}
+
+// Wraps inline content with a delimiter, keeping the surrounding spaces outside of it
+func wrapInline(text string, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:len(text)-len(strings.TrimLeft(text, " \n"))]
+	trailing := text[len(strings.TrimRight(text, " \n")):]
+	return leading + delimiter + trimmed + delimiter + trailing
+}
+
+var spaces = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+func collapseWhitespace(text string) string {
+	return spaces.ReplaceAllString(strings.ReplaceAll(text, " ", " "), " ")
+}
+
+// Cleans the inline content of a paragraph: one space between words and no spaces around line breaks
+func cleanInline(text string) string {
+	lines := strings.Split(text, "\n")
+	var kept []string
+	for _, line := range lines {
+		line = strings.TrimSpace(strings.Join(strings.Fields(line), " "))
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// Returns the raw text of a node, whitespace included
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var builder strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Br {
+			builder.WriteByte('\n')
+			continue
+		}
+		builder.WriteString(textContent(child))
+	}
+	return builder.String()
+}
@@ -0,0 +1,133 @@
+package linkup
+
+import (
+	"strings"
+	"testing"
+)
+
+const readabilityTestPage = `<!DOCTYPE html>
+<html><head><title>Release notes</title><style>body { color: red }</style></head>
+<body>
+  <header><a href="/">Home</a> <a href="/docs">Docs</a></header>
+  <nav class="menu"><ul><li><a href="/a">A</a></li><li><a href="/b">B</a></li></ul></nav>
+  <div class="ad-banner">Buy now, limited offer, click here!</div>
+  <div id="content">
+    <h1>Version 2.0</h1>
+    <p>This release brings <strong>faster</strong> searches, a new <a href="/docs/fetch">fetch API</a>, and many fixes.</p>
+    <p>Upgrading is easy, and the old API keeps working for now, as described below.</p>
+    <ul>
+      <li>New <code>FetchMany</code> helper</li>
+      <li>Better retries
+        <ol start="3"><li>first</li><li>second</li></ol>
+      </li>
+    </ul>
+    <pre><code class="language-go">client.Fetch(url)</code></pre>
+    <blockquote><p>Quoted text</p></blockquote>
+    <table><tr><th>Plan</th><th>Price</th></tr><tr><td>Pro</td><td>$10</td></tr></table>
+    <img src="/img/chart.png" alt="Chart">
+    <p style="display:none">Hidden paragraph</p>
+    <script>track()</script>
+  </div>
+  <aside class="sidebar"><p>Related posts, and more related posts, and even more.</p></aside>
+  <footer>Copyright 2024, all rights reserved, and more legal text.</footer>
+</body></html>`
+
+func TestConvertRawHtmlMainContent(t *testing.T) {
+	page := readabilityTestPage
+	converted, err := ConvertRawHtml(&FetchOutput{RawHtml: &page}, HtmlConversionOptions{
+		BaseUrl:         "https://example.com/blog/post",
+		MainContentOnly: true,
+		IncludeLinks:    true,
+		IncludeImages:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted.Title != "Release notes" {
+		t.Fatalf("Unexpected title: %q", converted.Title)
+	}
+	for _, expected := range []string{
+		"# Version 2.0",
+		"This release brings **faster** searches, a new [fetch API](https://example.com/docs/fetch), and many fixes.",
+		"- New `FetchMany` helper\n- Better retries\n  3. first\n  4. second",
+		"```go\nclient.Fetch(url)\n```",
+		"> Quoted text",
+		"| Plan | Price |\n| --- | --- |\n| Pro | $10 |",
+		"![Chart](https://example.com/img/chart.png)",
+	} {
+		if !strings.Contains(converted.Markdown, expected) {
+			t.Fatalf("Expected markdown to contain %q, got:\n%s", expected, converted.Markdown)
+		}
+	}
+	for _, unexpected := range []string{"Home", "Buy now", "Related posts", "Copyright", "Hidden", "track()", "color: red"} {
+		if strings.Contains(converted.Markdown, unexpected) {
+			t.Fatalf("Expected markdown not to contain %q, got:\n%s", unexpected, converted.Markdown)
+		}
+	}
+	if !strings.Contains(converted.Text, "This release brings faster searches, a new fetch API, and many fixes.") || strings.Contains(converted.Text, "**") {
+		t.Fatalf("Unexpected text:\n%s", converted.Text)
+	}
+}
+
+func TestConvertHtmlWholePage(t *testing.T) {
+	converted, err := ConvertHtml(strings.NewReader(readabilityTestPage), HtmlConversionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(converted.Markdown, "Related posts") || !strings.Contains(converted.Markdown, "Home Docs") {
+		t.Fatalf("Expected the whole page, got:\n%s", converted.Markdown)
+	}
+	if strings.Contains(converted.Markdown, "](") || strings.Contains(converted.Markdown, "Hidden") {
+		t.Fatalf("Expected no links, images nor hidden content, got:\n%s", converted.Markdown)
+	}
+	if _, err := ConvertRawHtml(&FetchOutput{}); err == nil {
+		t.Fatal("Expected an error when the raw HTML is missing")
+	}
+}
+
+func TestConvertHtmlScoresParagraphs(t *testing.T) {
+	page := `<body><div class="links"><a href="/1">One</a> <a href="/2">Two</a></div>
+<div class="story-body"><p>A long paragraph about the topic, with commas, details, and more.</p><p>Another paragraph, also long enough to be counted as content.</p></div>
+<div><p>Short.</p></div></body>`
+	converted, err := ConvertHtml(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(converted.Markdown, "A long paragraph") || strings.Contains(converted.Markdown, "Short.") || strings.Contains(converted.Markdown, "One") {
+		t.Fatalf("Unexpected main content:\n%s", converted.Markdown)
+	}
+}
+
+func TestConvertHtmlTables(t *testing.T) {
+	page := `<body><table><tr><th>A</th></tr><tr><th>B</th><th>C</th></tr><tr><td>1</td><td>2</td></tr></table></body>`
+	converted, err := ConvertHtml(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "| A B | C |\n| --- | --- |\n| 1 | 2 |"; converted.Markdown != expected {
+		t.Fatalf("Expected %q, got %q", expected, converted.Markdown)
+	}
+	if markdownTable(PageTable{}) != "" {
+		t.Fatal("Expected no markdown for an empty table")
+	}
+}
+
+func TestCompareRepresentations(t *testing.T) {
+	page := readabilityTestPage
+	output := &FetchOutput{Markdown: "# Version 2.0\n\nThis release brings faster searches.", RawHtml: &page}
+	comparison, err := CompareRepresentations(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !comparison.PreferConverted || comparison.Coverage >= 0.8 || comparison.ConvertedWords <= comparison.ApiWords {
+		t.Fatalf("Expected the converted page to be preferred, got %+v", comparison)
+	}
+	output.Markdown = comparison.Converted.Markdown
+	comparison, err = CompareRepresentations(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comparison.PreferConverted || comparison.Coverage != 1 {
+		t.Fatalf("Expected the API markdown to be preferred, got %+v", comparison)
+	}
+}
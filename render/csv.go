@@ -0,0 +1,160 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+)
+
+// Renderer writing CSV with a header row:
+//   - search results: one row per result, with the type, name, URL and content
+//   - sourced answers: one row per source, numbered as in the other formats
+//   - structured outputs: one row per object of the output when it is an array (or an object
+//     holding a single array of objects), a single row otherwise, with nested values as JSON
+//   - fetched pages: the tables of the page, separated by empty lines
+type CSVRenderer struct{}
+
+func (r *CSVRenderer) SearchResults(w io.Writer, output *linkup.SearchResultsOutput) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"type", "name", "url", "content"})
+	for _, result := range output.TextResults {
+		writer.Write([]string{string(result.Type), result.Name, result.Url, result.Content})
+	}
+	for _, result := range output.ImageResults {
+		writer.Write([]string{string(result.Type), result.Name, result.Url, ""})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *CSVRenderer) SourcedAnswer(w io.Writer, output *linkup.SourcedAnswerOutput) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"number", "name", "url", "snippet"})
+	for _, reference := range NewAnswerData(output).References {
+		writer.Write([]string{strconv.Itoa(reference.Number), reference.Name, reference.Url, reference.Snippet})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *CSVRenderer) Structured(w io.Writer, output *linkup.StructuredOutput) error {
+	data, err := NewStructuredData(output)
+	if err != nil {
+		return err
+	}
+	rows := structuredRows(data.ordered)
+	var columns []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for _, field := range row {
+			if !seen[field.Key] {
+				seen[field.Key] = true
+				columns = append(columns, field.Key)
+			}
+		}
+	}
+	writer := csv.NewWriter(w)
+	writer.Write(columns)
+	for _, row := range rows {
+		cells := make(map[string]string, len(row))
+		for _, field := range row {
+			if isScalar(field.Value) {
+				cells[field.Key] = scalarText(field.Value)
+			} else {
+				cells[field.Key] = orderedJson(field.Value)
+			}
+		}
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = cells[column]
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Returns the rows of a structured output, as objects. Scalars are put in a `value` column.
+func structuredRows(value any) [][]field {
+	items, isArray := value.([]any)
+	if fields, ok := value.([]field); ok {
+		var arrays []any
+		for _, field := range fields {
+			if nested, ok := field.Value.([]any); ok && len(nested) > 0 && isObjectArray(nested) {
+				arrays = append(arrays, nested)
+			}
+		}
+		if len(arrays) != 1 {
+			return [][]field{fields}
+		}
+		items, isArray = arrays[0].([]any), true
+	}
+	if !isArray {
+		return [][]field{{{Key: "value", Value: value}}}
+	}
+	rows := make([][]field, len(items))
+	for i, item := range items {
+		if fields, ok := item.([]field); ok {
+			rows[i] = fields
+		} else {
+			rows[i] = []field{{Key: "value", Value: item}}
+		}
+	}
+	return rows
+}
+
+func isObjectArray(items []any) bool {
+	for _, item := range items {
+		if _, ok := item.([]field); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Encodes a value decoded with `decodeOrdered` back to JSON, keeping the order of the fields
+func orderedJson(value any) string {
+	switch typed := value.(type) {
+	case []field:
+		parts := make([]string, len(typed))
+		for i, field := range typed {
+			key, _ := json.Marshal(field.Key)
+			parts[i] = string(key) + ":" + orderedJson(field.Value)
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	case []any:
+		parts := make([]string, len(typed))
+		for i, item := range typed {
+			parts[i] = orderedJson(item)
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+func (r *CSVRenderer) Fetch(w io.Writer, output *linkup.FetchOutput) error {
+	tables, err := linkup.ExtractTables(output)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return errors.New("the page has no table")
+	}
+	for i := range tables {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := tables[i].WriteCSV(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
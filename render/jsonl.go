@@ -0,0 +1,53 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+)
+
+// Renderer writing JSON Lines: one line per result for search results (text results first,
+// then image results), and one line for the whole output otherwise, so that the renderings
+// of several outputs can be appended to the same file
+type JSONLinesRenderer struct{}
+
+func (r *JSONLinesRenderer) SearchResults(w io.Writer, output *linkup.SearchResultsOutput) error {
+	encoder := json.NewEncoder(w)
+	for _, result := range output.TextResults {
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	}
+	for _, result := range output.ImageResults {
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *JSONLinesRenderer) SourcedAnswer(w io.Writer, output *linkup.SourcedAnswerOutput) error {
+	return json.NewEncoder(w).Encode(output)
+}
+
+func (r *JSONLinesRenderer) Structured(w io.Writer, output *linkup.StructuredOutput) error {
+	switch {
+	case output.SourcedOutput != nil:
+		return json.NewEncoder(w).Encode(output.SourcedOutput)
+	case output.RawJson != nil:
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, []byte(*output.RawJson)); err != nil {
+			return err
+		}
+		compacted.WriteByte('\n')
+		_, err := compacted.WriteTo(w)
+		return err
+	}
+	return errEmptyStructured
+}
+
+func (r *JSONLinesRenderer) Fetch(w io.Writer, output *linkup.FetchOutput) error {
+	return json.NewEncoder(w).Encode(output)
+}
@@ -0,0 +1,347 @@
+// Renderers for the outputs of the Linkup API. Every output type (search results, sourced
+// answers, structured outputs and fetched pages) can be rendered as Markdown (with citation
+// footnotes), sanitized HTML, plain text, JSON Lines or CSV. The Markdown, HTML and plain
+// text renderers are based on templates, which can be overridden.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+)
+
+// Interface for the renderers of the outputs of the Linkup API
+type Renderer interface {
+	SearchResults(w io.Writer, output *linkup.SearchResultsOutput) error
+	SourcedAnswer(w io.Writer, output *linkup.SourcedAnswerOutput) error
+	Structured(w io.Writer, output *linkup.StructuredOutput) error
+	Fetch(w io.Writer, output *linkup.FetchOutput) error
+}
+
+// Enum representing the output format of a renderer
+type Format int
+
+const (
+	Markdown Format = iota
+	HTML
+	Text
+	JSONLines
+	CSV
+)
+
+func (f Format) String() string {
+	switch f {
+	case Markdown:
+		return "markdown"
+	case HTML:
+		return "html"
+	case Text:
+		return "text"
+	case JSONLines:
+		return "jsonl"
+	case CSV:
+		return "csv"
+	}
+	return "unknown"
+}
+
+// Get the format with the given name (as returned by `Format.String`), for example from a command line flag
+func ParseFormat(name string) (Format, error) {
+	for _, format := range []Format{Markdown, HTML, Text, JSONLines, CSV} {
+		if strings.EqualFold(name, format.String()) {
+			return format, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown format %q", name)
+}
+
+// Create a renderer for the given format, with the default templates
+func New(format Format) (Renderer, error) {
+	switch format {
+	case Markdown:
+		return NewMarkdownRenderer()
+	case HTML:
+		return NewHtmlRenderer()
+	case Text:
+		return NewTextRenderer()
+	case JSONLines:
+		return &JSONLinesRenderer{}, nil
+	case CSV:
+		return &CSVRenderer{}, nil
+	}
+	return nil, fmt.Errorf("unknown format %d", format)
+}
+
+// Struct type holding the templates of a renderer, written with the `text/template` syntax.
+// Empty templates keep their default. HTML templates are parsed with `html/template`,
+// so that the values they output are escaped according to their context.
+type Templates struct {
+	// SearchResults The template for search results, executed with a `*linkup.SearchResultsOutput`.
+	SearchResults string
+
+	// SourcedAnswer The template for sourced answers, executed with an `AnswerData`.
+	SourcedAnswer string
+
+	// Structured The template for structured outputs, executed with a `StructuredData`.
+	Structured string
+
+	// Fetch The template for fetched pages, executed with a `*linkup.FetchOutput`.
+	Fetch string
+}
+
+// Struct type representing a source numbered for citation
+type Reference struct {
+	// Number The number of the source, starting from 1.
+	Number int
+
+	Name    string
+	Url     string
+	Snippet string
+}
+
+// Struct type representing the data passed to the templates of sourced answers
+type AnswerData struct {
+	*linkup.CitedAnswer
+
+	// Cited Whether the answer has inline citations.
+	Cited bool
+
+	// References The sources in the order of their first citation, or all the sources when the answer has no inline citations.
+	References []Reference
+}
+
+// Struct type representing the data passed to the templates of structured outputs
+type StructuredData struct {
+	// Data The structured output, decoded from JSON.
+	Data any
+
+	// References The sources of the output, when it was requested with `IncludeSources`.
+	References []Reference
+
+	// the decoded output, with the order of the fields of the objects preserved
+	ordered any
+}
+
+// Renderer executing templates: used for Markdown, HTML and plain text.
+//
+// Besides the builtin functions, templates can use:
+//   - `answer`: the answer of an `AnswerData`, with citations in the format of the renderer
+//   - `value`: a value of a `StructuredData` (or the whole data) in the format of the renderer
+//   - `page`: the content of a `*linkup.FetchOutput` in the format of the renderer
+//   - `escape`: a string escaped for the format of the renderer (a no-op for HTML, which is escaped anyway)
+//   - `oneline`: a string with its whitespace collapsed into single spaces
+//   - `inc`: an integer plus one, to number items from 1
+type TemplateRenderer struct {
+	searchResults executor
+	sourcedAnswer executor
+	structured    executor
+	fetch         executor
+}
+
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// Create a Markdown renderer. Citations are rendered as footnotes.
+func NewMarkdownRenderer(templates ...Templates) (*TemplateRenderer, error) {
+	return newTemplateRenderer(mergeTemplates(markdownTemplates, templates), func(name string, source string) (executor, error) {
+		return texttemplate.New(name).Funcs(markdownFuncs).Parse(source)
+	})
+}
+
+// Create a plain text renderer. Citations are rendered as bracketed numbers.
+func NewTextRenderer(templates ...Templates) (*TemplateRenderer, error) {
+	return newTemplateRenderer(mergeTemplates(textTemplates, templates), func(name string, source string) (executor, error) {
+		return texttemplate.New(name).Funcs(textFuncs).Parse(source)
+	})
+}
+
+// Create a sanitized HTML renderer. Citations are rendered as superscript links to the list of sources.
+func NewHtmlRenderer(templates ...Templates) (*TemplateRenderer, error) {
+	return newTemplateRenderer(mergeTemplates(htmlTemplates, templates), func(name string, source string) (executor, error) {
+		return htmltemplate.New(name).Funcs(htmlFuncs).Parse(source)
+	})
+}
+
+func newTemplateRenderer(sources Templates, parse func(name string, source string) (executor, error)) (*TemplateRenderer, error) {
+	renderer := &TemplateRenderer{}
+	var err error
+	if renderer.searchResults, err = parse("search results", sources.SearchResults); err != nil {
+		return nil, err
+	}
+	if renderer.sourcedAnswer, err = parse("sourced answer", sources.SourcedAnswer); err != nil {
+		return nil, err
+	}
+	if renderer.structured, err = parse("structured", sources.Structured); err != nil {
+		return nil, err
+	}
+	if renderer.fetch, err = parse("fetch", sources.Fetch); err != nil {
+		return nil, err
+	}
+	return renderer, nil
+}
+
+func mergeTemplates(defaults Templates, templates []Templates) Templates {
+	if len(templates) == 0 {
+		return defaults
+	}
+	merged := templates[0]
+	if merged.SearchResults == "" {
+		merged.SearchResults = defaults.SearchResults
+	}
+	if merged.SourcedAnswer == "" {
+		merged.SourcedAnswer = defaults.SourcedAnswer
+	}
+	if merged.Structured == "" {
+		merged.Structured = defaults.Structured
+	}
+	if merged.Fetch == "" {
+		merged.Fetch = defaults.Fetch
+	}
+	return merged
+}
+
+func (r *TemplateRenderer) SearchResults(w io.Writer, output *linkup.SearchResultsOutput) error {
+	return r.searchResults.Execute(w, output)
+}
+
+func (r *TemplateRenderer) SourcedAnswer(w io.Writer, output *linkup.SourcedAnswerOutput) error {
+	return r.sourcedAnswer.Execute(w, NewAnswerData(output))
+}
+
+func (r *TemplateRenderer) Structured(w io.Writer, output *linkup.StructuredOutput) error {
+	data, err := NewStructuredData(output)
+	if err != nil {
+		return err
+	}
+	return r.structured.Execute(w, data)
+}
+
+func (r *TemplateRenderer) Fetch(w io.Writer, output *linkup.FetchOutput) error {
+	return r.fetch.Execute(w, output)
+}
+
+// Get the data passed to the templates of sourced answers
+func NewAnswerData(output *linkup.SourcedAnswerOutput) AnswerData {
+	cited := linkup.ParseCitations(output)
+	data := AnswerData{CitedAnswer: cited}
+	sources := cited.CitedSources()
+	if len(sources) > 0 {
+		data.Cited = true
+	} else {
+		sources = output.Sources
+	}
+	for i, source := range sources {
+		data.References = append(data.References, newReference(i+1, source.Name, source.Url, source.Snippet))
+	}
+	return data
+}
+
+// Sources without a name are named after their URL
+func newReference(number int, name string, url string, snippet string) Reference {
+	if strings.TrimSpace(name) == "" {
+		name = url
+	}
+	return Reference{Number: number, Name: name, Url: url, Snippet: snippet}
+}
+
+// Get the data passed to the templates of structured outputs
+func NewStructuredData(output *linkup.StructuredOutput) (StructuredData, error) {
+	var data StructuredData
+	var raw []byte
+	switch {
+	case output.SourcedOutput != nil:
+		encoded, err := json.Marshal(output.SourcedOutput.Data)
+		if err != nil {
+			return data, err
+		}
+		raw = encoded
+		for i, source := range output.SourcedOutput.Sources {
+			var name, url, content string
+			if source.Name != nil {
+				name = *source.Name
+			}
+			if source.Url != nil {
+				url = *source.Url
+			}
+			if source.Content != nil {
+				content = *source.Content
+			}
+			data.References = append(data.References, newReference(i+1, name, url, content))
+		}
+	case output.RawJson != nil:
+		raw = []byte(*output.RawJson)
+	default:
+		return data, errEmptyStructured
+	}
+	if err := json.Unmarshal(raw, &data.Data); err != nil {
+		return data, err
+	}
+	ordered, err := decodeOrdered(raw)
+	if err != nil {
+		return data, err
+	}
+	data.ordered = ordered
+	return data, nil
+}
+
+var errEmptyStructured = errors.New("the structured output has neither raw JSON nor sourced output")
+
+// Struct type representing a field of a JSON object, decoded in order
+type field struct {
+	Key   string
+	Value any
+}
+
+// Decodes JSON keeping the order of the fields of the objects, which are decoded as `[]field`
+func decodeOrdered(raw []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decodeOrderedValue(decoder)
+}
+
+func decodeOrderedValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delimiter, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delimiter {
+	case '{':
+		fields := []field{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field{Key: key.(string), Value: value})
+		}
+		_, err = decoder.Token()
+		return fields, err
+	default:
+		values := []any{}
+		for decoder.More() {
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		_, err = decoder.Token()
+		return values, err
+	}
+}
@@ -0,0 +1,248 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+)
+
+var testResults = &linkup.SearchResultsOutput{
+	TextResults: []linkup.TextSearchResultDto{
+		{Name: "Go [docs]", Url: "https://go.dev/doc", Content: "The Go\nprogramming language.", Type: "text"},
+		{Name: "Effective Go", Url: "https://go.dev/doc/effective_go", Content: "Tips for writing <clear> Go.", Type: "text"},
+	},
+	ImageResults: []linkup.ImageSearchResultDto{
+		{Name: "Gopher", Url: "https://go.dev/gopher.png", Type: "image"},
+	},
+}
+
+var testAnswer = &linkup.SourcedAnswerOutput{
+	Answer: "Go is **compiled** [2]. It was designed at Google [1][2].",
+	Sources: []linkup.SourceDto{
+		{Name: "About Go", Url: "https://go.dev/about", Snippet: "Designed at Google"},
+		{Name: "", Url: "https://en.wikipedia.org/wiki/Go", Snippet: "A compiled language"},
+	},
+}
+
+func rawJson(raw string) *linkup.StructuredOutput {
+	return &linkup.StructuredOutput{RawJson: &raw}
+}
+
+func render(t *testing.T, renderer Renderer, call func(Renderer, *bytes.Buffer) error) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := call(renderer, &buffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return buffer.String()
+}
+
+func TestMarkdownRenderer(t *testing.T) {
+	renderer, err := New(Markdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.SearchResults(b, testResults) })
+	expected := "## 1. [Go \\[docs\\]](https://go.dev/doc)\n\nThe Go programming language.\n\n" +
+		"## 2. [Effective Go](https://go.dev/doc/effective_go)\n\nTips for writing <clear> Go.\n\n" +
+		"## Images\n\n- [Gopher](https://go.dev/gopher.png)\n"
+	if results != expected {
+		t.Fatalf("Unexpected search results:\n%s", results)
+	}
+
+	answer := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.SourcedAnswer(b, testAnswer) })
+	expected = "Go is **compiled**[^1]. It was designed at Google[^2][^1].\n\n" +
+		"[^1]: [https://en.wikipedia.org/wiki/Go](https://en.wikipedia.org/wiki/Go)\n" +
+		"[^2]: [About Go](https://go.dev/about)\n"
+	if answer != expected {
+		t.Fatalf("Unexpected answer:\n%s", answer)
+	}
+	uncited := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.SourcedAnswer(b, &linkup.SourcedAnswerOutput{Answer: "Go is compiled.", Sources: testAnswer.Sources[:1]})
+	})
+	if uncited != "Go is compiled.\n\n## Sources\n\n1. [About Go](https://go.dev/about)\n" {
+		t.Fatalf("Unexpected answer without citations:\n%s", uncited)
+	}
+
+	structured := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.Structured(b, rawJson(`{"title": "Go", "tags": ["fast", "simple"], "releases": [{"version": "1.22", "year": 2024}], "stable": true}`))
+	})
+	expected = "- **title**: Go\n- **tags**:\n  1. fast\n  2. simple\n- **releases**:\n  1. - **version**: 1.22\n     - **year**: 2024\n- **stable**: true\n"
+	if structured != expected {
+		t.Fatalf("Unexpected structured output:\n%s", structured)
+	}
+
+	page := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.Fetch(b, &linkup.FetchOutput{Markdown: "# Title\n\nBody.\n\n"})
+	})
+	if page != "# Title\n\nBody.\n" {
+		t.Fatalf("Unexpected page:\n%s", page)
+	}
+}
+
+func TestTextRenderer(t *testing.T) {
+	renderer, err := New(Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.SourcedAnswer(b, testAnswer) })
+	expected := "Go is compiled[1]. It was designed at Google[2][1].\n\nSources:\n" +
+		"[1] https://en.wikipedia.org/wiki/Go: https://en.wikipedia.org/wiki/Go\n[2] About Go: https://go.dev/about\n"
+	if answer != expected {
+		t.Fatalf("Unexpected answer:\n%s", answer)
+	}
+
+	sourced := &linkup.StructuredOutput{SourcedOutput: &linkup.StructuredWithSourcesDto{
+		Data: map[string]any{"name": "Go", "authors": []any{map[string]any{"name": "Rob", "role": "designer"}}},
+	}}
+	name, url := "About Go", "https://go.dev/about"
+	sourced.SourcedOutput.Sources = append(sourced.SourcedOutput.Sources, struct {
+		Content *string `json:"content,omitempty"`
+		Name    *string `json:"name,omitempty"`
+		Type    *string `json:"type,omitempty"`
+		Url     *string `json:"url,omitempty"`
+	}{Name: &name, Url: &url})
+	structured := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.Structured(b, sourced) })
+	expected = "authors:\n  - name: Rob\n    role: designer\nname: Go\n\nSources:\n[1] About Go: https://go.dev/about\n"
+	if structured != expected {
+		t.Fatalf("Unexpected structured output:\n%s", structured)
+	}
+
+	page := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.Fetch(b, &linkup.FetchOutput{Markdown: "# Title\n\nSome [link](https://example.com)."})
+	})
+	if page != "Title\n\nSome link.\n" {
+		t.Fatalf("Unexpected page:\n%s", page)
+	}
+}
+
+func TestHtmlRenderer(t *testing.T) {
+	renderer, err := New(HTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.SearchResults(b, &linkup.SearchResultsOutput{TextResults: []linkup.TextSearchResultDto{
+			{Name: "<script>alert(1)</script>", Url: "javascript:alert(1)", Content: "a & b"},
+		}})
+	})
+	expected := "<ol class=\"linkup-results\">\n<li><a href=\"#ZgotmplZ\">&lt;script&gt;alert(1)&lt;/script&gt;</a>\n<p>a &amp; b</p></li>\n</ol>\n"
+	if results != expected {
+		t.Fatalf("Unexpected search results:\n%s", results)
+	}
+
+	answer := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.SourcedAnswer(b, testAnswer) })
+	for _, fragment := range []string{
+		`<p>Go is compiled<sup><a href="#source-1">1</a></sup>. It was designed at Google<sup><a href="#source-2">2</a></sup><sup><a href="#source-1">1</a></sup>.</p>`,
+		`<li id="source-2"><a href="https://go.dev/about">About Go</a></li>`,
+	} {
+		if !strings.Contains(answer, fragment) {
+			t.Fatalf("Expected the answer to contain %q, got:\n%s", fragment, answer)
+		}
+	}
+
+	structured := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.Structured(b, rawJson(`{"title": "<b>Go</b>", "tags": ["fast"]}`))
+	})
+	expected = "<div class=\"linkup-structured\">\n<dl>\n<dt>title</dt>\n<dd>&lt;b&gt;Go&lt;/b&gt;</dd>\n<dt>tags</dt>\n<dd><ol>\n<li>fast</li>\n</ol>\n</dd>\n</dl>\n</div>\n"
+	if structured != expected {
+		t.Fatalf("Unexpected structured output:\n%s", structured)
+	}
+
+	page := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.Fetch(b, &linkup.FetchOutput{Markdown: "# Title\n\n<img src=x onerror=alert(1)> text"})
+	})
+	if page != "<article class=\"linkup-page\">\n<h1>Title</h1>\n<p>text</p>\n</article>\n" {
+		t.Fatalf("Unexpected page:\n%s", page)
+	}
+}
+
+func TestTemplateOverrides(t *testing.T) {
+	renderer, err := NewMarkdownRenderer(Templates{
+		SearchResults: "{{range .TextResults}}* {{.Url}}\n{{end}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.SearchResults(b, testResults) })
+	if results != "* https://go.dev/doc\n* https://go.dev/doc/effective_go\n" {
+		t.Fatalf("Unexpected search results:\n%s", results)
+	}
+	// the other templates keep their default
+	page := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.Fetch(b, &linkup.FetchOutput{Markdown: "Body."}) })
+	if page != "Body.\n" {
+		t.Fatalf("Unexpected page:\n%s", page)
+	}
+	if _, err := NewHtmlRenderer(Templates{Fetch: "{{page"}); err == nil {
+		t.Fatal("Expected an error for an invalid template")
+	}
+}
+
+func TestJSONLinesRenderer(t *testing.T) {
+	renderer := &JSONLinesRenderer{}
+	results := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.SearchResults(b, testResults) })
+	lines := strings.Split(strings.TrimSuffix(results, "\n"), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"url":"https://go.dev/doc"`) || !strings.Contains(lines[2], `"type":"image"`) {
+		t.Fatalf("Unexpected search results:\n%s", results)
+	}
+	structured := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.Structured(b, rawJson("{\n  \"b\": 1,\n  \"a\": [1, 2]\n}"))
+	})
+	if structured != "{\"b\":1,\"a\":[1,2]}\n" {
+		t.Fatalf("Unexpected structured output: %q", structured)
+	}
+	if err := renderer.Structured(&bytes.Buffer{}, &linkup.StructuredOutput{}); err == nil {
+		t.Fatal("Expected an error for an empty structured output")
+	}
+}
+
+func TestCSVRenderer(t *testing.T) {
+	renderer := &CSVRenderer{}
+	results := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.SearchResults(b, testResults) })
+	expected := "type,name,url,content\ntext,Go [docs],https://go.dev/doc,\"The Go\nprogramming language.\"\n" +
+		"text,Effective Go,https://go.dev/doc/effective_go,Tips for writing <clear> Go.\nimage,Gopher,https://go.dev/gopher.png,\n"
+	if results != expected {
+		t.Fatalf("Unexpected search results:\n%s", results)
+	}
+
+	answer := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.SourcedAnswer(b, testAnswer) })
+	expected = "number,name,url,snippet\n1,https://en.wikipedia.org/wiki/Go,https://en.wikipedia.org/wiki/Go,A compiled language\n2,About Go,https://go.dev/about,Designed at Google\n"
+	if answer != expected {
+		t.Fatalf("Unexpected answer:\n%s", answer)
+	}
+
+	structured := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.Structured(b, rawJson(`{"query": "go", "items": [{"name": "a", "price": 1}, {"name": "b", "tags": ["x"]}]}`))
+	})
+	if structured != "name,price,tags\na,1,\nb,,\"[\"\"x\"\"]\"\n" {
+		t.Fatalf("Unexpected structured output:\n%s", structured)
+	}
+	single := render(t, renderer, func(r Renderer, b *bytes.Buffer) error { return r.Structured(b, rawJson(`{"z": 1, "a": "b"}`)) })
+	if single != "z,a\n1,b\n" {
+		t.Fatalf("Unexpected structured output:\n%s", single)
+	}
+
+	page := render(t, renderer, func(r Renderer, b *bytes.Buffer) error {
+		return r.Fetch(b, &linkup.FetchOutput{Markdown: "| a | b |\n|---|---|\n| 1 | 2 |\n\ntext\n\n| c |\n|---|\n| 3 |\n"})
+	})
+	if page != "a,b\n1,2\n\nc\n3\n" {
+		t.Fatalf("Unexpected page:\n%s", page)
+	}
+	if err := renderer.Fetch(&bytes.Buffer{}, &linkup.FetchOutput{Markdown: "no tables"}); err == nil {
+		t.Fatal("Expected an error for a page without tables")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range []Format{Markdown, HTML, Text, JSONLines, CSV} {
+		parsed, err := ParseFormat(strings.ToUpper(format.String()))
+		if err != nil || parsed != format {
+			t.Fatalf("Unexpected format for %q: %v, %v", format, parsed, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Fatal("Expected an error for an unknown format")
+	}
+}
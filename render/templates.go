@@ -0,0 +1,304 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+	"github.com/AstraBert/linkup-go-sdk/fetch/markdown"
+)
+
+var markdownTemplates = Templates{
+	SearchResults: `{{range $i, $result := .TextResults}}{{if $i}}
+{{end}}## {{inc $i}}. [{{escape (oneline $result.Name)}}]({{$result.Url}})
+
+{{oneline $result.Content}}
+{{end}}{{if .ImageResults}}{{if .TextResults}}
+{{end}}## Images
+
+{{range .ImageResults}}- [{{escape (oneline .Name)}}]({{.Url}})
+{{end}}{{end}}`,
+	SourcedAnswer: `{{answer .}}
+{{if .References}}
+{{if .Cited}}{{range .References}}[^{{.Number}}]: [{{escape (oneline .Name)}}]({{.Url}})
+{{end}}{{else}}## Sources
+
+{{range .References}}{{.Number}}. [{{escape (oneline .Name)}}]({{.Url}})
+{{end}}{{end}}{{end}}`,
+	Structured: `{{value .}}
+{{if .References}}
+## Sources
+
+{{range .References}}{{.Number}}. [{{escape (oneline .Name)}}]({{.Url}})
+{{end}}{{end}}`,
+	Fetch: `{{page .}}
+`,
+}
+
+var textTemplates = Templates{
+	SearchResults: `{{range $i, $result := .TextResults}}{{if $i}}
+{{end}}{{inc $i}}. {{oneline $result.Name}}
+{{$result.Url}}
+{{oneline $result.Content}}
+{{end}}{{if .ImageResults}}{{if .TextResults}}
+{{end}}Images:
+{{range .ImageResults}}- {{oneline .Name}}: {{.Url}}
+{{end}}{{end}}`,
+	SourcedAnswer: `{{answer .}}
+{{if .References}}
+Sources:
+{{range .References}}[{{.Number}}] {{oneline .Name}}: {{.Url}}
+{{end}}{{end}}`,
+	Structured: `{{value .}}
+{{if .References}}
+Sources:
+{{range .References}}[{{.Number}}] {{oneline .Name}}: {{.Url}}
+{{end}}{{end}}`,
+	Fetch: `{{page .}}
+`,
+}
+
+var htmlTemplates = Templates{
+	SearchResults: `{{if .TextResults}}<ol class="linkup-results">
+{{range .TextResults}}<li><a href="{{.Url}}">{{.Name}}</a>
+<p>{{.Content}}</p></li>
+{{end}}</ol>
+{{end}}{{if .ImageResults}}<ul class="linkup-images">
+{{range .ImageResults}}<li><a href="{{.Url}}"><img src="{{.Url}}" alt="{{.Name}}"></a></li>
+{{end}}</ul>
+{{end}}`,
+	SourcedAnswer: `<div class="linkup-answer">
+{{answer .}}</div>
+{{if .References}}<ol class="linkup-sources">
+{{range .References}}<li id="source-{{.Number}}"><a href="{{.Url}}">{{.Name}}</a></li>
+{{end}}</ol>
+{{end}}`,
+	Structured: `<div class="linkup-structured">
+{{value .}}</div>
+{{if .References}}<ol class="linkup-sources">
+{{range .References}}<li id="source-{{.Number}}"><a href="{{.Url}}">{{.Name}}</a></li>
+{{end}}</ol>
+{{end}}`,
+	Fetch: `<article class="linkup-page">
+{{page .}}</article>
+`,
+}
+
+var markdownFuncs = texttemplate.FuncMap{
+	"answer": func(data AnswerData) string {
+		return data.RenderMarkers(func(number int, _ *linkup.SourceDto) string {
+			return fmt.Sprintf("[^%d]", number)
+		})
+	},
+	"value": func(value any) string {
+		return strings.TrimRight(markdownValue(orderedValue(value), ""), "\n")
+	},
+	"page": func(output *linkup.FetchOutput) string {
+		return strings.TrimSpace(output.Markdown)
+	},
+	"escape":  escapeMarkdown,
+	"oneline": oneline,
+	"inc":     inc,
+}
+
+var textFuncs = texttemplate.FuncMap{
+	"answer": func(data AnswerData) string {
+		answer := data.RenderMarkers(func(number int, _ *linkup.SourceDto) string {
+			return fmt.Sprintf("[%d]", number)
+		})
+		return markdown.Parse(answer).PlainText()
+	},
+	"value": func(value any) string {
+		return strings.TrimRight(textValue(orderedValue(value), ""), "\n")
+	},
+	"page": func(output *linkup.FetchOutput) string {
+		return markdown.Parse(output.Markdown).PlainText()
+	},
+	"escape":  func(text string) string { return text },
+	"oneline": oneline,
+	"inc":     inc,
+}
+
+// Private use characters delimiting the citation markers of an answer while it is converted to HTML
+var htmlCitation = regexp.MustCompile("\uE000(\\d+)\uE001")
+
+var htmlFuncs = htmltemplate.FuncMap{
+	"answer": func(data AnswerData) htmltemplate.HTML {
+		answer := data.RenderMarkers(func(number int, _ *linkup.SourceDto) string {
+			return fmt.Sprintf("\uE000%d\uE001", number)
+		})
+		converted := markdown.Parse(answer).HTML()
+		return htmltemplate.HTML(htmlCitation.ReplaceAllString(converted, `<sup><a href="#source-$1">$1</a></sup>`))
+	},
+	"value": func(value any) htmltemplate.HTML {
+		var builder strings.Builder
+		writeHtmlValue(&builder, orderedValue(value))
+		return htmltemplate.HTML(builder.String())
+	},
+	"page": func(output *linkup.FetchOutput) htmltemplate.HTML {
+		return htmltemplate.HTML(markdown.Parse(output.Markdown).HTML())
+	},
+	"escape":  func(text string) string { return text },
+	"oneline": oneline,
+	"inc":     inc,
+}
+
+func oneline(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func inc(i int) int {
+	return i + 1
+}
+
+var markdownSpecial = regexp.MustCompile("[\\\\`*_\\[\\]<>#|]")
+
+func escapeMarkdown(text string) string {
+	return markdownSpecial.ReplaceAllString(text, `\$0`)
+}
+
+// Returns the value with the objects as `[]field`: the whole data of a `StructuredData`
+// keeps the order of its fields, while the fields of other maps are sorted by key
+func orderedValue(value any) any {
+	switch typed := value.(type) {
+	case StructuredData:
+		return typed.ordered
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		fields := make([]field, len(keys))
+		for i, key := range keys {
+			fields[i] = field{Key: key, Value: orderedValue(typed[key])}
+		}
+		return fields
+	case []any:
+		values := make([]any, len(typed))
+		for i, item := range typed {
+			values[i] = orderedValue(item)
+		}
+		return values
+	}
+	return value
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case []field, []any:
+		return false
+	}
+	return true
+}
+
+func scalarText(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case json.Number:
+		return typed.String()
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(typed)
+	}
+	return fmt.Sprint(value)
+}
+
+// Renders objects as nested lists of bold keys, and arrays as numbered lists
+func markdownValue(value any, indent string) string {
+	var builder strings.Builder
+	switch typed := value.(type) {
+	case []field:
+		for _, field := range typed {
+			fmt.Fprintf(&builder, "%s- **%s**:", indent, escapeMarkdown(field.Key))
+			if isScalar(field.Value) {
+				if text := oneline(scalarText(field.Value)); text != "" {
+					builder.WriteString(" " + escapeMarkdown(text))
+				}
+				builder.WriteString("\n")
+			} else {
+				builder.WriteString("\n" + markdownValue(field.Value, indent+"  "))
+			}
+		}
+	case []any:
+		for i, item := range typed {
+			marker := fmt.Sprintf("%d. ", i+1)
+			if isScalar(item) {
+				builder.WriteString(indent + marker + escapeMarkdown(oneline(scalarText(item))) + "\n")
+				continue
+			}
+			// the first line of the nested list goes on the line of the marker
+			nested := markdownValue(item, indent+strings.Repeat(" ", len(marker)))
+			builder.WriteString(indent + marker + strings.TrimLeft(nested, " "))
+		}
+	default:
+		builder.WriteString(escapeMarkdown(scalarText(value)) + "\n")
+	}
+	return builder.String()
+}
+
+// Renders objects as indented `key: value` lines, and arrays as `-` items
+func textValue(value any, indent string) string {
+	var builder strings.Builder
+	switch typed := value.(type) {
+	case []field:
+		for _, field := range typed {
+			builder.WriteString(indent + field.Key + ":")
+			if isScalar(field.Value) {
+				if text := oneline(scalarText(field.Value)); text != "" {
+					builder.WriteString(" " + text)
+				}
+				builder.WriteString("\n")
+			} else {
+				builder.WriteString("\n" + textValue(field.Value, indent+"  "))
+			}
+		}
+	case []any:
+		for _, item := range typed {
+			if isScalar(item) {
+				builder.WriteString(indent + "- " + oneline(scalarText(item)) + "\n")
+				continue
+			}
+			nested := textValue(item, indent+"  ")
+			builder.WriteString(indent + "- " + strings.TrimLeft(nested, " "))
+		}
+	default:
+		builder.WriteString(scalarText(value) + "\n")
+	}
+	return builder.String()
+}
+
+// Renders objects as description lists, and arrays as ordered lists
+func writeHtmlValue(builder *strings.Builder, value any) {
+	switch typed := value.(type) {
+	case []field:
+		builder.WriteString("<dl>\n")
+		for _, field := range typed {
+			fmt.Fprintf(builder, "<dt>%s</dt>\n<dd>", html.EscapeString(field.Key))
+			writeHtmlValue(builder, field.Value)
+			builder.WriteString("</dd>\n")
+		}
+		builder.WriteString("</dl>\n")
+	case []any:
+		builder.WriteString("<ol>\n")
+		for _, item := range typed {
+			builder.WriteString("<li>")
+			writeHtmlValue(builder, item)
+			builder.WriteString("</li>\n")
+		}
+		builder.WriteString("</ol>\n")
+	default:
+		builder.WriteString(html.EscapeString(scalarText(value)))
+	}
+}
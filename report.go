@@ -0,0 +1,424 @@
+package linkup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+)
+
+// Struct type representing a section of the outline of a report
+type ReportSection struct {
+	// Title The title of the section.
+	Title string
+
+	// Query The query answered by the section. Empty means the topic of the report followed by the title.
+	Query string
+
+	// Schema The JSON schema of the content of the section, as generated by `GenerateJSONSchema`.
+	Schema json.RawMessage
+
+	// Render The function writing the content of the section as Markdown. Nil means that every
+	// property of the content is written under its title, in the order of the schema.
+	Render func(data map[string]any) (string, error)
+}
+
+// Struct type representing the content of the sections created with `DefaultReportSection`
+type SectionContent struct {
+	Summary   string   `json:"summary" jsonschema:"title=Summary,description=A summary of the answer in one or two paragraphs"`
+	KeyPoints []string `json:"key_points" jsonschema:"title=Key points,description=The key facts of the answer, one sentence each"`
+}
+
+// Create a section whose content follows the schema generated from T. The optional render
+// function writes the content as Markdown; without it, every property of the content is
+// written under its title.
+func NewReportSection[T any](title string, query string, render func(content T) string) (ReportSection, error) {
+	schema, err := GenerateJSONSchema[T]()
+	if err != nil {
+		return ReportSection{}, err
+	}
+	section := ReportSection{Title: title, Query: query, Schema: schema}
+	if render != nil {
+		section.Render = func(data map[string]any) (string, error) {
+			content, err := GetResultFromSourcedOutput[T](&StructuredWithSourcesDto{Data: data})
+			if err != nil {
+				return "", err
+			}
+			return render(content.(T)), nil
+		}
+	}
+	return section, nil
+}
+
+// Create a section made of a summary and a list of key points (see `SectionContent`)
+func DefaultReportSection(title string, query string) (ReportSection, error) {
+	return NewReportSection(title, query, func(content SectionContent) string {
+		var builder strings.Builder
+		builder.WriteString(strings.TrimSpace(content.Summary))
+		if len(content.KeyPoints) > 0 {
+			builder.WriteString("\n\n")
+			for _, point := range content.KeyPoints {
+				fmt.Fprintf(&builder, "- %s\n", strings.TrimSpace(point))
+			}
+		}
+		return strings.TrimSpace(builder.String())
+	})
+}
+
+// Options to generate a report with `GenerateReport`
+type ReportOptions struct {
+	// Depth The depth of the searches of the sections.
+	Depth SearchDepth
+
+	// SearchOptions The options of the searches of the sections. Sources are always included.
+	SearchOptions AdditionalSearchOptions
+
+	// Concurrency The maximum number of sections generated at the same time.
+	Concurrency int
+}
+
+func DefaultReportOptions() ReportOptions {
+	return ReportOptions{
+		Depth:         Standard,
+		SearchOptions: DefaultAdditionalSearchOptions(),
+		Concurrency:   4,
+	}
+}
+
+// Struct type representing a generated section of a report
+type GeneratedSection struct {
+	// Title The title of the section.
+	Title string
+
+	// Query The query answered by the section.
+	Query string
+
+	// Data The structured content of the section, following its schema.
+	Data map[string]any
+
+	// Markdown The content of the section, written as Markdown.
+	Markdown string
+
+	// Sources The numbers of the sources of the section, in the sources of the report.
+	Sources []int
+
+	// Err The error that occurred while generating the section, if any.
+	Err error
+}
+
+// Struct type representing a report generated from an outline
+type Report struct {
+	// Topic The topic of the report.
+	Topic string
+
+	// Sections The sections of the report, in the order of the outline.
+	Sections []GeneratedSection
+
+	// Sources The sources of all the sections, deduplicated by canonical URL and numbered from 1
+	// in the order of the sections.
+	Sources []SourceDto
+}
+
+// Generate a report on a topic: the content of each section of the outline is requested with
+// `GetStructuredResults`, following the schema of the section, and the sections are assembled
+// with their sources. The sources are numbered in the order of the outline, so that the same
+// answers always give the same report. Sections that fail are reported in their `Err` field;
+// an error is returned only when every section fails.
+func (l *LinkupClient) GenerateReport(topic string, outline []ReportSection, reportOptions ...ReportOptions) (*Report, error) {
+	var options ReportOptions
+	switch len(reportOptions) {
+	case 0:
+		options = DefaultReportOptions()
+	default:
+		options = reportOptions[0]
+		if options.Concurrency <= 0 {
+			options.Concurrency = DefaultReportOptions().Concurrency
+		}
+	}
+	if len(outline) == 0 {
+		return nil, errors.New("the outline of the report is empty")
+	}
+	options.SearchOptions.IncludeSources = true
+
+	outputs := make([]*StructuredOutput, len(outline))
+	errs := make([]error, len(outline))
+	semaphore := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+	for i, section := range outline {
+		if len(section.Schema) == 0 {
+			errs[i] = fmt.Errorf("section %q has no schema", section.Title)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, section ReportSection) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			outputs[i], errs[i] = l.GetStructuredResults(sectionQuery(topic, section), options.Depth, section.Schema, options.SearchOptions)
+		}(i, section)
+	}
+	wg.Wait()
+
+	if !slices.ContainsFunc(errs, func(err error) bool { return err == nil }) {
+		return nil, fmt.Errorf("every section failed: %w", errors.Join(errs...))
+	}
+	return assembleReport(topic, outline, outputs, errs), nil
+}
+
+func sectionQuery(topic string, section ReportSection) string {
+	if query := strings.TrimSpace(section.Query); query != "" {
+		return query
+	}
+	return strings.TrimSpace(topic) + ": " + strings.TrimSpace(section.Title)
+}
+
+func assembleReport(topic string, outline []ReportSection, outputs []*StructuredOutput, errs []error) *Report {
+	report := &Report{Topic: topic}
+	numbers := make(map[string]int)
+	for i, section := range outline {
+		generated := GeneratedSection{Title: section.Title, Query: sectionQuery(topic, section), Err: errs[i]}
+		if generated.Err == nil && (outputs[i] == nil || outputs[i].SourcedOutput == nil) {
+			generated.Err = errors.New("the response has no sources")
+		}
+		if generated.Err == nil {
+			sourced := outputs[i].SourcedOutput
+			generated.Data = sourced.Data
+			render := section.Render
+			if render == nil {
+				render = func(data map[string]any) (string, error) {
+					return markdownData(data, schemaProperties(section.Schema)), nil
+				}
+			}
+			generated.Markdown, generated.Err = render(sourced.Data)
+			for _, source := range sourced.Sources {
+				if source.Url == nil || strings.TrimSpace(*source.Url) == "" {
+					continue
+				}
+				key := canonical.URL(*source.Url)
+				number, ok := numbers[key]
+				if !ok {
+					report.Sources = append(report.Sources, SourceDto{
+						Name:    valueOrEmpty(source.Name),
+						Url:     *source.Url,
+						Snippet: valueOrEmpty(source.Content),
+					})
+					number = len(report.Sources)
+					numbers[key] = number
+				}
+				if !slices.Contains(generated.Sources, number) {
+					generated.Sources = append(generated.Sources, number)
+				}
+			}
+		}
+		report.Sections = append(report.Sections, generated)
+	}
+	return report
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// Render the report as Markdown: the topic as title, a heading per section followed by the
+// numbers of its sources, and the list of the sources at the end
+func (r *Report) Markdown() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# %s\n", strings.TrimSpace(r.Topic))
+	for _, section := range r.Sections {
+		fmt.Fprintf(&builder, "\n## %s\n\n", strings.TrimSpace(section.Title))
+		if section.Err != nil {
+			fmt.Fprintf(&builder, "_This section could not be generated: %s_\n", section.Err)
+			continue
+		}
+		if content := strings.TrimSpace(section.Markdown); content != "" {
+			builder.WriteString(content + "\n")
+		}
+		if len(section.Sources) > 0 {
+			citations := make([]string, len(section.Sources))
+			for i, number := range section.Sources {
+				citations[i] = fmt.Sprintf("[%d]", number)
+			}
+			fmt.Fprintf(&builder, "\nSources: %s\n", strings.Join(citations, " "))
+		}
+	}
+	if len(r.Sources) > 0 {
+		builder.WriteString("\n## Sources\n\n")
+		for i, source := range r.Sources {
+			name := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(strings.Join(strings.Fields(source.Name), " "))
+			if name == "" {
+				name = source.Url
+			}
+			fmt.Fprintf(&builder, "- [%d] [%s](%s)\n", i+1, name, source.Url)
+		}
+	}
+	return builder.String()
+}
+
+// Struct type representing a property of a JSON schema
+type schemaProperty struct {
+	name  string
+	title string
+}
+
+// Returns the top-level properties of a JSON schema, in the order they are declared
+func schemaProperties(schema json.RawMessage) []schemaProperty {
+	var parsed struct {
+		Properties json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(schema, &parsed); err != nil || len(parsed.Properties) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(parsed.Properties))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+	var properties []schemaProperty
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		name, _ := token.(string)
+		var property struct {
+			Title string `json:"title"`
+		}
+		if err := decoder.Decode(&property); err != nil {
+			break
+		}
+		properties = append(properties, schemaProperty{name: name, title: property.Title})
+	}
+	return properties
+}
+
+// Writes structured data as Markdown: each property under its title in bold, in the order of
+// the schema, followed by the properties missing from the schema in alphabetical order
+func markdownData(data map[string]any, properties []schemaProperty) string {
+	ordered := slices.Clone(properties)
+	var extra []string
+	for name := range data {
+		if !slices.ContainsFunc(properties, func(property schemaProperty) bool { return property.name == name }) {
+			extra = append(extra, name)
+		}
+	}
+	slices.Sort(extra)
+	for _, name := range extra {
+		ordered = append(ordered, schemaProperty{name: name})
+	}
+	var blocks []string
+	for _, property := range ordered {
+		value, ok := data[property.name]
+		if !ok {
+			continue
+		}
+		content := markdownBlock(value)
+		if content == "" {
+			continue
+		}
+		title := property.title
+		if title == "" {
+			title = humanize(property.name)
+		}
+		blocks = append(blocks, fmt.Sprintf("**%s**\n\n%s", title, content))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func markdownBlock(value any) string {
+	switch typed := value.(type) {
+	case []any:
+		lines := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if text := markdownInline(item); text != "" {
+				lines = append(lines, "- "+text)
+			}
+		}
+		return strings.Join(lines, "\n")
+	case map[string]any:
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		lines := make([]string, 0, len(names))
+		for _, name := range names {
+			if text := markdownInline(typed[name]); text != "" {
+				lines = append(lines, fmt.Sprintf("- %s: %s", humanize(name), text))
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+	return markdownInline(value)
+}
+
+func markdownInline(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.Join(strings.Fields(typed), " ")
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case []any:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if text := markdownInline(item); text != "" {
+				items = append(items, text)
+			}
+		}
+		return strings.Join(items, ", ")
+	case map[string]any:
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		items := make([]string, 0, len(names))
+		for _, name := range names {
+			if text := markdownInline(typed[name]); text != "" {
+				items = append(items, fmt.Sprintf("%s: %s", humanize(name), text))
+			}
+		}
+		return strings.Join(items, "; ")
+	}
+	return fmt.Sprint(value)
+}
+
+// Turns a property name (`keyPoints`, `key_points`) into a label (`Key points`)
+func humanize(name string) string {
+	var words []string
+	var current []rune
+	for _, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+		case unicode.IsUpper(r) && len(current) > 0 && !unicode.IsUpper(current[len(current)-1]):
+			words = append(words, string(current))
+			current = []rune{r}
+		default:
+			current = append(current, r)
+		}
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	if len(words) == 0 {
+		return name
+	}
+	label := strings.ToLower(strings.Join(words, " "))
+	first := []rune(label)
+	first[0] = unicode.ToUpper(first[0])
+	return string(first)
+}
@@ -0,0 +1,166 @@
+package linkup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type StructuredMockClient struct {
+	MockClient
+	outputs map[string]StructuredWithSourcesDto
+
+	mu      sync.Mutex
+	schemas map[string]string
+}
+
+func (m *StructuredMockClient) SearchWithResponse(ctx context.Context, body SearchJSONRequestBody, requestEditors ...RequestEditorFn) (*SearchResponse, error) {
+	m.mu.Lock()
+	m.schemas[body.Q] = string(body.StructuredOutputSchema)
+	m.mu.Unlock()
+	output, ok := m.outputs[body.Q]
+	if !ok || body.IncludeSources == nil || !*body.IncludeSources {
+		return &SearchResponse{
+			Body:         []byte("bad request"),
+			HTTPResponse: &http.Response{Status: "400 Bad Request", StatusCode: 400},
+		}, nil
+	}
+	marshaled, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResponse{Body: marshaled, HTTPResponse: &http.Response{Status: "200 OK", StatusCode: 200}}, nil
+}
+
+func structuredSources(urls ...string) StructuredWithSourcesDto {
+	var output StructuredWithSourcesDto
+	for _, url := range urls {
+		name := "Page " + url
+		output.Sources = append(output.Sources, sourcedOutputSource{Name: &name, Url: &url})
+	}
+	return output
+}
+
+type ReportRelease struct {
+	Version string   `json:"version" jsonschema:"title=Version"`
+	Year    int      `json:"year"`
+	Changes []string `json:"changes" jsonschema:"title=Main changes"`
+}
+
+func TestGenerateReport(t *testing.T) {
+	overview := structuredSources("https://go.dev", "https://en.wikipedia.org/wiki/Go")
+	overview.Data = map[string]any{"summary": "Go is a programming language.", "key_points": []string{"Designed at Google", "Open source"}}
+	release := structuredSources("https://www.go.dev/", "https://go.dev/doc/go1.22")
+	release.Data = map[string]any{"changes": []string{"Range over integers", "Loop variables"}, "version": "1.22", "year": 2024, "codename": "none"}
+	mock := &StructuredMockClient{
+		outputs: map[string]StructuredWithSourcesDto{
+			"Go: Overview":      overview,
+			"latest Go release": release,
+		},
+		schemas: make(map[string]string),
+	}
+	client := &LinkupClient{apiKey: "test", client: mock}
+
+	overviewSection, err := DefaultReportSection("Overview", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseSection, err := NewReportSection[ReportRelease]("Latest release", "latest Go release", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failingSection, err := DefaultReportSection("Failing", "a query without answer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := client.GenerateReport("Go", []ReportSection{overviewSection, releaseSection, failingSection})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(mock.schemas["latest Go release"], `"changes"`) || !strings.Contains(mock.schemas["Go: Overview"], `"key_points"`) {
+		t.Fatalf("expected the schema of each section to be sent, got %v", mock.schemas)
+	}
+	if len(report.Sections) != 3 || report.Sections[2].Err == nil {
+		t.Fatalf("expected the failing section to be reported, got %+v", report.Sections)
+	}
+	if len(report.Sources) != 3 || report.Sections[1].Sources[0] != 1 || report.Sections[1].Sources[1] != 3 {
+		t.Fatalf("expected the sources to be deduplicated across sections, got %+v and %v", report.Sources, report.Sections[1].Sources)
+	}
+
+	expected := `# Go
+
+## Overview
+
+Go is a programming language.
+
+- Designed at Google
+- Open source
+
+Sources: [1] [2]
+
+## Latest release
+
+**Version**
+
+1.22
+
+**Year**
+
+2024
+
+**Main changes**
+
+- Range over integers
+- Loop variables
+
+**Codename**
+
+none
+
+Sources: [1] [3]
+
+## Failing
+
+_This section could not be generated: response returned a status code of 400: 400 Bad Request_
+
+## Sources
+
+- [1] [Page https://go.dev](https://go.dev)
+- [2] [Page https://en.wikipedia.org/wiki/Go](https://en.wikipedia.org/wiki/Go)
+- [3] [Page https://go.dev/doc/go1.22](https://go.dev/doc/go1.22)
+`
+	if markdown := report.Markdown(); markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+}
+
+func TestGenerateReportErrors(t *testing.T) {
+	client := &LinkupClient{apiKey: "test", client: &StructuredMockClient{schemas: make(map[string]string)}}
+	if _, err := client.GenerateReport("Go", nil); err == nil {
+		t.Fatal("expected an error for an empty outline")
+	}
+	section, err := DefaultReportSection("Overview", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GenerateReport("Go", []ReportSection{section, {Title: "No schema"}}); err == nil {
+		t.Fatal("expected an error when every section fails")
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	cases := map[string]string{
+		"keyPoints":  "Key points",
+		"key_points": "Key points",
+		"URL":        "Url",
+		"year":       "Year",
+	}
+	for name, expected := range cases {
+		if label := humanize(name); label != expected {
+			t.Errorf("humanize(%q) = %q, expected %q", name, label, expected)
+		}
+	}
+}
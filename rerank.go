@@ -0,0 +1,324 @@
+package linkup
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Struct type representing a search result being re-ranked
+type ScoredResult struct {
+	// Result The search result.
+	Result TextSearchResultDto
+
+	// Rank The position of the result in the search results, starting from 1.
+	Rank int
+
+	// Score The score of the result, the higher the better. Before any ranker runs, the score
+	// decreases linearly with the rank, from 1 for the first result.
+	Score float64
+}
+
+// Interface representing a stage of local re-ranking of search results. A ranker updates the
+// scores of the results and may drop some of them; the results are sorted by score once every
+// ranker has run. Each ranker is given its own copy of the results, which it may modify.
+type Ranker interface {
+	Rank(query string, results []ScoredResult) []ScoredResult
+}
+
+// Function type implementing Ranker
+type RankerFunc func(query string, results []ScoredResult) []ScoredResult
+
+func (f RankerFunc) Rank(query string, results []ScoredResult) []ScoredResult {
+	return f(query, results)
+}
+
+// Option to re-rank the text results of `GetSearchResults` with the given rankers, applied in order
+// (see `RankResults`). The image results are not affected.
+func WithRankers(rankers ...Ranker) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		s.rankers = append(s.rankers, rankers...)
+		return nil
+	}
+}
+
+// Run the rankers, in order, on the text results of a search, and get the remaining results
+// sorted by score. Results with the same score keep their original order.
+func RankResults(query string, results []TextSearchResultDto, rankers ...Ranker) []ScoredResult {
+	scored := make([]ScoredResult, len(results))
+	for i, result := range results {
+		scored[i] = ScoredResult{
+			Result: result,
+			Rank:   i + 1,
+			Score:  float64(len(results)-i) / float64(len(results)),
+		}
+	}
+	for _, ranker := range rankers {
+		scored = ranker.Rank(query, slices.Clone(scored))
+	}
+	slices.SortStableFunc(scored, func(a ScoredResult, b ScoredResult) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return a.Rank - b.Rank
+	})
+	return scored
+}
+
+// Get the search results with their text results re-ranked by the given rankers (see `RankResults`).
+// The output passed as argument is not modified.
+func RerankSearchResults(query string, output *SearchResultsOutput, rankers ...Ranker) *SearchResultsOutput {
+	scored := RankResults(query, output.TextResults, rankers...)
+	reranked := &SearchResultsOutput{
+		TextResults:  make([]TextSearchResultDto, len(scored)),
+		ImageResults: output.ImageResults,
+	}
+	for i, result := range scored {
+		reranked.TextResults[i] = result.Result
+	}
+	return reranked
+}
+
+// Ranker scoring the results by the lexical relevance of their content to the query, with
+// the Okapi BM25 function. The BM25 scores are normalized between 0 and 1 (the best result
+// getting 1), and blended with the current scores according to Weight. When no word of
+// the query appears in any result, the scores are not changed.
+type BM25Ranker struct {
+	// K1 The term frequency saturation. Zero means 1.2.
+	K1 float64
+
+	// B The document length normalization, between 0 and 1. Zero means 0.75.
+	B float64
+
+	// Weight The weight of the BM25 score against the current score, between 0 and 1. Zero means 1:
+	// the results are ranked by BM25 only, ties keeping the current order.
+	Weight float64
+}
+
+func (r BM25Ranker) Rank(query string, results []ScoredResult) []ScoredResult {
+	k1, b, weight := r.K1, r.B, r.Weight
+	if k1 <= 0 {
+		k1 = 1.2
+	}
+	if b <= 0 {
+		b = 0.75
+	}
+	if weight <= 0 {
+		weight = 1
+	}
+	terms := words(query)
+	slices.Sort(terms)
+	terms = slices.Compact(terms)
+	if len(terms) == 0 || len(results) == 0 {
+		return results
+	}
+
+	frequencies := make([]map[string]int, len(results))
+	lengths := make([]int, len(results))
+	documentFrequencies := make(map[string]int)
+	totalLength := 0
+	for i, result := range results {
+		frequencies[i] = make(map[string]int)
+		for _, word := range words(result.Result.Content) {
+			frequencies[i][word]++
+			lengths[i]++
+		}
+		totalLength += lengths[i]
+		for _, term := range terms {
+			if frequencies[i][term] > 0 {
+				documentFrequencies[term]++
+			}
+		}
+	}
+	averageLength := max(float64(totalLength)/float64(len(results)), 1)
+
+	scores := make([]float64, len(results))
+	best := 0.0
+	for i := range results {
+		for _, term := range terms {
+			frequency := float64(frequencies[i][term])
+			if frequency == 0 {
+				continue
+			}
+			n := float64(documentFrequencies[term])
+			idf := math.Log((float64(len(results))-n+0.5)/(n+0.5) + 1)
+			scores[i] += idf * frequency * (k1 + 1) / (frequency + k1*(1-b+b*float64(lengths[i])/averageLength))
+		}
+		best = max(best, scores[i])
+	}
+	if best == 0 {
+		return results
+	}
+	for i := range results {
+		results[i].Score = (1-weight)*results[i].Score + weight*scores[i]/best
+	}
+	return results
+}
+
+// Ranker filtering and boosting the results by domain. Domains match their subdomains too,
+// and the `www.` prefix of hosts is ignored.
+type DomainRanker struct {
+	// Allow When not empty, only the results from these domains are kept.
+	Allow []string
+
+	// Deny The domains whose results are dropped.
+	Deny []string
+
+	// Boost The factors the scores of the results are multiplied by, keyed by domain: use factors
+	// above 1 to promote a domain, and below 1 to demote it. When several domains match a host,
+	// the most specific one is used.
+	Boost map[string]float64
+}
+
+func (r DomainRanker) Rank(query string, results []ScoredResult) []ScoredResult {
+	kept := results[:0]
+	for _, result := range results {
+		host := resultHost(result.Result.Url)
+		if len(r.Allow) > 0 && !slices.ContainsFunc(r.Allow, func(domain string) bool { return matchesDomain(host, domain) }) {
+			continue
+		}
+		if slices.ContainsFunc(r.Deny, func(domain string) bool { return matchesDomain(host, domain) }) {
+			continue
+		}
+		matched := ""
+		for domain := range r.Boost {
+			if matchesDomain(host, domain) && len(normalizeDomain(domain)) > len(normalizeDomain(matched)) {
+				matched = domain
+			}
+		}
+		if matched != "" {
+			result.Score *= r.Boost[matched]
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
+
+// Returns the lowercased host of a URL, without the `www.` prefix
+func resultHost(rawUrl string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return ""
+	}
+	return normalizeDomain(parsed.Hostname())
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	return strings.TrimPrefix(domain, "www.")
+}
+
+// Whether a host is the given domain or one of its subdomains
+func matchesDomain(host string, domain string) bool {
+	domain = normalizeDomain(domain)
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// Ranker boosting the recent results. The date of a result is extracted from its URL (as in
+// `/2024/05/12/`) or from the beginning of its content; results without an extractable date
+// keep their score. The score of a dated result is multiplied by 1 + Weight * 2^(-age/HalfLife).
+type RecencyRanker struct {
+	// HalfLife The age at which the boost is halved. Zero means 30 days.
+	HalfLife time.Duration
+
+	// Weight The boost of a result published right now. Zero means 1, doubling its score.
+	Weight float64
+
+	// Now The time the ages are computed from. Zero means the current time.
+	Now time.Time
+}
+
+func (r RecencyRanker) Rank(query string, results []ScoredResult) []ScoredResult {
+	halfLife, weight, now := r.HalfLife, r.Weight, r.Now
+	if halfLife <= 0 {
+		halfLife = 30 * 24 * time.Hour
+	}
+	if weight <= 0 {
+		weight = 1
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for i := range results {
+		date, ok := ResultDate(results[i].Result)
+		// dates in the future are most likely not publication dates
+		if !ok || date.After(now.Add(24*time.Hour)) {
+			continue
+		}
+		age := max(now.Sub(date), 0)
+		results[i].Score *= 1 + weight*math.Exp2(-float64(age)/float64(halfLife))
+	}
+	return results
+}
+
+// The full and three-letter month names, the forms that the layouts of `parseFlexibleDate` can parse
+const monthNames = `(?:January|February|March|April|May|June|July|August|September|October|November|December|Jan|Feb|Mar|Apr|Jun|Jul|Aug|Sep|Oct|Nov|Dec)`
+
+var (
+	urlDatePattern     = regexp.MustCompile(`/((?:19|20)\d{2})[/-](0[1-9]|1[0-2])(?:[/-](0[1-9]|[12]\d|3[01]))?(?:/|$|[^\d])`)
+	isoDatePattern     = regexp.MustCompile(`\b(?:19|20)\d{2}-(?:0[1-9]|1[0-2])-(?:0[1-9]|[12]\d|3[01])\b`)
+	writtenDatePattern = regexp.MustCompile(`\b(?:` + monthNames + ` \d{1,2}, (?:19|20)\d{2}|\d{1,2} ` + monthNames + ` (?:19|20)\d{2})\b`)
+)
+
+// The number of characters at the beginning of the content searched for a date
+const contentDatePrefix = 500
+
+// Extract the publication date of a search result from its URL (as in `/2024/05/12/` or
+// `/2024/05/`) or, failing that, from the first date written at the beginning of its content
+func ResultDate(result TextSearchResultDto) (time.Time, bool) {
+	if match := urlDatePattern.FindStringSubmatch(result.Url); match != nil {
+		day := match[3]
+		if day == "" {
+			day = "01"
+		}
+		if date, ok := parseFlexibleDate(match[1] + "-" + match[2] + "-" + day); ok {
+			return date, true
+		}
+	}
+	content := result.Content
+	if utf8.RuneCountInString(content) > contentDatePrefix {
+		content = string([]rune(content)[:contentDatePrefix])
+	}
+	for _, pattern := range []*regexp.Regexp{isoDatePattern, writtenDatePattern} {
+		if match := pattern.FindString(content); match != "" {
+			if date, ok := parseFlexibleDate(match); ok {
+				return date, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Ranker dropping the results whose content is too short or too long
+type LengthFilter struct {
+	// MinLength The minimum length of the content, in Unit. Zero means no minimum.
+	MinLength int
+
+	// MaxLength The maximum length of the content, in Unit. Zero means no maximum.
+	MaxLength int
+
+	// Unit The unit of MinLength and MaxLength.
+	Unit ChunkUnit
+}
+
+func (f LengthFilter) Rank(query string, results []ScoredResult) []ScoredResult {
+	kept := results[:0]
+	for _, result := range results {
+		length := utf8.RuneCountInString(strings.TrimSpace(result.Result.Content))
+		if f.Unit == ChunkTokens {
+			length = (length + 3) / 4
+		}
+		if (f.MinLength > 0 && length < f.MinLength) || (f.MaxLength > 0 && length > f.MaxLength) {
+			continue
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
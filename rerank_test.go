@@ -0,0 +1,180 @@
+package linkup
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rankedUrls(results []ScoredResult) []string {
+	urls := make([]string, len(results))
+	for i, result := range results {
+		urls[i] = result.Result.Url
+	}
+	return urls
+}
+
+func TestRankResultsWithoutRankers(t *testing.T) {
+	results := []TextSearchResultDto{textResult("https://a.com"), textResult("https://b.com")}
+	ranked := RankResults("query", results)
+	if !slices.Equal(rankedUrls(ranked), []string{"https://a.com", "https://b.com"}) {
+		t.Fatalf("expected the original order, got %v", rankedUrls(ranked))
+	}
+	if ranked[0].Score != 1 || ranked[1].Score != 0.5 || ranked[1].Rank != 2 {
+		t.Fatalf("unexpected initial scores: %+v", ranked)
+	}
+}
+
+func TestBM25Ranker(t *testing.T) {
+	results := []TextSearchResultDto{
+		{Url: "https://a.com", Content: "A guide to cooking pasta at home."},
+		{Url: "https://b.com", Content: "Go generics: type parameters explained, with generics examples."},
+		{Url: "https://c.com", Content: "Go concurrency patterns with goroutines."},
+	}
+	ranked := RankResults("go generics", results, BM25Ranker{})
+	expected := []string{"https://b.com", "https://c.com", "https://a.com"}
+	if !slices.Equal(rankedUrls(ranked), expected) {
+		t.Fatalf("expected %v, got %v", expected, rankedUrls(ranked))
+	}
+	if ranked[0].Score != 1 || ranked[2].Score != 0 {
+		t.Fatalf("expected normalized scores, got %+v", ranked)
+	}
+
+	// a small weight keeps the original order when the lexical scores are close
+	blended := RankResults("go", results[1:], BM25Ranker{Weight: 0.1})
+	if blended[0].Result.Url != "https://b.com" {
+		t.Fatalf("expected the original order to prevail, got %v", rankedUrls(blended))
+	}
+
+	// no matching word: the scores are unchanged
+	unchanged := RankResults("rust", results, BM25Ranker{})
+	if !slices.Equal(rankedUrls(unchanged), []string{"https://a.com", "https://b.com", "https://c.com"}) {
+		t.Fatalf("expected the original order, got %v", rankedUrls(unchanged))
+	}
+}
+
+func TestDomainRanker(t *testing.T) {
+	results := []TextSearchResultDto{
+		textResult("https://spam.example.com/page"),
+		textResult("https://www.example.com/page"),
+		textResult("https://docs.go.dev/page"),
+		textResult("https://other.org/page"),
+	}
+	ranked := RankResults("", results, DomainRanker{
+		Deny:  []string{"spam.example.com"},
+		Boost: map[string]float64{"go.dev": 3, "docs.go.dev": 4, "example.com": 0.5},
+	})
+	// 0.5*4, 0.75*0.5 and 0.25
+	expected := []string{"https://docs.go.dev/page", "https://www.example.com/page", "https://other.org/page"}
+	if !slices.Equal(rankedUrls(ranked), expected) {
+		t.Fatalf("expected %v, got %v", expected, rankedUrls(ranked))
+	}
+	if ranked[0].Score != 2 {
+		t.Fatalf("expected the most specific boost to apply, got a score of %v", ranked[0].Score)
+	}
+
+	allowed := RankResults("", results, DomainRanker{Allow: []string{"EXAMPLE.com"}})
+	if len(allowed) != 2 {
+		t.Fatalf("expected 2 allowed results, got %v", rankedUrls(allowed))
+	}
+}
+
+func TestResultDate(t *testing.T) {
+	cases := []struct {
+		result   TextSearchResultDto
+		expected string
+	}{
+		{TextSearchResultDto{Url: "https://blog.com/2024/05/12/post"}, "2024-05-12"},
+		{TextSearchResultDto{Url: "https://blog.com/2023/11/post"}, "2023-11-01"},
+		{TextSearchResultDto{Url: "https://blog.com/post", Content: "Published 2022-03-04 by Jane"}, "2022-03-04"},
+		{TextSearchResultDto{Url: "https://blog.com/post", Content: "Posted on January 5, 2021."}, "2021-01-05"},
+		{TextSearchResultDto{Url: "https://blog.com/post", Content: "Updated 7 Feb 2020"}, "2020-02-07"},
+		{TextSearchResultDto{Url: "https://blog.com/post", Content: "Sept 5, 2024, updated March 3, 2023"}, "2023-03-03"},
+		{TextSearchResultDto{Url: "https://blog.com/post", Content: "No date here"}, ""},
+		{TextSearchResultDto{Url: "https://blog.com/post", Content: strings.Repeat("x ", 300) + "2020-01-01"}, ""},
+	}
+	for _, c := range cases {
+		date, ok := ResultDate(c.result)
+		got := ""
+		if ok {
+			got = date.Format("2006-01-02")
+		}
+		if got != c.expected {
+			t.Errorf("ResultDate(%q, %q) = %q, expected %q", c.result.Url, c.result.Content, got, c.expected)
+		}
+	}
+}
+
+func TestRecencyRanker(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	results := []TextSearchResultDto{
+		textResult("https://blog.com/2020/01/01/old"),
+		textResult("https://blog.com/undated"),
+		textResult("https://blog.com/2024/05/31/recent"),
+		textResult("https://blog.com/2030/01/01/future"),
+	}
+	ranked := RankResults("", results, RecencyRanker{HalfLife: 24 * time.Hour, Weight: 4, Now: now})
+	expected := []string{"https://blog.com/2024/05/31/recent", "https://blog.com/2020/01/01/old", "https://blog.com/undated", "https://blog.com/2030/01/01/future"}
+	if !slices.Equal(rankedUrls(ranked), expected) {
+		t.Fatalf("expected %v, got %v", expected, rankedUrls(ranked))
+	}
+	if ranked[0].Score != 0.5*(1+4*0.5) {
+		t.Fatalf("expected the recent result to get half of the boost, got a score of %v", ranked[0].Score)
+	}
+}
+
+func TestLengthFilter(t *testing.T) {
+	results := []TextSearchResultDto{
+		{Url: "https://a.com", Content: "short"},
+		{Url: "https://b.com", Content: strings.Repeat("a", 40)},
+		{Url: "https://c.com", Content: strings.Repeat("a", 400)},
+	}
+	ranked := RankResults("", results, LengthFilter{MinLength: 5, MaxLength: 50, Unit: ChunkTokens})
+	if !slices.Equal(rankedUrls(ranked), []string{"https://b.com"}) {
+		t.Fatalf("expected only the medium result, got %v", rankedUrls(ranked))
+	}
+}
+
+func TestRankerPipeline(t *testing.T) {
+	results := []TextSearchResultDto{
+		{Url: "https://a.com", Content: "golang channels"},
+		{Url: "https://b.com", Content: "golang generics and golang type parameters"},
+	}
+	calls := 0
+	counter := RankerFunc(func(query string, results []ScoredResult) []ScoredResult {
+		calls++
+		if query != "golang generics" {
+			t.Errorf("unexpected query %q", query)
+		}
+		return results
+	})
+	output := RerankSearchResults("golang generics", &SearchResultsOutput{TextResults: results}, BM25Ranker{}, counter, DomainRanker{Boost: map[string]float64{"a.com": 10}})
+	if calls != 1 {
+		t.Fatalf("expected the custom ranker to run once, got %d", calls)
+	}
+	if output.TextResults[0].Url != "https://a.com" {
+		t.Fatalf("expected the boosted result first, got %v", output.TextResults)
+	}
+	if results[0].Url != "https://a.com" || results[1].Url != "https://b.com" {
+		t.Fatal("the original results should not be modified")
+	}
+}
+
+func TestWithRankers(t *testing.T) {
+	mock := &QueryMockClient{results: map[string][]TextSearchResultDto{
+		"go": {textResult("https://spam.com/go"), textResult("https://go.dev/doc")},
+	}}
+	settings := &linkupClientSettings{}
+	if err := WithRankers(DomainRanker{Deny: []string{"spam.com"}})(settings); err != nil {
+		t.Fatal(err)
+	}
+	client := &LinkupClient{apiKey: "test", client: mock, rankers: settings.rankers}
+	output, err := client.GetSearchResults("go", Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.TextResults) != 1 || output.TextResults[0].Url != "https://go.dev/doc" {
+		t.Fatalf("expected the denied result to be dropped, got %v", output.TextResults)
+	}
+}
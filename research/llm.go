@@ -0,0 +1,137 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+)
+
+// Interface for the language model driving a research: it decides which questions to research
+// next, and writes the final report. The evidence is given as an LLM context, whose sources
+// are numbered: reports cite them with their number between brackets, as in `[2]`.
+type LLM interface {
+	// FollowUpQuestions Get the questions to research next, given the research question, the
+	// questions already researched and the evidence gathered so far. No question means that the
+	// evidence is enough to answer.
+	FollowUpQuestions(ctx context.Context, question string, asked []string, evidence *linkup.LLMContext) ([]string, error)
+
+	// WriteReport Write the answer to the research question, citing the evidence.
+	WriteReport(ctx context.Context, question string, evidence *linkup.LLMContext) (string, error)
+}
+
+// Function type sending a prompt to a language model and returning its completion
+type CompletionFunc func(ctx context.Context, prompt string) (string, error)
+
+// LLM built on a plain completion function, to plug any language model API in a research
+type CompletionLLM struct {
+	complete CompletionFunc
+}
+
+func NewCompletionLLM(complete CompletionFunc) *CompletionLLM {
+	return &CompletionLLM{complete: complete}
+}
+
+const followUpPrompt = `You are researching the question below using web sources.
+
+Question: %s
+
+Questions already researched:
+%s
+
+Sources gathered so far:
+%s
+
+List the follow-up questions, if any, that must be researched to answer the question completely:
+one short, self-contained web search query per line, without any other text. Do not repeat the
+questions already researched. Answer NONE if the sources are enough.`
+
+const reportPrompt = `Answer the question below using only the numbered sources. Cite the sources
+supporting each statement with their number between brackets, as in [1] or [2][3]. If the sources
+do not answer some part of the question, say so.
+
+Question: %s
+
+Sources:
+%s`
+
+func (c *CompletionLLM) FollowUpQuestions(ctx context.Context, question string, asked []string, evidence *linkup.LLMContext) ([]string, error) {
+	completion, err := c.complete(ctx, fmt.Sprintf(followUpPrompt, question, "- "+strings.Join(asked, "\n- "), evidence.Prompt))
+	if err != nil {
+		return nil, err
+	}
+	return parseQuestions(completion), nil
+}
+
+func (c *CompletionLLM) WriteReport(ctx context.Context, question string, evidence *linkup.LLMContext) (string, error) {
+	completion, err := c.complete(ctx, fmt.Sprintf(reportPrompt, question, evidence.Prompt))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(completion), nil
+}
+
+var listMarker = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`)
+
+// Returns the questions of a completion, one per line, without list markers
+func parseQuestions(completion string) []string {
+	var questions []string
+	for _, line := range strings.Split(completion, "\n") {
+		line = strings.TrimSpace(listMarker.ReplaceAllString(strings.TrimSpace(line), ""))
+		if line == "" || strings.EqualFold(strings.Trim(line, "."), "none") {
+			continue
+		}
+		questions = append(questions, line)
+	}
+	return questions
+}
+
+// Deterministic LLM, for tests and offline runs: it returns scripted follow-up questions, and
+// writes reports made of the first sentence of every source, cited.
+// A StubLLM is not safe for concurrent use.
+type StubLLM struct {
+	// FollowUps The follow-up questions returned by the successive calls to FollowUpQuestions.
+	// Once they are exhausted, no more question is returned.
+	FollowUps [][]string
+
+	calls int
+}
+
+func (s *StubLLM) FollowUpQuestions(ctx context.Context, question string, asked []string, evidence *linkup.LLMContext) ([]string, error) {
+	if s.calls >= len(s.FollowUps) {
+		return nil, nil
+	}
+	s.calls++
+	return s.FollowUps[s.calls-1], nil
+}
+
+func (s *StubLLM) WriteReport(ctx context.Context, question string, evidence *linkup.LLMContext) (string, error) {
+	var sentences []string
+	for _, id := range evidence.Ids {
+		if sentence := firstSentence(evidence.Sources[id].Content); sentence != "" {
+			sentences = append(sentences, fmt.Sprintf("%s [%d]", sentence, id))
+		}
+	}
+	if len(sentences) == 0 {
+		return "No evidence was found to answer: " + question, nil
+	}
+	return strings.Join(sentences, "\n\n"), nil
+}
+
+// Returns the first sentence of a text, without its final punctuation
+func firstSentence(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	for i, r := range text {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		if next, _ := utf8.DecodeRuneInString(text[i+1:]); i+1 == len(text) || unicode.IsSpace(next) {
+			return text[:i]
+		}
+	}
+	return strings.TrimRightFunc(text, unicode.IsPunct)
+}
@@ -0,0 +1,67 @@
+package research
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+)
+
+func TestParseQuestions(t *testing.T) {
+	completion := "1. Who created Go?\n- When was Go released\n\n* Is Go fast?\n2) Is Go garbage collected?\n"
+	expected := []string{"Who created Go?", "When was Go released", "Is Go fast?", "Is Go garbage collected?"}
+	if questions := parseQuestions(completion); !slices.Equal(questions, expected) {
+		t.Fatalf("expected %v, got %v", expected, questions)
+	}
+	if questions := parseQuestions("NONE."); len(questions) != 0 {
+		t.Fatalf("expected no question, got %v", questions)
+	}
+}
+
+func TestCompletionLLM(t *testing.T) {
+	var prompts []string
+	llm := NewCompletionLLM(func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if strings.Contains(prompt, "follow-up questions") {
+			return "- Who created Go?", nil
+		}
+		return "  Go is a language [1].\n", nil
+	})
+	evidence := &linkup.LLMContext{Prompt: "[1] Go\nURL: https://go.dev\nGo is a language."}
+	questions, err := llm.FollowUpQuestions(context.Background(), "What is Go?", []string{"What is Go?"}, evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(questions, []string{"Who created Go?"}) {
+		t.Fatalf("unexpected questions: %v", questions)
+	}
+	answer, err := llm.WriteReport(context.Background(), "What is Go?", evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "Go is a language [1]." {
+		t.Fatalf("unexpected answer: %q", answer)
+	}
+	for _, prompt := range prompts {
+		if !strings.Contains(prompt, "Question: What is Go?") || !strings.Contains(prompt, evidence.Prompt) {
+			t.Fatalf("expected the question and evidence in the prompt, got:\n%s", prompt)
+		}
+	}
+}
+
+func TestFirstSentence(t *testing.T) {
+	cases := map[string]string{
+		"Go is fast. It is simple.": "Go is fast",
+		"Version 1.22 is out!":      "Version 1.22 is out",
+		"No  final\npunctuation":    "No final punctuation",
+		"Is it a question?":         "Is it a question",
+		"Città è bella. Sì.":        "Città è bella",
+	}
+	for text, expected := range cases {
+		if sentence := firstSentence(text); sentence != expected {
+			t.Errorf("firstSentence(%q) = %q, expected %q", text, sentence, expected)
+		}
+	}
+}
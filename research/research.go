@@ -0,0 +1,360 @@
+// Iterative research on top of the Linkup API: starting from a question, a Researcher searches
+// the web, fetches the most relevant pages, asks a language model for the follow-up questions
+// to research next, and repeats until the model has enough evidence or the budget is exhausted.
+// The evidence is then handed to the model to write a report citing its sources.
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+	"github.com/AstraBert/linkup-go-sdk/fetch/markdown"
+)
+
+// Interface for the search engine used by a Researcher, implemented by `linkup.LinkupClient`
+type Searcher interface {
+	GetSearchResults(query string, depth linkup.SearchDepth, searchOptions ...linkup.AdditionalSearchOptions) (*linkup.SearchResultsOutput, error)
+	Fetch(url string, fetchOptions ...linkup.AdditionalFetchOptions) (*linkup.FetchOutput, error)
+}
+
+// Options to run a research with a Researcher
+type Options struct {
+	// MaxIterations The maximum number of rounds of searches, the first round researching the question itself.
+	MaxIterations int
+
+	// MaxQuestionsPerIteration The maximum number of questions researched in a round. Extra follow-up
+	// questions are postponed to the next rounds.
+	MaxQuestionsPerIteration int
+
+	// MaxSearches The maximum number of searches of the whole research.
+	MaxSearches int
+
+	// MaxFetches The maximum number of pages fetched during the whole research. Zero means no page is fetched,
+	// and the evidence is made of the content of the search results only.
+	MaxFetches int
+
+	// FetchesPerSearch The number of new results of each search whose page is fetched, from the top.
+	// Use MaxFetches to disable fetching.
+	FetchesPerSearch int
+
+	// Depth The depth of the searches. Empty means the default depth of the client.
+	Depth linkup.SearchDepth
+
+	// SearchOptions The options of the searches.
+	SearchOptions linkup.AdditionalSearchOptions
+
+	// FetchOptions The options of the fetches.
+	FetchOptions linkup.AdditionalFetchOptions
+
+	// ContextOptions The options of the context given to the LLM, which bound the size of the evidence.
+	ContextOptions linkup.ContextOptions
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxIterations:            3,
+		MaxQuestionsPerIteration: 3,
+		MaxSearches:              10,
+		MaxFetches:               5,
+		FetchesPerSearch:         1,
+		Depth:                    "",
+		SearchOptions:            linkup.DefaultAdditionalSearchOptions(),
+		FetchOptions:             linkup.DefaultAdditionalFetchOptions(),
+		ContextOptions:           linkup.DefaultContextOptions(),
+	}
+}
+
+// Enum representing the reason why a research stopped
+type StopReason int
+
+const (
+	// The LLM had no more follow-up question
+	StopAnswered StopReason = iota
+	// MaxIterations was reached
+	StopMaxIterations
+	// MaxSearches was reached
+	StopSearchBudget
+)
+
+func (s StopReason) String() string {
+	switch s {
+	case StopAnswered:
+		return "answered"
+	case StopMaxIterations:
+		return "max iterations"
+	case StopSearchBudget:
+		return "search budget"
+	}
+	return "unknown"
+}
+
+// Struct type representing the research of one question
+type Step struct {
+	// Iteration The round of the research, starting from 1.
+	Iteration int
+
+	// Question The question searched.
+	Question string
+
+	// Results The number of text results of the search.
+	Results int
+
+	// Fetched The URLs of the pages fetched successfully.
+	Fetched []string
+
+	// FetchErrors The errors of the fetches that failed, keyed by URL.
+	FetchErrors map[string]error
+
+	// Err The error of the search, if it failed.
+	Err error
+}
+
+// Struct type representing a source of a research report
+type Source struct {
+	// Id The number citing the source in the answer.
+	Id int
+
+	// Name The name of the source.
+	Name string
+
+	// Url The URL of the source.
+	Url string
+
+	// Fetched Whether the page of the source was fetched, rather than only known through its search result.
+	Fetched bool
+}
+
+// Struct type representing the outcome of a research
+type Report struct {
+	// Question The research question.
+	Question string
+
+	// Answer The report written by the LLM, citing the sources with their number between brackets.
+	Answer string
+
+	// Sources The sources given to the LLM to write the answer, by number.
+	Sources []Source
+
+	// Steps The questions researched, in order.
+	Steps []Step
+
+	// StopReason Why the research stopped.
+	StopReason StopReason
+
+	// Searches The number of searches sent.
+	Searches int
+
+	// Fetches The number of pages fetched, including the failed fetches.
+	Fetches int
+}
+
+// A researcher running iterative researches with a search engine and an LLM
+type Researcher struct {
+	searcher Searcher
+	llm      LLM
+	options  Options
+}
+
+func NewResearcher(searcher Searcher, llm LLM, options ...Options) *Researcher {
+	var researchOptions Options
+	switch len(options) {
+	case 0:
+		researchOptions = DefaultOptions()
+	default:
+		researchOptions = options[0]
+		defaults := DefaultOptions()
+		if researchOptions.MaxIterations <= 0 {
+			researchOptions.MaxIterations = defaults.MaxIterations
+		}
+		if researchOptions.MaxQuestionsPerIteration <= 0 {
+			researchOptions.MaxQuestionsPerIteration = defaults.MaxQuestionsPerIteration
+		}
+		if researchOptions.MaxSearches <= 0 {
+			researchOptions.MaxSearches = defaults.MaxSearches
+		}
+		if researchOptions.FetchesPerSearch <= 0 {
+			researchOptions.FetchesPerSearch = defaults.FetchesPerSearch
+		}
+		// a zero budget means no limit, which would not bound the evidence
+		if researchOptions.ContextOptions == (linkup.ContextOptions{}) {
+			researchOptions.ContextOptions = defaults.ContextOptions
+		}
+	}
+	return &Researcher{searcher: searcher, llm: llm, options: researchOptions}
+}
+
+// Research a question and write a report. The searches that fail are reported in the steps of
+// the report and do not stop the research; an error is returned when every search fails, when
+// the LLM fails, or when the context is cancelled.
+func (r *Researcher) Research(ctx context.Context, question string) (*Report, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, errors.New("the research question cannot be empty")
+	}
+	state := &researchState{
+		report:  &Report{Question: question},
+		builder: linkup.NewContextBuilder(r.options.ContextOptions),
+		known:   make(map[string]bool),
+		fetched: make(map[string]bool),
+		seen:    map[string]bool{questionKey(question): true},
+	}
+	queue := []string{question}
+	for iteration := 1; ; iteration++ {
+		batch := queue[:min(len(queue), r.options.MaxQuestionsPerIteration)]
+		queue = queue[len(batch):]
+		for _, current := range batch {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if state.report.Searches >= r.options.MaxSearches {
+				break
+			}
+			state.report.Steps = append(state.report.Steps, r.investigate(state, iteration, current))
+			state.asked = append(state.asked, current)
+		}
+		if state.report.Searches >= r.options.MaxSearches {
+			state.report.StopReason = StopSearchBudget
+			break
+		}
+		if iteration >= r.options.MaxIterations {
+			state.report.StopReason = StopMaxIterations
+			break
+		}
+		followUps, err := r.llm.FollowUpQuestions(ctx, question, state.asked, state.builder.Build())
+		if err != nil {
+			return nil, fmt.Errorf("could not get the follow-up questions: %w", err)
+		}
+		for _, followUp := range followUps {
+			key := questionKey(followUp)
+			if key != "" && !state.seen[key] {
+				state.seen[key] = true
+				queue = append(queue, strings.TrimSpace(followUp))
+			}
+		}
+		if len(queue) == 0 {
+			state.report.StopReason = StopAnswered
+			break
+		}
+	}
+	if !slices.ContainsFunc(state.report.Steps, func(step Step) bool { return step.Err == nil }) {
+		errs := make([]error, len(state.report.Steps))
+		for i, step := range state.report.Steps {
+			errs[i] = step.Err
+		}
+		return nil, fmt.Errorf("every search failed: %w", errors.Join(errs...))
+	}
+
+	evidence := state.builder.Build()
+	answer, err := r.llm.WriteReport(ctx, question, evidence)
+	if err != nil {
+		return nil, fmt.Errorf("could not write the report: %w", err)
+	}
+	state.report.Answer = answer
+	for _, id := range evidence.Ids {
+		source := evidence.Sources[id]
+		state.report.Sources = append(state.report.Sources, Source{
+			Id:      id,
+			Name:    source.Name,
+			Url:     source.Url,
+			Fetched: state.fetched[canonical.URL(source.Url)],
+		})
+	}
+	return state.report, nil
+}
+
+// The state of a research in progress
+type researchState struct {
+	report  *Report
+	builder *linkup.ContextBuilder
+	asked   []string
+	// the canonical URLs of the sources added to the builder, and of the pages fetched successfully
+	known   map[string]bool
+	fetched map[string]bool
+	// the normalized questions already researched or queued
+	seen map[string]bool
+}
+
+// Searches a question, fetches the top new results, and adds the results to the evidence
+func (r *Researcher) investigate(state *researchState, iteration int, question string) Step {
+	step := Step{Iteration: iteration, Question: question}
+	state.report.Searches++
+	output, err := r.searcher.GetSearchResults(question, r.options.Depth, r.options.SearchOptions)
+	if err != nil {
+		step.Err = err
+		return step
+	}
+	step.Results = len(output.TextResults)
+	fetches := 0
+	for _, result := range output.TextResults {
+		key := canonical.URL(result.Url)
+		// pages already known are not fetched again: the builder keeps the first content of a source
+		if !state.known[key] && fetches < r.options.FetchesPerSearch && state.report.Fetches < r.options.MaxFetches {
+			fetches++
+			state.report.Fetches++
+			page, err := r.searcher.Fetch(result.Url, r.options.FetchOptions)
+			if err != nil {
+				if step.FetchErrors == nil {
+					step.FetchErrors = make(map[string]error)
+				}
+				step.FetchErrors[result.Url] = err
+			} else if text := strings.TrimSpace(markdown.Parse(page.Markdown).PlainText()); text != "" {
+				result.Content = text
+				state.fetched[key] = true
+				step.Fetched = append(step.Fetched, result.Url)
+			}
+		}
+		state.known[key] = true
+		state.builder.Add(result)
+	}
+	return step
+}
+
+// Returns the key used to recognize the same question asked twice
+func questionKey(question string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.TrimRight(strings.TrimSpace(question), "?.!"))), " ")
+}
+
+var citation = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// Get the sources cited in the answer, in the order of their first citation
+func (r *Report) CitedSources() []Source {
+	var cited []Source
+	for _, match := range citation.FindAllStringSubmatch(r.Answer, -1) {
+		for _, number := range strings.Split(match[1], ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil {
+				continue
+			}
+			index := slices.IndexFunc(r.Sources, func(source Source) bool { return source.Id == id })
+			if index >= 0 && !slices.ContainsFunc(cited, func(source Source) bool { return source.Id == id }) {
+				cited = append(cited, r.Sources[index])
+			}
+		}
+	}
+	return cited
+}
+
+// Render the report as Markdown: the question as title, the answer, and the cited sources
+func (r *Report) Markdown() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# %s\n\n%s\n", r.Question, r.Answer)
+	if cited := r.CitedSources(); len(cited) > 0 {
+		slices.SortFunc(cited, func(a Source, b Source) int { return a.Id - b.Id })
+		builder.WriteString("\n## Sources\n\n")
+		for _, source := range cited {
+			name := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(strings.Join(strings.Fields(source.Name), " "))
+			if name == "" {
+				name = source.Url
+			}
+			fmt.Fprintf(&builder, "- [%d] [%s](%s)\n", source.Id, name, source.Url)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,194 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	linkup "github.com/AstraBert/linkup-go-sdk"
+)
+
+type fakeSearcher struct {
+	results map[string][]linkup.TextSearchResultDto
+	pages   map[string]string
+
+	queries []string
+	fetches []string
+}
+
+func (f *fakeSearcher) GetSearchResults(query string, depth linkup.SearchDepth, searchOptions ...linkup.AdditionalSearchOptions) (*linkup.SearchResultsOutput, error) {
+	f.queries = append(f.queries, query)
+	results, ok := f.results[query]
+	if !ok {
+		return nil, errors.New("no results for " + query)
+	}
+	return &linkup.SearchResultsOutput{TextResults: results}, nil
+}
+
+func (f *fakeSearcher) Fetch(url string, fetchOptions ...linkup.AdditionalFetchOptions) (*linkup.FetchOutput, error) {
+	f.fetches = append(f.fetches, url)
+	page, ok := f.pages[url]
+	if !ok {
+		return nil, errors.New("could not fetch " + url)
+	}
+	return &linkup.FetchOutput{Markdown: page}, nil
+}
+
+func result(url string, content string) linkup.TextSearchResultDto {
+	return linkup.TextSearchResultDto{Name: "Page " + url, Url: url, Content: content, Type: "text"}
+}
+
+func newSearcher() *fakeSearcher {
+	return &fakeSearcher{
+		results: map[string][]linkup.TextSearchResultDto{
+			"What is Go?": {
+				result("https://go.dev", "Go is an open source programming language."),
+				result("https://en.wikipedia.org/wiki/Go", "Go is a language designed at Google."),
+			},
+			"Who created Go?": {
+				result("https://go.dev/", "Go home page."),
+				result("https://go.dev/doc/faq", "Go was designed by Robert Griesemer, Rob Pike and Ken Thompson."),
+			},
+			"When was Go released": {
+				result("https://go.dev/blog/go1", "Go 1 was released in March 2012."),
+			},
+		},
+		pages: map[string]string{
+			"https://go.dev":         "Go is an **open source** programming language that makes it simple to build software.",
+			"https://go.dev/doc/faq": "Go was designed at Google in 2007.",
+		},
+	}
+}
+
+func TestResearch(t *testing.T) {
+	searcher := newSearcher()
+	llm := &StubLLM{FollowUps: [][]string{{"Who created Go?", "what is go", "When was Go released"}}}
+	researcher := NewResearcher(searcher, llm)
+	report, err := researcher.Research(context.Background(), "What is Go?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the follow-up repeating the question is not searched
+	expectedQueries := []string{"What is Go?", "Who created Go?", "When was Go released"}
+	if !slices.Equal(searcher.queries, expectedQueries) {
+		t.Fatalf("expected queries %v, got %v", expectedQueries, searcher.queries)
+	}
+	// one new page is fetched per search, and the home page is not fetched twice
+	expectedFetches := []string{"https://go.dev", "https://go.dev/doc/faq", "https://go.dev/blog/go1"}
+	if !slices.Equal(searcher.fetches, expectedFetches) {
+		t.Fatalf("expected fetches %v, got %v", expectedFetches, searcher.fetches)
+	}
+	if report.StopReason != StopAnswered || report.Searches != 3 || report.Fetches != 3 {
+		t.Fatalf("unexpected report: %s, %d searches, %d fetches", report.StopReason, report.Searches, report.Fetches)
+	}
+	if len(report.Steps) != 3 || report.Steps[1].Iteration != 2 || report.Steps[2].FetchErrors["https://go.dev/blog/go1"] == nil {
+		t.Fatalf("unexpected steps: %+v", report.Steps)
+	}
+	if len(report.Sources) != 4 || !report.Sources[0].Fetched || report.Sources[1].Fetched || report.Sources[3].Id != 4 {
+		t.Fatalf("unexpected sources: %+v", report.Sources)
+	}
+	expectedAnswer := strings.Join([]string{
+		"Go is an open source programming language that makes it simple to build software [1]",
+		"Go is a language designed at Google [2]",
+		"Go was designed at Google in 2007 [3]",
+		"Go 1 was released in March 2012 [4]",
+	}, "\n\n")
+	if report.Answer != expectedAnswer {
+		t.Fatalf("expected answer %q, got %q", expectedAnswer, report.Answer)
+	}
+	markdown := report.Markdown()
+	if !strings.HasPrefix(markdown, "# What is Go?\n\n") || !strings.Contains(markdown, "- [3] [Page https://go.dev/doc/faq](https://go.dev/doc/faq)\n") {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+}
+
+func TestResearchBudgets(t *testing.T) {
+	followUps := [][]string{{"Who created Go?", "When was Go released"}, {"Is Go fast"}}
+
+	searcher := newSearcher()
+	options := DefaultOptions()
+	options.MaxIterations = 2
+	options.MaxQuestionsPerIteration = 1
+	report, err := NewResearcher(searcher, &StubLLM{FollowUps: followUps}, options).Research(context.Background(), "What is Go?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.StopReason != StopMaxIterations || !slices.Equal(searcher.queries, []string{"What is Go?", "Who created Go?"}) {
+		t.Fatalf("expected to stop after 2 iterations, got %s with queries %v", report.StopReason, searcher.queries)
+	}
+
+	searcher = newSearcher()
+	options = DefaultOptions()
+	options.MaxSearches = 2
+	options.MaxFetches = 1
+	report, err = NewResearcher(searcher, &StubLLM{FollowUps: followUps}, options).Research(context.Background(), "What is Go?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.StopReason != StopSearchBudget || report.Searches != 2 || len(searcher.fetches) != 1 {
+		t.Fatalf("expected to stop on the search budget, got %s with %d searches and %d fetches", report.StopReason, report.Searches, len(searcher.fetches))
+	}
+}
+
+func TestResearchPartialOptions(t *testing.T) {
+	if depth := DefaultOptions().Depth; depth != "" {
+		t.Fatalf("expected the default depth of the client, got %q", depth)
+	}
+	// unset budgets fall back to the defaults, so that pages are still fetched
+	searcher := newSearcher()
+	researcher := NewResearcher(searcher, &StubLLM{}, Options{MaxFetches: 2})
+	if researcher.options.ContextOptions != linkup.DefaultContextOptions() {
+		t.Fatalf("expected the default context options, got %+v", researcher.options.ContextOptions)
+	}
+	report, err := researcher.Research(context.Background(), "What is Go?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Fetches != 1 || !slices.Equal(searcher.fetches, []string{"https://go.dev"}) {
+		t.Fatalf("expected the top result to be fetched, got %v", searcher.fetches)
+	}
+}
+
+func TestResearchErrors(t *testing.T) {
+	if _, err := NewResearcher(newSearcher(), &StubLLM{}).Research(context.Background(), "  "); err == nil {
+		t.Fatal("expected an error for an empty question")
+	}
+	if _, err := NewResearcher(newSearcher(), &StubLLM{}).Research(context.Background(), "unknown"); err == nil {
+		t.Fatal("expected an error when every search fails")
+	}
+
+	// failing follow-up searches do not stop the research
+	report, err := NewResearcher(newSearcher(), &StubLLM{FollowUps: [][]string{{"unknown"}}}).Research(context.Background(), "What is Go?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Steps[1].Err == nil {
+		t.Fatal("expected the error of the failed search in the steps")
+	}
+
+	failing := NewCompletionLLM(func(ctx context.Context, prompt string) (string, error) {
+		return "", errors.New("model unavailable")
+	})
+	if _, err := NewResearcher(newSearcher(), failing).Research(context.Background(), "What is Go?"); err == nil || !strings.Contains(err.Error(), "model unavailable") {
+		t.Fatalf("expected the error of the LLM, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewResearcher(newSearcher(), &StubLLM{}).Research(ctx, "What is Go?"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+}
+
+func TestCitedSources(t *testing.T) {
+	report := &Report{
+		Answer:  "Second [2]. Both [2, 1]. Unknown [9].",
+		Sources: []Source{{Id: 1, Url: "https://a.com"}, {Id: 2, Url: "https://b.com"}, {Id: 3, Url: "https://c.com"}},
+	}
+	cited := report.CitedSources()
+	if len(cited) != 2 || cited[0].Id != 2 || cited[1].Id != 1 {
+		t.Fatalf("unexpected cited sources: %+v", cited)
+	}
+}
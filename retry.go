@@ -0,0 +1,82 @@
+package linkup
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Policy to retry the requests that failed because of transport errors,
+// rate limiting (429) or server-side errors (5xx), with exponential backoff
+type RetryPolicy struct {
+	// MaxAttempts The maximum number of attempts for a request, including the first one.
+	MaxAttempts int
+
+	// InitialBackoff The time waited before the first retry, doubled at every subsequent retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff The upper bound for the time waited between two attempts.
+	MaxBackoff time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+	}
+}
+
+// Returns the time to wait before the given retry (starting from 1)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 {
+		backoff = min(backoff, p.MaxBackoff)
+	}
+	return backoff
+}
+
+// Sends a request to the given endpoint through the retry policy and the circuit breaker, if configured.
+// The `send` function returns the status code of the response it got.
+func (l *LinkupClient) call(ctx context.Context, endpoint LinkupEndpoint, send func(context.Context) (int, error)) error {
+	maxAttempts := 1
+	if l.retry != nil && l.retry.MaxAttempts > 1 {
+		maxAttempts = l.retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		statusCode, err := l.guarded(ctx, endpoint, send)
+		var openErr *ErrCircuitOpen
+		if errors.As(err, &openErr) || !isBreakerFailure(statusCode, err) || attempt >= maxAttempts {
+			return err
+		}
+		timer := time.NewTimer(l.retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Sends a single request, going through the circuit breaker of the endpoint if configured
+func (l *LinkupClient) guarded(ctx context.Context, endpoint LinkupEndpoint, send func(context.Context) (int, error)) (int, error) {
+	breaker, ok := l.breakers[endpoint]
+	if !ok {
+		return send(ctx)
+	}
+	generation, err := breaker.allow()
+	if err != nil {
+		return 0, err
+	}
+	statusCode, err := send(ctx)
+	if isCallerCancellation(ctx, err) {
+		breaker.release(generation)
+		return statusCode, err
+	}
+	breaker.done(generation, !isBreakerFailure(statusCode, err))
+	return statusCode, err
+}
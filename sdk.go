@@ -9,6 +9,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"slices"
+	"time"
 )
 
 const LinkupServerUrl string = "https://api.linkup.so"
@@ -22,33 +24,226 @@ type LinkupHttpClient interface {
 
 // Struct type representing a client to perform operations with the Linkup API
 type LinkupClient struct {
-	apiKey string
-	client LinkupHttpClient
+	apiKey    string
+	client    LinkupHttpClient
+	breakers  map[LinkupEndpoint]*circuitBreaker
+	latencies *latencyTracker
+	metrics   *clientMetrics
+	retry     *RetryPolicy
+
+	defaultDepth         SearchDepth
+	defaultSearchOptions *AdditionalSearchOptions
+	deduplicate          bool
+	rankers              []Ranker
+}
+
+// Settings collected from the options passed to NewLinkupClient
+type linkupClientSettings struct {
+	serverUrl      string
+	keys           KeyProvider
+	circuitBreaker *CircuitBreakerConfig
+	hedging        *HedgingPolicy
+	retry          *RetryPolicy
+	timeout        time.Duration
+
+	defaultDepth         SearchDepth
+	defaultSearchOptions *AdditionalSearchOptions
+	deduplicate          bool
+	rankers              []Ranker
+}
+
+// Functional option to customize a LinkupClient at construction time
+type LinkupClientOption func(*linkupClientSettings) error
+
+// Option to send the requests to a different server than LinkupServerUrl
+func WithServerUrl(serverUrl string) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		if serverUrl == "" {
+			return errors.New("server url cannot be empty")
+		}
+		s.serverUrl = serverUrl
+		return nil
+	}
+}
+
+// Option to get the API key from a KeyProvider, consulted for every request.
+// When this option is used, the API key passed to NewLinkupClient is ignored.
+func WithKeyProvider(keys KeyProvider) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		if keys == nil {
+			return errors.New("key provider cannot be nil")
+		}
+		s.keys = keys
+		return nil
+	}
+}
+
+// Option to wrap the Linkup API with a circuit breaker: once the failure ratio
+// of an endpoint goes above the configured threshold, requests to that endpoint
+// fail fast with ErrCircuitOpen until the cool-down has elapsed.
+// Search and fetch requests are tracked independently.
+func WithCircuitBreaker(config CircuitBreakerConfig) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		if config.FailureRatio < 0 || config.FailureRatio > 1 {
+			return fmt.Errorf("circuit breaker failure ratio must be between 0 and 1, got %f", config.FailureRatio)
+		}
+		s.circuitBreaker = &config
+		return nil
+	}
+}
+
+// Option to hedge latency-sensitive searches (`standard` depth, `searchResults` or `sourcedAnswer` output):
+// a second identical request is sent when the first one is slower than the configured latency percentile.
+// The number of hedged requests is reported by the `Metrics` method.
+func WithHedging(policy HedgingPolicy) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		if policy.Percentile < 0 || policy.Percentile > 1 {
+			return fmt.Errorf("hedging percentile must be between 0 and 1, got %f", policy.Percentile)
+		}
+		s.hedging = &policy
+		return nil
+	}
+}
+
+// Option to retry the requests failing because of transport errors, rate limiting or server-side errors
+func WithRetry(policy RetryPolicy) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		if policy.MaxAttempts < 1 {
+			return fmt.Errorf("retry policy must allow at least one attempt, got %d", policy.MaxAttempts)
+		}
+		s.retry = &policy
+		return nil
+	}
+}
+
+// Option to set a timeout for every HTTP request sent to the Linkup API
+func WithTimeout(timeout time.Duration) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		if timeout < 0 {
+			return fmt.Errorf("timeout cannot be negative, got %s", timeout)
+		}
+		s.timeout = timeout
+		return nil
+	}
+}
+
+// Option to set the depth used by the search methods when they are called with an empty depth
+func WithDefaultDepth(depth SearchDepth) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		if depth != Standard && depth != Deep {
+			return fmt.Errorf("unsupported search depth: %q", depth)
+		}
+		s.defaultDepth = depth
+		return nil
+	}
+}
+
+// Option to set client-level search options, merged with the options passed to every search call
+// (see `MergeSearchOptions` for the override semantics). This is useful for organization-wide
+// policies, such as a list of excluded domains, that should not be repeated at every call site.
+func WithDefaultSearchOptions(options AdditionalSearchOptions) LinkupClientOption {
+	return func(s *linkupClientSettings) error {
+		s.defaultSearchOptions = &options
+		return nil
+	}
 }
 
 // Constructor to create a new LinkupClient instance.
 // If the API Key is passed as an empty string, it will be loaded
-// from the environment
-func NewLinkupClient(apiKey string) (*LinkupClient, error) {
-	if apiKey == "" {
-		key, ok := os.LookupEnv("LINKUP_API_KEY")
-		if !ok {
-			return nil, errors.New("api key not provided and could not find LINKUP_API_KEY in the environment")
+// from the environment, unless a KeyProvider is passed with `WithKeyProvider`
+func NewLinkupClient(apiKey string, opts ...LinkupClientOption) (*LinkupClient, error) {
+	settings := &linkupClientSettings{serverUrl: LinkupServerUrl}
+	for _, opt := range opts {
+		if err := opt(settings); err != nil {
+			return nil, err
 		}
-		apiKey = key
 	}
-	var requestEditor RequestEditorFn = func(ctx context.Context, req *http.Request) error {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-		return nil
+	keys := settings.keys
+	if keys == nil {
+		if apiKey == "" {
+			key, ok := os.LookupEnv("LINKUP_API_KEY")
+			if !ok {
+				return nil, errors.New("api key not provided and could not find LINKUP_API_KEY in the environment")
+			}
+			apiKey = key
+		}
+		keys = StaticKeyProvider(apiKey)
+	} else {
+		apiKey = ""
 	}
-	client, err := NewClientWithResponses(LinkupServerUrl, WithRequestEditorFn(requestEditor))
+	client, err := NewClientWithResponses(
+		settings.serverUrl,
+		WithHTTPClient(&keyRotatingDoer{doer: &http.Client{Timeout: settings.timeout}, keys: keys}),
+		WithRequestEditorFn(keyRequestEditor(keys)),
+	)
 	if err != nil {
 		return nil, err
 	}
-	return &LinkupClient{
-		apiKey: apiKey,
-		client: client,
-	}, nil
+	linkupClient := &LinkupClient{
+		apiKey:               apiKey,
+		client:               client,
+		metrics:              &clientMetrics{},
+		retry:                settings.retry,
+		defaultDepth:         settings.defaultDepth,
+		defaultSearchOptions: settings.defaultSearchOptions,
+		deduplicate:          settings.deduplicate,
+		rankers:              settings.rankers,
+	}
+	if settings.circuitBreaker != nil {
+		linkupClient.breakers = map[LinkupEndpoint]*circuitBreaker{
+			SearchEndpoint: newCircuitBreaker(SearchEndpoint, *settings.circuitBreaker),
+			FetchEndpoint:  newCircuitBreaker(FetchEndpoint, *settings.circuitBreaker),
+		}
+	}
+	if settings.hedging != nil {
+		linkupClient.latencies = newLatencyTracker(*settings.hedging)
+	}
+	return linkupClient, nil
+}
+
+// Get the state of the circuit breaker for the given endpoint.
+// If the client was created without a circuit breaker, the circuit is always closed.
+func (l *LinkupClient) CircuitState(endpoint LinkupEndpoint) CircuitState {
+	if breaker, ok := l.breakers[endpoint]; ok {
+		return breaker.State()
+	}
+	return CircuitClosed
+}
+
+// Sends a request to the /v1/search endpoint, going through the retry policy,
+// the circuit breaker and hedging the request if configured
+func (l *LinkupClient) search(ctx context.Context, body SearchJSONRequestBody) (*SearchResponse, error) {
+	send := func(ctx context.Context, body SearchJSONRequestBody) (*SearchResponse, error) {
+		return l.client.SearchWithResponse(ctx, body)
+	}
+	if l.latencies != nil && l.metrics != nil && isHedgeable(body) {
+		send = l.hedgedSearch
+	}
+	var response *SearchResponse
+	err := l.call(ctx, SearchEndpoint, func(ctx context.Context) (int, error) {
+		var err error
+		response, err = send(ctx, body)
+		if response == nil {
+			return 0, err
+		}
+		return response.StatusCode(), err
+	})
+	return response, err
+}
+
+// Sends a request to the /v1/fetch endpoint, going through the retry policy
+// and the circuit breaker if configured
+func (l *LinkupClient) fetch(ctx context.Context, body FetchJSONRequestBody) (*FetchResponse, error) {
+	var response *FetchResponse
+	err := l.call(ctx, FetchEndpoint, func(ctx context.Context) (int, error) {
+		var err error
+		response, err = l.client.FetchWithResponse(ctx, body)
+		if response == nil {
+			return 0, err
+		}
+		return response.StatusCode(), err
+	})
+	return response, err
 }
 
 // Additional search options to be used with search methods for customization
@@ -76,8 +271,23 @@ type AdditionalSearchOptions struct {
 
 	// ToDate The date until which the search results should be considered, in ISO 8601 format (YYYY-MM-DD). It must be later than `fromDate`, if provided, or than 1970-01-01.
 	ToDate *string `json:"toDate,omitempty"`
+
+	// Override The boolean options whose value, even false, replaces the default when these options are merged with `MergeSearchOptions`, for instance to turn off a flag enabled in the client defaults.
+	Override SearchFlag `json:"-"`
 }
 
+// Enum representing the boolean options of `AdditionalSearchOptions`, combined as a bit mask
+type SearchFlag int
+
+const (
+	// The `IncludeImages` option
+	SearchIncludeImages SearchFlag = 1 << iota
+	// The `IncludeInlineCitations` option
+	SearchIncludeInlineCitations
+	// The `IncludeSources` option
+	SearchIncludeSources
+)
+
 func DefaultAdditionalSearchOptions() AdditionalSearchOptions {
 	return AdditionalSearchOptions{
 		ExcludeDomains:         nil,
@@ -88,7 +298,78 @@ func DefaultAdditionalSearchOptions() AdditionalSearchOptions {
 		MaxResults:             nil,
 		IncludeInlineCitations: false,
 		IncludeSources:         false,
+		Override:               0,
+	}
+}
+
+// Merge per-call search options into a set of defaults, with the following semantics:
+//   - ExcludeDomains: a nil slice keeps the defaults, a non-empty slice is added to the defaults
+//     (without duplicates) and an empty non-nil slice clears the defaults;
+//   - IncludeDomains: a nil slice keeps the defaults and a non-nil slice replaces them, so that the
+//     domains of a call are not added to the ones of the defaults;
+//   - pointers (FromDate, ToDate, MaxResults): a non-nil value replaces the default;
+//   - booleans: a flag listed in `Override` takes the value of the overrides, even false; other flags
+//     are enabled if they are enabled either in the defaults or in the overrides.
+//
+// For instance, a call turns off images enabled in the client defaults with
+// `AdditionalSearchOptions{IncludeImages: false, Override: SearchIncludeImages}`.
+// The returned options never share their slices with the arguments.
+func MergeSearchOptions(defaults AdditionalSearchOptions, overrides AdditionalSearchOptions) AdditionalSearchOptions {
+	return AdditionalSearchOptions{
+		ExcludeDomains:         mergeDomains(defaults.ExcludeDomains, overrides.ExcludeDomains),
+		IncludeDomains:         replaceDomains(defaults.IncludeDomains, overrides.IncludeDomains),
+		FromDate:               mergePointer(defaults.FromDate, overrides.FromDate),
+		ToDate:                 mergePointer(defaults.ToDate, overrides.ToDate),
+		MaxResults:             mergePointer(defaults.MaxResults, overrides.MaxResults),
+		IncludeImages:          mergeFlag(defaults.IncludeImages, overrides, SearchIncludeImages, overrides.IncludeImages),
+		IncludeInlineCitations: mergeFlag(defaults.IncludeInlineCitations, overrides, SearchIncludeInlineCitations, overrides.IncludeInlineCitations),
+		IncludeSources:         mergeFlag(defaults.IncludeSources, overrides, SearchIncludeSources, overrides.IncludeSources),
+		Override:               defaults.Override | overrides.Override,
+	}
+}
+
+func mergeFlag(defaults bool, overrides AdditionalSearchOptions, flag SearchFlag, value bool) bool {
+	if overrides.Override&flag != 0 {
+		return value
+	}
+	return defaults || value
+}
+
+func replaceDomains(defaults []string, overrides []string) []string {
+	if overrides != nil {
+		return slices.Clone(overrides)
+	}
+	return slices.Clone(defaults)
+}
+
+func mergeDomains(defaults []string, overrides []string) []string {
+	if overrides != nil && len(overrides) == 0 {
+		return []string{}
+	}
+	if defaults == nil && overrides == nil {
+		return nil
+	}
+	merged := make([]string, 0, len(defaults)+len(overrides))
+	seen := make(map[string]bool, len(defaults)+len(overrides))
+	for _, domain := range append(slices.Clone(defaults), overrides...) {
+		if !seen[domain] {
+			seen[domain] = true
+			merged = append(merged, domain)
+		}
 	}
+	return merged
+}
+
+func mergePointer[T any](defaults *T, overrides *T) *T {
+	if overrides != nil {
+		value := *overrides
+		return &value
+	}
+	if defaults != nil {
+		value := *defaults
+		return &value
+	}
+	return nil
 }
 
 // Additional option to be used with the fetch method for customization
@@ -139,21 +420,40 @@ type StructuredOutput struct {
 // Struct type representing the results from the `/v1/fetch` endpoint
 type FetchOutput = FetchResponseDto
 
+// Returns the search options to use for a call: the client defaults merged
+// with the options passed by the caller, if any
+func (l *LinkupClient) resolveSearchOptions(searchOptions []AdditionalSearchOptions) AdditionalSearchOptions {
+	defaults := DefaultAdditionalSearchOptions()
+	if l.defaultSearchOptions != nil {
+		defaults = *l.defaultSearchOptions
+	}
+	if len(searchOptions) == 0 {
+		return MergeSearchOptions(defaults, DefaultAdditionalSearchOptions())
+	}
+	return MergeSearchOptions(defaults, searchOptions[0])
+}
+
+// Returns the depth to use for a call, falling back to the client default (or `standard`) when empty
+func (l *LinkupClient) resolveDepth(depth SearchDepth) SearchDepth {
+	switch {
+	case depth != "":
+		return depth
+	case l.defaultDepth != "":
+		return l.defaultDepth
+	default:
+		return Standard
+	}
+}
+
 // Method to query the /v1/search API endpoint with `searchResults` as output type.
 func (l *LinkupClient) GetSearchResults(
 	query string,
 	depth SearchDepth,
 	searchOptions ...AdditionalSearchOptions,
 ) (*SearchResultsOutput, error) {
-	var options AdditionalSearchOptions
-	switch len(searchOptions) {
-	case 0:
-		options = DefaultAdditionalSearchOptions()
-	default:
-		options = searchOptions[0]
-	}
+	options := l.resolveSearchOptions(searchOptions)
 	searchQuery := SearchJSONRequestBody{
-		Depth:                  depth,
+		Depth:                  l.resolveDepth(depth),
 		Q:                      query,
 		ExcludeDomains:         &options.ExcludeDomains,
 		IncludeDomains:         &options.IncludeDomains,
@@ -166,7 +466,7 @@ func (l *LinkupClient) GetSearchResults(
 		StructuredOutputSchema: nil,
 		OutputType:             SearchResults,
 	}
-	response, err := l.client.SearchWithResponse(context.Background(), searchQuery)
+	response, err := l.search(context.Background(), searchQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +500,12 @@ func (l *LinkupClient) GetSearchResults(
 		if len(output.TextResults)+len(output.ImageResults) == 0 {
 			return nil, errors.New("no valid results were found")
 		}
+		if l.deduplicate {
+			output = *DedupeSearchResults(&output)
+		}
+		if len(l.rankers) > 0 {
+			output = *RerankSearchResults(query, &output, l.rankers...)
+		}
 		return &output, nil
 	}
 	return nil, fmt.Errorf("response returned a status code of %d: %s", response.StatusCode(), response.Status())
@@ -211,15 +517,9 @@ func (l *LinkupClient) GetSourcedAnswer(
 	depth SearchDepth,
 	searchOptions ...AdditionalSearchOptions,
 ) (*SourcedAnswerOutput, error) {
-	var options AdditionalSearchOptions
-	switch len(searchOptions) {
-	case 0:
-		options = DefaultAdditionalSearchOptions()
-	default:
-		options = searchOptions[0]
-	}
+	options := l.resolveSearchOptions(searchOptions)
 	searchQuery := SearchJSONRequestBody{
-		Depth:                  depth,
+		Depth:                  l.resolveDepth(depth),
 		Q:                      query,
 		ExcludeDomains:         &options.ExcludeDomains,
 		IncludeDomains:         &options.IncludeDomains,
@@ -232,7 +532,7 @@ func (l *LinkupClient) GetSourcedAnswer(
 		StructuredOutputSchema: nil,
 		OutputType:             SourcedAnswer,
 	}
-	response, err := l.client.SearchWithResponse(context.Background(), searchQuery)
+	response, err := l.search(context.Background(), searchQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -242,6 +542,9 @@ func (l *LinkupClient) GetSourcedAnswer(
 		if err != nil {
 			return nil, err
 		}
+		if l.deduplicate {
+			return DedupeSourcedAnswer(&results), nil
+		}
 		return &results, nil
 	}
 	return nil, fmt.Errorf("response returned a status code of %d: %s", response.StatusCode(), response.Status())
@@ -255,15 +558,9 @@ func (l *LinkupClient) GetStructuredResults(
 	jsonSchema json.RawMessage,
 	searchOptions ...AdditionalSearchOptions,
 ) (*StructuredOutput, error) {
-	var options AdditionalSearchOptions
-	switch len(searchOptions) {
-	case 0:
-		options = DefaultAdditionalSearchOptions()
-	default:
-		options = searchOptions[0]
-	}
+	options := l.resolveSearchOptions(searchOptions)
 	searchQuery := SearchJSONRequestBody{
-		Depth:                  depth,
+		Depth:                  l.resolveDepth(depth),
 		Q:                      query,
 		ExcludeDomains:         &options.ExcludeDomains,
 		IncludeDomains:         &options.IncludeDomains,
@@ -276,7 +573,7 @@ func (l *LinkupClient) GetStructuredResults(
 		StructuredOutputSchema: jsonSchema,
 		OutputType:             Structured,
 	}
-	response, err := l.client.SearchWithResponse(context.Background(), searchQuery)
+	response, err := l.search(context.Background(), searchQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -293,6 +590,9 @@ func (l *LinkupClient) GetStructuredResults(
 			bodyStr := string(response.Body)
 			output.RawJson = &bodyStr
 		}
+		if l.deduplicate {
+			return DedupeStructuredSources(output), nil
+		}
 		return output, nil
 	}
 	return nil, fmt.Errorf("response returned a status code of %d: %s", response.StatusCode(), response.Status())
@@ -321,13 +621,18 @@ func (l *LinkupClient) Fetch(
 	default:
 		options = fetchOptions[0]
 	}
+	return l.fetchUrl(context.Background(), url, options)
+}
+
+// Fetches a single URL with the given options, stopping when the context is done
+func (l *LinkupClient) fetchUrl(ctx context.Context, url string, options AdditionalFetchOptions) (*FetchOutput, error) {
 	fetchQuery := FetchJSONRequestBody{
 		Url:            url,
 		RenderJs:       &options.RenderJs,
 		IncludeRawHtml: &options.IncludeRawHtml,
 		ExtractImages:  &options.ExtractImages,
 	}
-	response, err := l.client.FetchWithResponse(context.Background(), fetchQuery)
+	response, err := l.fetch(ctx, fetchQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"slices"
 	"testing"
 )
 
@@ -503,3 +504,101 @@ func TestFetchFails(t *testing.T) {
 		t.Fatalf("No error recorded, but one was expected")
 	}
 }
+
+// Mock client recording the last search request it received
+type RecordingMockClient struct {
+	MockClient
+	lastSearch SearchJSONRequestBody
+}
+
+func (m *RecordingMockClient) SearchWithResponse(ctx context.Context, body SearchJSONRequestBody, requestEditors ...RequestEditorFn) (*SearchResponse, error) {
+	m.lastSearch = body
+	return m.MockClient.SearchWithResponse(ctx, body, requestEditors...)
+}
+
+func TestMergeSearchOptions(t *testing.T) {
+	fromDate := "2024-01-01"
+	toDate := "2024-12-31"
+	var defaultMaxResults float32 = 10
+	var maxResults float32 = 3
+	defaults := AdditionalSearchOptions{
+		ExcludeDomains: []string{"spam.net", "ads.com"},
+		FromDate:       &fromDate,
+		MaxResults:     &defaultMaxResults,
+		IncludeImages:  true,
+	}
+	merged := MergeSearchOptions(defaults, AdditionalSearchOptions{
+		ExcludeDomains: []string{"ads.com", "tabloid.org"},
+		IncludeDomains: []string{"wikipedia.org"},
+		ToDate:         &toDate,
+		MaxResults:     &maxResults,
+		IncludeSources: true,
+	})
+	if !slices.Equal(merged.ExcludeDomains, []string{"spam.net", "ads.com", "tabloid.org"}) {
+		t.Fatalf("Unexpected excluded domains: %v", merged.ExcludeDomains)
+	}
+	if !slices.Equal(merged.IncludeDomains, []string{"wikipedia.org"}) {
+		t.Fatalf("Unexpected included domains: %v", merged.IncludeDomains)
+	}
+	if merged.FromDate == nil || *merged.FromDate != fromDate || merged.ToDate == nil || *merged.ToDate != toDate {
+		t.Fatalf("Unexpected dates: %v, %v", merged.FromDate, merged.ToDate)
+	}
+	if merged.MaxResults == nil || *merged.MaxResults != 3 {
+		t.Fatalf("Unexpected max results: %v", merged.MaxResults)
+	}
+	if !merged.IncludeImages || !merged.IncludeSources || merged.IncludeInlineCitations {
+		t.Fatalf("Unexpected flags: %+v", merged)
+	}
+	merged.ExcludeDomains[0] = "changed"
+	if defaults.ExcludeDomains[0] != "spam.net" {
+		t.Fatal("Merged options should not share slices with the defaults")
+	}
+	cleared := MergeSearchOptions(defaults, AdditionalSearchOptions{ExcludeDomains: []string{}})
+	if cleared.ExcludeDomains == nil || len(cleared.ExcludeDomains) != 0 {
+		t.Fatalf("Expected the excluded domains to be cleared, got %v", cleared.ExcludeDomains)
+	}
+	inherited := MergeSearchOptions(defaults, DefaultAdditionalSearchOptions())
+	if !slices.Equal(inherited.ExcludeDomains, defaults.ExcludeDomains) || inherited.IncludeDomains != nil || !inherited.IncludeImages {
+		t.Fatalf("Unexpected inherited options: %+v", inherited)
+	}
+	// a call can turn off a flag enabled in the defaults
+	disabled := MergeSearchOptions(defaults, AdditionalSearchOptions{IncludeImages: false, Override: SearchIncludeImages})
+	if disabled.IncludeImages {
+		t.Fatal("Expected the per-call value to override the default")
+	}
+	// included domains of a call replace the defaults instead of being added to them
+	narrowed := MergeSearchOptions(AdditionalSearchOptions{IncludeDomains: []string{"go.dev", "golang.org"}}, AdditionalSearchOptions{IncludeDomains: []string{"go.dev"}})
+	if !slices.Equal(narrowed.IncludeDomains, []string{"go.dev"}) {
+		t.Fatalf("Unexpected included domains: %v", narrowed.IncludeDomains)
+	}
+}
+
+func TestClientDefaultSearchOptions(t *testing.T) {
+	mock := &RecordingMockClient{}
+	client := LinkupClient{
+		apiKey:               "hello",
+		client:               mock,
+		defaultDepth:         Deep,
+		defaultSearchOptions: &AdditionalSearchOptions{ExcludeDomains: []string{"spam.net"}},
+	}
+	if _, err := client.GetSourcedAnswer("lake", ""); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err.Error())
+	}
+	if mock.lastSearch.Depth != Deep || !slices.Equal(*mock.lastSearch.ExcludeDomains, []string{"spam.net"}) {
+		t.Fatalf("Unexpected request: %+v", mock.lastSearch)
+	}
+	if _, err := client.GetSearchResults("lake", Standard, AdditionalSearchOptions{ExcludeDomains: []string{"ads.com"}, IncludeImages: true}); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err.Error())
+	}
+	if mock.lastSearch.Depth != Standard || !slices.Equal(*mock.lastSearch.ExcludeDomains, []string{"spam.net", "ads.com"}) || !*mock.lastSearch.IncludeImages {
+		t.Fatalf("Unexpected request: %+v", mock.lastSearch)
+	}
+
+	client.defaultSearchOptions = &AdditionalSearchOptions{IncludeImages: true}
+	if _, err := client.GetSearchResults("lake", Standard, AdditionalSearchOptions{Override: SearchIncludeImages}); err != nil {
+		t.Fatalf("An unexpected error occurred: %s", err.Error())
+	}
+	if *mock.lastSearch.IncludeImages {
+		t.Fatalf("Expected the call to turn off the images enabled by default: %+v", mock.lastSearch)
+	}
+}
@@ -0,0 +1,442 @@
+package linkup
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/AstraBert/linkup-go-sdk/fetch/markdown"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Enum representing where a table has been extracted from
+type TableOrigin int
+
+const (
+	TableFromMarkdown TableOrigin = iota
+	TableFromHtml
+)
+
+// Struct type representing a table extracted from a fetched page
+type PageTable struct {
+	// Caption The caption of the table, only available for HTML tables.
+	Caption string
+
+	// Headers The column headers. It is empty when the table has no header row.
+	Headers []string
+
+	// Rows The rows of the table, with the plain text of each cell. All rows have the same number of cells.
+	Rows [][]string
+
+	// Origin Whether the table comes from the markdown or the raw HTML of the page.
+	Origin TableOrigin
+}
+
+// Extract the tables of a fetched page. The tables of the markdown are returned
+// if there are any; otherwise, when the page has been fetched with `IncludeRawHtml`,
+// the tables of the raw HTML are returned.
+func ExtractTables(output *FetchOutput) ([]PageTable, error) {
+	tables := ExtractMarkdownTables(output.Markdown)
+	if len(tables) > 0 || output.RawHtml == nil {
+		return tables, nil
+	}
+	return ExtractHtmlTables(strings.NewReader(*output.RawHtml))
+}
+
+// Extract the pipe tables of a markdown document
+func ExtractMarkdownTables(source string) []PageTable {
+	var tables []PageTable
+	for _, table := range markdown.Parse(source).Tables() {
+		tables = append(tables, normalizeTable(PageTable{
+			Headers: table.Headers,
+			Rows:    table.Rows,
+			Origin:  TableFromMarkdown,
+		}))
+	}
+	return tables
+}
+
+// Extract the tables of an HTML document. Cells spanning several rows or columns
+// are repeated in each of them, and nested tables are extracted separately.
+func ExtractHtmlTables(r io.Reader) ([]PageTable, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	var tables []PageTable
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.Table {
+			if table, ok := parseHtmlTable(node); ok {
+				tables = append(tables, table)
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return tables, nil
+}
+
+type htmlCell struct {
+	text     string
+	header   bool
+	colspan  int
+	rowspan  int
+	inHeader bool
+}
+
+func parseHtmlTable(table *html.Node) (PageTable, bool) {
+	result := PageTable{Origin: TableFromHtml}
+	var rows [][]htmlCell
+	var collectRows func(node *html.Node, inHeader bool)
+	collectRows = func(node *html.Node, inHeader bool) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Caption:
+				result.Caption = nodeText(child)
+			case atom.Thead:
+				collectRows(child, true)
+			case atom.Tbody, atom.Tfoot:
+				collectRows(child, false)
+			case atom.Tr:
+				var cells []htmlCell
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+						continue
+					}
+					cells = append(cells, htmlCell{
+						text:     nodeText(cell),
+						header:   cell.DataAtom == atom.Th,
+						colspan:  spanAttribute(cell, "colspan"),
+						rowspan:  spanAttribute(cell, "rowspan"),
+						inHeader: inHeader,
+					})
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			}
+		}
+	}
+	collectRows(table, false)
+	if len(rows) == 0 {
+		return result, false
+	}
+
+	grid, headerRows := layoutCells(rows)
+	if headerRows > 0 {
+		width := 0
+		for _, row := range grid[:headerRows] {
+			width = max(width, len(row))
+		}
+		result.Headers = make([]string, width)
+	}
+	for _, row := range grid[:headerRows] {
+		for i, text := range row {
+			// multi-row headers are joined, without repeating the cells spanning several rows
+			if text != "" && !strings.HasSuffix(result.Headers[i], text) {
+				result.Headers[i] = strings.TrimSpace(result.Headers[i] + " " + text)
+			}
+		}
+	}
+	result.Rows = grid[headerRows:]
+	return normalizeTable(result), true
+}
+
+// Places the cells in a grid, expanding row and column spans, and returns the grid
+// with the number of leading header rows (rows in `thead`, or made only of `th` cells)
+func layoutCells(rows [][]htmlCell) ([][]string, int) {
+	type pending struct {
+		text      string
+		remaining int
+	}
+	spans := map[int]*pending{}
+	grid := make([][]string, 0, len(rows))
+	headerRows := 0
+	countingHeaders := true
+	for _, cells := range rows {
+		var row []string
+		column := 0
+		fillSpans := func() {
+			for {
+				span, ok := spans[column]
+				if !ok {
+					return
+				}
+				row = append(row, span.text)
+				if span.remaining--; span.remaining == 0 {
+					delete(spans, column)
+				}
+				column++
+			}
+		}
+		isHeader := true
+		for _, cell := range cells {
+			fillSpans()
+			for i := 0; i < cell.colspan; i++ {
+				row = append(row, cell.text)
+				if cell.rowspan > 1 {
+					spans[column] = &pending{text: cell.text, remaining: cell.rowspan - 1}
+				}
+				column++
+			}
+			isHeader = isHeader && (cell.header || cell.inHeader)
+		}
+		fillSpans()
+		// spans continuing past the last cell of the row
+		for spanColumn, span := range spans {
+			if spanColumn >= column {
+				for len(row) <= spanColumn {
+					row = append(row, "")
+				}
+				row[spanColumn] = span.text
+				if span.remaining--; span.remaining == 0 {
+					delete(spans, spanColumn)
+				}
+			}
+		}
+		if countingHeaders && isHeader {
+			headerRows++
+		} else {
+			countingHeaders = false
+		}
+		grid = append(grid, row)
+	}
+	// a table made only of header cells has no header row
+	if headerRows == len(grid) {
+		headerRows = min(headerRows, 1)
+		if len(grid) == 1 {
+			headerRows = 0
+		}
+	}
+	return grid, headerRows
+}
+
+func spanAttribute(node *html.Node, name string) int {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			if span, err := strconv.Atoi(strings.TrimSpace(attr.Val)); err == nil && span > 0 {
+				// browsers cap spans, and so do we to avoid huge grids
+				return min(span, 1000)
+			}
+		}
+	}
+	return 1
+}
+
+// Returns the text of a node with collapsed whitespace, skipping scripts, styles and nested tables
+func nodeText(node *html.Node) string {
+	var builder strings.Builder
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			builder.WriteString(node.Data)
+		case html.ElementNode:
+			switch node.DataAtom {
+			case atom.Script, atom.Style, atom.Table:
+				return
+			case atom.Br, atom.P, atom.Div, atom.Li:
+				builder.WriteByte(' ')
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// Pads the headers and rows so that they all have the same width
+func normalizeTable(table PageTable) PageTable {
+	width := len(table.Headers)
+	for _, row := range table.Rows {
+		width = max(width, len(row))
+	}
+	pad := func(cells []string) []string {
+		for len(cells) < width {
+			cells = append(cells, "")
+		}
+		return cells
+	}
+	if len(table.Headers) > 0 {
+		table.Headers = pad(table.Headers)
+	}
+	for i, row := range table.Rows {
+		table.Rows[i] = pad(row)
+	}
+	return table
+}
+
+// Write the table as CSV, with the headers (if any) as the first record
+func (t *PageTable) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if len(t.Headers) > 0 {
+		if err := writer.Write(t.Headers); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// Error type returned when a cell cannot be decoded into a struct field
+type TableDecodeError struct {
+	Row    int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *TableDecodeError) Error() string {
+	return fmt.Sprintf("cannot decode %q in row %d, column %q: %v", e.Value, e.Row, e.Column, e.Err)
+}
+
+func (e *TableDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Utility function to decode the rows of a table into structs. Each exported field is
+// matched to the column whose header is given by its `table` struct tag, or else to the
+// column whose header equals its name, ignoring case. Fields tagged with `table:"-"` and
+// fields without a matching column are left untouched.
+//
+// Supported field types are strings, booleans, integers, floats, pointers to them (nil for
+// empty cells) and types implementing `encoding.TextUnmarshaler`. Numbers may contain
+// thousands separators, currency symbols and percent signs, as often found on pricing pages.
+//
+// A cell that cannot be decoded, such as "N/A" in a numeric column, leaves its field with the
+// zero value: every row is returned, along with an error joining a `TableDecodeError` per cell.
+func DecodeTable[T any](table PageTable) ([]T, error) {
+	structType := reflect.TypeFor[T]()
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot decode a table into %v: a struct type is required", structType)
+	}
+	if len(table.Headers) == 0 {
+		return nil, errors.New("cannot decode a table without headers")
+	}
+	columns := make(map[int]int, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, tagged := field.Tag.Lookup("table")
+		if name == "-" {
+			continue
+		}
+		for column, header := range table.Headers {
+			if (tagged && header == name) || (!tagged && strings.EqualFold(header, field.Name)) {
+				columns[i] = column
+				break
+			}
+		}
+	}
+	results := make([]T, len(table.Rows))
+	var cellErrors []error
+	for r, row := range table.Rows {
+		value := reflect.ValueOf(&results[r]).Elem()
+		for field, column := range columns {
+			if column >= len(row) {
+				continue
+			}
+			if err := decodeCell(value.Field(field), row[column]); err != nil {
+				value.Field(field).SetZero()
+				cellErrors = append(cellErrors, &TableDecodeError{Row: r, Column: table.Headers[column], Value: row[column], Err: err})
+			}
+		}
+	}
+	return results, errors.Join(cellErrors...)
+}
+
+func decodeCell(field reflect.Value, cell string) error {
+	cell = strings.TrimSpace(cell)
+	if field.Kind() == reflect.Pointer {
+		if cell == "" {
+			return nil
+		}
+		pointer := reflect.New(field.Type().Elem())
+		if err := decodeCell(pointer.Elem(), cell); err != nil {
+			return err
+		}
+		field.Set(pointer)
+		return nil
+	}
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(cell))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+		return nil
+	case reflect.Bool:
+		if cell == "" {
+			return nil
+		}
+		switch strings.ToLower(cell) {
+		case "true", "yes", "y", "1", "✓", "✔", "x":
+			field.SetBool(true)
+		case "false", "no", "n", "0", "✗", "✘", "-":
+			field.SetBool(false)
+		default:
+			return errors.New("not a boolean")
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if cell = cleanNumber(cell); cell == "" {
+			return nil
+		}
+		number, err := strconv.ParseInt(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(number)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if cell = cleanNumber(cell); cell == "" {
+			return nil
+		}
+		number, err := strconv.ParseUint(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(number)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		if cell = cleanNumber(cell); cell == "" {
+			return nil
+		}
+		number, err := strconv.ParseFloat(cell, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(number)
+		return nil
+	}
+	return fmt.Errorf("unsupported field type %v", field.Type())
+}
+
+// Removes thousands separators, currency symbols and percent signs from a number
+func cleanNumber(cell string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ',', '$', '€', '£', '¥', '%', ' ', ' ', ' ':
+			return -1
+		}
+		return r
+	}, cell)
+}
@@ -0,0 +1,131 @@
+package linkup
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type pricingRow struct {
+	Plan    string
+	Price   *float64 `table:"Monthly price"`
+	Seats   int
+	Support bool
+	Notes   string `table:"-"`
+}
+
+const pricingMarkdown = `# Pricing
+
+| Plan | Monthly price | Seats | Support |
+|------|--------------:|-------|---------|
+| Free | | 1 | no |
+| **Pro** | $1,250.50 | 10 | ✓ |
+`
+
+func TestExtractMarkdownTablesAndDecode(t *testing.T) {
+	tables, err := ExtractTables(&FetchOutput{Markdown: pricingMarkdown})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0].Origin != TableFromMarkdown {
+		t.Fatalf("Unexpected tables: %+v", tables)
+	}
+	rows, err := DecodeTable[pricingRow](tables[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Plan != "Free" || rows[0].Price != nil || rows[0].Support {
+		t.Fatalf("Unexpected first row: %+v", rows[0])
+	}
+	if rows[1].Plan != "Pro" || rows[1].Price == nil || *rows[1].Price != 1250.5 || rows[1].Seats != 10 || !rows[1].Support {
+		t.Fatalf("Unexpected second row: %+v", rows[1])
+	}
+
+	tables[0].Rows[0][2] = "many"
+	tables[0].Rows[1][1] = "Contact us"
+	var decodeErr *TableDecodeError
+	rows, err = DecodeTable[pricingRow](tables[0])
+	if !errors.As(err, &decodeErr) || decodeErr.Row != 0 || decodeErr.Column != "Seats" {
+		t.Fatalf("Expected a decode error for the seats column, got %v", err)
+	}
+	// the other cells of the rows are still decoded
+	if len(rows) != 2 || rows[0].Plan != "Free" || rows[0].Seats != 0 || rows[1].Price != nil || rows[1].Seats != 10 {
+		t.Fatalf("Unexpected rows: %+v", rows)
+	}
+	if count := strings.Count(err.Error(), "cannot decode"); count != 2 {
+		t.Fatalf("Expected an error per undecodable cell, got %v", err)
+	}
+	if _, err := DecodeTable[string](tables[0]); err == nil {
+		t.Fatal("Expected an error when decoding into a non-struct type")
+	}
+}
+
+func TestExtractHtmlTables(t *testing.T) {
+	page := `<html><body>
+<table>
+  <caption>Plans</caption>
+  <thead>
+    <tr><th rowspan="2">Plan</th><th colspan="2">Price</th></tr>
+    <tr><th>Monthly</th><th>Yearly</th></tr>
+  </thead>
+  <tbody>
+    <tr><td>Free</td><td colspan="2">$0</td></tr>
+    <tr><td rowspan="2">Pro<script>x()</script></td><td>$10</td><td>$100</td></tr>
+    <tr><td>$12</td><td>$120</td></tr>
+  </tbody>
+</table>
+<table><tr><td>no</td><td>headers <table><tr><td>nested</td></tr></table></td></tr></table>
+</body></html>`
+	markdown := "No tables here."
+	tables, err := ExtractTables(&FetchOutput{Markdown: markdown, RawHtml: &page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 3 {
+		t.Fatalf("Expected 3 tables, got %d: %+v", len(tables), tables)
+	}
+	plans := tables[0]
+	if plans.Caption != "Plans" || plans.Origin != TableFromHtml {
+		t.Fatalf("Unexpected table: %+v", plans)
+	}
+	if !slices.Equal(plans.Headers, []string{"Plan", "Price Monthly", "Price Yearly"}) {
+		t.Fatalf("Unexpected headers: %q", plans.Headers)
+	}
+	expected := [][]string{{"Free", "$0", "$0"}, {"Pro", "$10", "$100"}, {"Pro", "$12", "$120"}}
+	if !slices.EqualFunc(plans.Rows, expected, slices.Equal[[]string]) {
+		t.Fatalf("Unexpected rows: %q", plans.Rows)
+	}
+	if len(tables[1].Headers) != 0 || !slices.Equal(tables[1].Rows[0], []string{"no", "headers"}) {
+		t.Fatalf("Unexpected table without headers: %+v", tables[1])
+	}
+	if tables[2].Rows[0][0] != "nested" {
+		t.Fatalf("Unexpected nested table: %+v", tables[2])
+	}
+}
+
+func TestExtractHtmlTablesUnevenHeaders(t *testing.T) {
+	page := `<table><tr><th>A</th></tr><tr><th>B</th><th>C</th></tr><tr><td>1</td><td>2</td></tr></table>`
+	tables, err := ExtractTables(&FetchOutput{RawHtml: &page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || !slices.Equal(tables[0].Headers, []string{"A B", "C"}) {
+		t.Fatalf("Unexpected table: %+v", tables)
+	}
+	if !slices.EqualFunc(tables[0].Rows, [][]string{{"1", "2"}}, slices.Equal[[]string]) {
+		t.Fatalf("Unexpected rows: %q", tables[0].Rows)
+	}
+}
+
+func TestPageTableWriteCSV(t *testing.T) {
+	table := PageTable{Headers: []string{"Plan", "Note"}, Rows: [][]string{{"Pro", "fast, \"reliable\""}}}
+	var buffer bytes.Buffer
+	if err := table.WriteCSV(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if got := buffer.String(); got != "Plan,Note\nPro,\"fast, \"\"reliable\"\"\"\n" {
+		t.Fatalf("Unexpected CSV: %q", got)
+	}
+}
@@ -0,0 +1,312 @@
+package linkup
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/AstraBert/linkup-go-sdk/canonical"
+	"github.com/AstraBert/linkup-go-sdk/fetch/markdown"
+)
+
+// Enum representing how well a text is supported by the page of a source
+type SupportLevel int
+
+const (
+	// The page could not be fetched, or there was nothing to check
+	SupportUnknown SupportLevel = iota
+	// The text does not appear in the page
+	SupportNone
+	// Most of the text appears in the page, but not verbatim
+	SupportFuzzy
+	// The text appears verbatim in the page, ignoring case, punctuation and whitespace
+	SupportExact
+)
+
+func (s SupportLevel) String() string {
+	switch s {
+	case SupportNone:
+		return "none"
+	case SupportFuzzy:
+		return "fuzzy"
+	case SupportExact:
+		return "exact"
+	}
+	return "unknown"
+}
+
+// Struct type representing how well a text is supported by the page of a source
+type SupportCheck struct {
+	// Text The checked text.
+	Text string
+
+	Level SupportLevel
+
+	// Score The fraction of the text found in the page, from 0 to 1.
+	Score float64
+}
+
+// Struct type representing the verification of a source cited in an answer
+type CitationCheck struct {
+	// SourceIndex The index of the source in the sources of the answer.
+	SourceIndex int
+
+	Source SourceDto
+
+	// Snippet How well the snippet of the source is supported by its page.
+	Snippet SupportCheck
+
+	// Claims How well each sentence of the answer citing the source is supported by its page.
+	Claims []SupportCheck
+
+	// Err The error that occurred while fetching the page of the source, if any.
+	Err error
+}
+
+// Struct type representing the verification of a sourced answer against the pages of its sources
+type VerificationReport struct {
+	// Citations The verification of each cited source, or of every source when the answer has no inline citations.
+	Citations []CitationCheck
+
+	// Dangling The citations of the answer that do not match any source.
+	Dangling []CitationRef
+
+	// GroundingScore The average score of the claims of the answer (or of the snippets, when the answer
+	// has no inline citations), from 0 to 1. Claims whose source could not be fetched count as unsupported.
+	GroundingScore float64
+}
+
+// Options to verify sourced answers with `Verify`
+type VerifyOptions struct {
+	// Fetch The options used to fetch the pages of the sources.
+	Fetch FetchManyOptions
+
+	// SnippetThreshold The minimum fraction of the word trigrams of a snippet found in the page for a fuzzy match.
+	SnippetThreshold float64
+
+	// ClaimThreshold The minimum fraction of the significant words of a claim found in the page for a fuzzy match.
+	ClaimThreshold float64
+}
+
+func DefaultVerifyOptions() VerifyOptions {
+	return VerifyOptions{
+		Fetch:            DefaultFetchManyOptions(),
+		SnippetThreshold: 0.7,
+		ClaimThreshold:   0.6,
+	}
+}
+
+// Verify a sourced answer: fetch the page of each cited source, and check whether the snippet of the
+// source and the sentences citing it appear in the page, verbatim or approximately
+func (l *LinkupClient) Verify(ctx context.Context, output *SourcedAnswerOutput, verifyOptions ...VerifyOptions) *VerificationReport {
+	var options VerifyOptions
+	switch len(verifyOptions) {
+	case 0:
+		options = DefaultVerifyOptions()
+	default:
+		options = verifyOptions[0]
+	}
+	cited := ParseCitations(output)
+	claims := citedClaims(cited)
+	report := &VerificationReport{Dangling: cited.Dangling}
+
+	indexes := cited.citationOrder()
+	if len(indexes) == 0 {
+		for i := range output.Sources {
+			indexes = append(indexes, i)
+		}
+	}
+	urls := make([]string, len(indexes))
+	for i, index := range indexes {
+		urls[i] = output.Sources[index].Url
+	}
+	pages := make(map[string]FetchResult)
+	for result := range l.FetchMany(ctx, urls, options.Fetch) {
+		pages[result.CanonicalUrl] = result
+	}
+
+	total, count := 0.0, 0
+	for _, index := range indexes {
+		source := output.Sources[index]
+		check := CitationCheck{SourceIndex: index, Source: source}
+		result, ok := pages[canonical.URL(source.Url)]
+		var page *normalizedPage
+		switch {
+		case !ok:
+			check.Err = ctx.Err()
+		case result.Err != nil:
+			check.Err = result.Err
+		default:
+			page = newNormalizedPage(markdown.Parse(result.Output.Markdown).PlainText())
+		}
+		check.Snippet = SupportCheck{Text: source.Snippet}
+		if page != nil && strings.TrimSpace(source.Snippet) != "" {
+			check.Snippet = page.checkSnippet(source.Snippet, options.SnippetThreshold)
+		}
+		for _, claim := range claims[index] {
+			claimCheck := SupportCheck{Text: claim}
+			if page != nil {
+				claimCheck = page.checkClaim(claim, options.ClaimThreshold)
+			}
+			check.Claims = append(check.Claims, claimCheck)
+			total += claimCheck.Score
+			count++
+		}
+		report.Citations = append(report.Citations, check)
+	}
+	// without inline citations, the grounding relies on the snippets
+	if len(claims) == 0 {
+		for _, check := range report.Citations {
+			if strings.TrimSpace(check.Source.Snippet) != "" {
+				total += check.Snippet.Score
+				count++
+			}
+		}
+	}
+	if count > 0 {
+		report.GroundingScore = total / float64(count)
+	}
+	return report
+}
+
+// Returns the sentences of the answer citing each source, keyed by source index
+func citedClaims(answer *CitedAnswer) map[int][]string {
+	claims := make(map[int][]string)
+	var current, previous string
+	for _, segment := range answer.Segments {
+		if segment.Kind == TextSegment {
+			current += segment.Text
+			if sentence, rest, ok := lastSentenceEnd(current); ok {
+				if len(words(sentence)) > 0 {
+					previous = sentence
+				}
+				current = rest
+			}
+			continue
+		}
+		claim := strings.TrimSpace(current)
+		// markers placed after the end of a sentence cite that sentence
+		if claim == "" {
+			claim = previous
+		}
+		if claim == "" {
+			continue
+		}
+		for _, citation := range segment.Citations {
+			if citation.Source != nil && !slices.Contains(claims[citation.SourceIndex], claim) {
+				claims[citation.SourceIndex] = append(claims[citation.SourceIndex], claim)
+			}
+		}
+	}
+	return claims
+}
+
+// Splits a text after its last sentence terminator, returning the last complete sentence and the rest
+func lastSentenceEnd(text string) (string, string, bool) {
+	end := -1
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '.', '!', '?', '\n':
+			if i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\n' {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return "", "", false
+	}
+	before := strings.TrimSpace(text[:end+1])
+	start := 0
+	for i := len(before) - 2; i >= 0; i-- {
+		if before[i] == '.' || before[i] == '!' || before[i] == '?' || before[i] == '\n' {
+			start = i + 1
+			break
+		}
+	}
+	return strings.TrimSpace(before[start:]), text[end+1:], true
+}
+
+// Words that carry no meaning on their own, ignored when matching claims
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "were": true, "with": true, "that": true,
+	"this": true, "from": true, "its": true, "has": true, "have": true, "had": true, "but": true, "not": true,
+	"which": true, "also": true, "been": true, "into": true, "than": true, "their": true, "they": true, "these": true,
+	"can": true, "will": true, "would": true, "about": true, "such": true, "other": true, "more": true, "most": true,
+}
+
+// The text of a page, as lowercased words, indexed for matching
+type normalizedPage struct {
+	joined   string
+	words    map[string]bool
+	trigrams map[string]bool
+}
+
+func newNormalizedPage(text string) *normalizedPage {
+	pageWords := words(text)
+	page := &normalizedPage{
+		joined:   " " + strings.Join(pageWords, " ") + " ",
+		words:    make(map[string]bool, len(pageWords)),
+		trigrams: make(map[string]bool, len(pageWords)),
+	}
+	for i, word := range pageWords {
+		page.words[word] = true
+		if i+3 <= len(pageWords) {
+			page.trigrams[strings.Join(pageWords[i:i+3], " ")] = true
+		}
+	}
+	return page
+}
+
+func (p *normalizedPage) contains(textWords []string) bool {
+	return len(textWords) > 0 && strings.Contains(p.joined, " "+strings.Join(textWords, " ")+" ")
+}
+
+func (p *normalizedPage) checkSnippet(snippet string, threshold float64) SupportCheck {
+	check := SupportCheck{Text: snippet, Level: SupportNone}
+	snippetWords := words(strings.Trim(strings.TrimSpace(snippet), ".…"))
+	if p.contains(snippetWords) {
+		check.Level, check.Score = SupportExact, 1
+		return check
+	}
+	if len(snippetWords) < 3 {
+		return check
+	}
+	found := 0
+	for i := 0; i+3 <= len(snippetWords); i++ {
+		if p.trigrams[strings.Join(snippetWords[i:i+3], " ")] {
+			found++
+		}
+	}
+	check.Score = float64(found) / float64(len(snippetWords)-2)
+	if check.Score >= threshold {
+		check.Level = SupportFuzzy
+	}
+	return check
+}
+
+func (p *normalizedPage) checkClaim(claim string, threshold float64) SupportCheck {
+	check := SupportCheck{Text: claim, Level: SupportNone}
+	claimWords := words(claim)
+	if p.contains(claimWords) {
+		check.Level, check.Score = SupportExact, 1
+		return check
+	}
+	significant, found := 0, 0
+	for _, word := range claimWords {
+		if len(word) < 3 || stopWords[word] {
+			continue
+		}
+		significant++
+		if p.words[word] {
+			found++
+		}
+	}
+	if significant == 0 {
+		return check
+	}
+	check.Score = float64(found) / float64(significant)
+	if check.Score >= threshold {
+		check.Level = SupportFuzzy
+	}
+	return check
+}
@@ -0,0 +1,72 @@
+package linkup
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestCitedClaims(t *testing.T) {
+	answer := ParseCitations(&SourcedAnswerOutput{
+		Answer:  "Go was created at Google [1]. It is compiled. [2] It has garbage collection [1][2].",
+		Sources: []SourceDto{{Url: "https://a.com"}, {Url: "https://b.com"}},
+	})
+	claims := citedClaims(answer)
+	if !slices.Equal(claims[0], []string{"Go was created at Google", "It has garbage collection"}) {
+		t.Fatalf("Unexpected claims for the first source: %q", claims[0])
+	}
+	if !slices.Equal(claims[1], []string{"It is compiled.", "It has garbage collection"}) {
+		t.Fatalf("Unexpected claims for the second source: %q", claims[1])
+	}
+}
+
+func TestVerify(t *testing.T) {
+	mock := &SiteMockClient{pages: map[string]string{
+		"https://go.dev/about":        "# About Go\n\nGo is an open source programming language, **created at Google** in 2007.\n\nIt is statically typed and compiled.",
+		"https://blog.example.com/gc": "# Memory\n\nThe runtime uses a concurrent collector for garbage.",
+	}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	output := &SourcedAnswerOutput{
+		Answer: "Go is an open source programming language [1]. Go is statically typed [1]. It has a concurrent garbage collector [2]. It runs on Mars [3]. Extra [3](https://extra.example.com).",
+		Sources: []SourceDto{
+			{Name: "About", Url: "https://go.dev/about", Snippet: "Go is an open-source programming language created at Google"},
+			{Name: "GC", Url: "https://blog.example.com/gc", Snippet: "a completely different text"},
+			{Name: "Missing", Url: "https://missing.example.com", Snippet: "Go runs on Mars"},
+		},
+	}
+	report := client.Verify(context.Background(), output)
+	if len(report.Citations) != 3 || len(report.Dangling) != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	about := report.Citations[0]
+	if about.Err != nil || about.Snippet.Level != SupportExact {
+		t.Fatalf("Expected the snippet to match exactly, got %+v", about.Snippet)
+	}
+	if len(about.Claims) != 2 || about.Claims[0].Level != SupportExact || about.Claims[1].Level != SupportFuzzy {
+		t.Fatalf("Unexpected claims: %+v", about.Claims)
+	}
+	gc := report.Citations[1]
+	if gc.Snippet.Level != SupportNone || gc.Claims[0].Level != SupportFuzzy {
+		t.Fatalf("Unexpected check for the second source: %+v", gc)
+	}
+	missing := report.Citations[2]
+	if missing.Err == nil || missing.Snippet.Level != SupportUnknown || missing.Claims[0].Level != SupportUnknown {
+		t.Fatalf("Expected the missing source to be unverified, got %+v", missing)
+	}
+	expected := (1 + about.Claims[1].Score + gc.Claims[0].Score) / 4
+	if report.GroundingScore != expected || report.GroundingScore <= 0.5 || report.GroundingScore >= 1 {
+		t.Fatalf("Unexpected grounding score: %v", report.GroundingScore)
+	}
+}
+
+func TestVerifyWithoutInlineCitations(t *testing.T) {
+	mock := &SiteMockClient{pages: map[string]string{"https://go.dev/about": "Go is an open source programming language."}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	report := client.Verify(context.Background(), &SourcedAnswerOutput{
+		Answer:  "Go is a programming language.",
+		Sources: []SourceDto{{Url: "https://go.dev/about", Snippet: "Go is an open source programming language"}},
+	})
+	if len(report.Citations) != 1 || report.GroundingScore != 1 {
+		t.Fatalf("Expected the snippets to ground the answer, got %+v", report)
+	}
+}
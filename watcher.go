@@ -0,0 +1,416 @@
+package linkup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AstraBert/linkup-go-sdk/fetch/markdown"
+)
+
+// Struct type representing a snapshot of a watched page
+type PageSnapshot struct {
+	// Url The URL of the page.
+	Url string `json:"url"`
+
+	// FetchedAt When the page was fetched.
+	FetchedAt time.Time `json:"fetched_at"`
+
+	// Markdown The normalized markdown of the page.
+	Markdown string `json:"markdown"`
+
+	// Hash The hex-encoded SHA-256 hash of the normalized markdown.
+	Hash string `json:"hash"`
+}
+
+// Interface for the storage of page snapshots
+type SnapshotStore interface {
+	// Load the last snapshot of a page, or nil if there is none.
+	Load(url string) (*PageSnapshot, error)
+
+	// Save the snapshot of a page, replacing the previous one.
+	Save(snapshot *PageSnapshot) error
+}
+
+// Snapshot store keeping one JSON file per page in a directory
+type FileSnapshotStore struct {
+	dir string
+}
+
+// Create a snapshot store in the given directory, creating it if needed
+func NewFileSnapshotStore(dir string) (*FileSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSnapshotStore{dir: dir}, nil
+}
+
+func (s *FileSnapshotStore) path(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:16])+".json")
+}
+
+func (s *FileSnapshotStore) Load(url string) (*PageSnapshot, error) {
+	content, err := os.ReadFile(s.path(url))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshot PageSnapshot
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (s *FileSnapshotStore) Save(snapshot *PageSnapshot) error {
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := s.path(snapshot.Url)
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// Enum representing the kind of a change
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// Struct type representing a line added to or removed from a page
+type LineChange struct {
+	// Kind Either ChangeAdded or ChangeRemoved.
+	Kind ChangeKind
+
+	// Line The line number (starting from 1) in the current snapshot for added lines, in the previous one for removed lines.
+	Line int
+
+	// Text The content of the line.
+	Text string
+}
+
+// Struct type representing a section of a page that was added, removed or modified
+type SectionChange struct {
+	Kind ChangeKind
+
+	// Path The titles of the headings leading to the section, empty for the content before the first heading.
+	Path []string
+}
+
+// Struct type representing a meaningful change of a watched page
+type PageChange struct {
+	Url string
+
+	// Previous The previous snapshot of the page, nil when the fetch failed before any snapshot was taken.
+	Previous *PageSnapshot
+
+	// Current The new snapshot of the page, nil when the fetch failed.
+	Current *PageSnapshot
+
+	// Lines The lines added and removed, in order.
+	Lines []LineChange
+
+	// Sections The sections added, removed or modified.
+	Sections []SectionChange
+
+	// Err The error that occurred while fetching the page or storing its snapshot, if any.
+	Err error
+}
+
+// Options to watch pages with a `PageWatcher`
+type WatchOptions struct {
+	// Interval The time between two checks of the pages.
+	Interval time.Duration
+
+	// Fetch The options used to fetch the pages.
+	Fetch FetchManyOptions
+
+	// Store Where the snapshots are kept. By default, in the `.linkup-snapshots` directory.
+	Store SnapshotStore
+
+	// IgnorePatterns Patterns of content to ignore when comparing pages, such as dates or counters.
+	IgnorePatterns []*regexp.Regexp
+
+	// MinChangedLines The minimum number of lines added or removed for a change to be reported.
+	MinChangedLines int
+}
+
+func DefaultWatchOptions() WatchOptions {
+	return WatchOptions{
+		Interval:        time.Hour,
+		Fetch:           DefaultFetchManyOptions(),
+		MinChangedLines: 1,
+	}
+}
+
+// A watcher fetching pages periodically and reporting their meaningful changes
+type PageWatcher struct {
+	client  *LinkupClient
+	urls    []string
+	options WatchOptions
+	now     func() time.Time
+}
+
+func NewPageWatcher(client *LinkupClient, urls []string, options WatchOptions) (*PageWatcher, error) {
+	if options.Interval <= 0 {
+		options.Interval = DefaultWatchOptions().Interval
+	}
+	if options.MinChangedLines <= 0 {
+		options.MinChangedLines = 1
+	}
+	if options.Store == nil {
+		store, err := NewFileSnapshotStore(".linkup-snapshots")
+		if err != nil {
+			return nil, err
+		}
+		options.Store = store
+	}
+	return &PageWatcher{client: client, urls: urls, options: options, now: time.Now}, nil
+}
+
+// Check the pages once: fetch them, compare them with their previous snapshot and store
+// the new snapshots. Pages seen for the first time are stored without reporting a change.
+// Fetch and store errors are reported as changes with a non-nil `Err`.
+func (w *PageWatcher) Check(ctx context.Context) []PageChange {
+	var changes []PageChange
+	for result := range w.client.FetchMany(ctx, w.urls, w.options.Fetch) {
+		if change, ok := w.compare(result); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Watch the pages, checking them right away and then at every interval, and stream the changes
+// through the returned channel, which is closed when the context is cancelled
+func (w *PageWatcher) Watch(ctx context.Context) <-chan PageChange {
+	changes := make(chan PageChange)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(w.options.Interval)
+		defer ticker.Stop()
+		for {
+			for _, change := range w.Check(ctx) {
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
+
+func (w *PageWatcher) compare(result FetchResult) (PageChange, bool) {
+	change := PageChange{Url: result.Url}
+	previous, err := w.options.Store.Load(result.Url)
+	if err != nil {
+		change.Err = err
+		return change, true
+	}
+	change.Previous = previous
+	if result.Err != nil {
+		change.Err = result.Err
+		return change, true
+	}
+	normalized := NormalizeMarkdown(result.Output.Markdown, w.options.IgnorePatterns...)
+	hash := sha256.Sum256([]byte(normalized))
+	current := &PageSnapshot{
+		Url:       result.Url,
+		FetchedAt: w.now(),
+		Markdown:  normalized,
+		Hash:      hex.EncodeToString(hash[:]),
+	}
+	change.Current = current
+	if previous != nil && previous.Hash == current.Hash {
+		return change, false
+	}
+	if previous != nil {
+		change.Lines = DiffLines(previous.Markdown, current.Markdown)
+		// the previous snapshot stays the reference, so that small changes add up
+		if len(change.Lines) < w.options.MinChangedLines {
+			return change, false
+		}
+		change.Sections = DiffSections(previous.Markdown, current.Markdown)
+	}
+	if err := w.options.Store.Save(current); err != nil {
+		change.Err = err
+		return change, true
+	}
+	return change, previous != nil
+}
+
+var multipleBlankLines = regexp.MustCompile(`\n{3,}`)
+
+// Normalize markdown before comparing it: line endings and non-breaking spaces are
+// normalized, the content matching the ignore patterns is removed, trailing whitespace
+// is trimmed and consecutive blank lines are collapsed
+func NormalizeMarkdown(source string, ignorePatterns ...*regexp.Regexp) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\r", "\n")
+	source = strings.ReplaceAll(source, " ", " ")
+	for _, pattern := range ignorePatterns {
+		source = pattern.ReplaceAllString(source, "")
+	}
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	source = strings.Join(lines, "\n")
+	source = multipleBlankLines.ReplaceAllString(source, "\n\n")
+	return strings.TrimSpace(source)
+}
+
+// Above this number of cells, the longest common subsequence is not computed
+// and the differing lines are reported as fully replaced
+const maxDiffCells = 16_000_000
+
+// Compute the lines removed from `previous` and added in `current`, ignoring blank lines
+func DiffLines(previous string, current string) []LineChange {
+	before := strings.Split(previous, "\n")
+	after := strings.Split(current, "\n")
+	// skip the common prefix and suffix
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix && before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+	a := before[prefix : len(before)-suffix]
+	b := after[prefix : len(after)-suffix]
+
+	var changes []LineChange
+	add := func(kind ChangeKind, line int, text string) {
+		if strings.TrimSpace(text) != "" {
+			changes = append(changes, LineChange{Kind: kind, Line: prefix + line + 1, Text: text})
+		}
+	}
+	if len(a)*len(b) > maxDiffCells {
+		for i, line := range a {
+			add(ChangeRemoved, i, line)
+		}
+		for j, line := range b {
+			add(ChangeAdded, j, line)
+		}
+		return changes
+	}
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lengths := make([][]int32, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lengths[i][j+1] >= lengths[i+1][j]):
+			add(ChangeAdded, j, b[j])
+			j++
+		default:
+			add(ChangeRemoved, i, a[i])
+			i++
+		}
+	}
+	return changes
+}
+
+// Compute the sections added, removed or modified between two markdown documents.
+// The content of a section excludes its subsections, which are compared on their own.
+func DiffSections(previous string, current string) []SectionChange {
+	before := sectionContents(previous)
+	after := sectionContents(current)
+	var changes []SectionChange
+	for _, section := range after.order {
+		old, ok := before.contents[section.key]
+		switch {
+		case !ok:
+			changes = append(changes, SectionChange{Kind: ChangeAdded, Path: section.path})
+		case old != after.contents[section.key]:
+			changes = append(changes, SectionChange{Kind: ChangeModified, Path: section.path})
+		}
+	}
+	for _, section := range before.order {
+		if _, ok := after.contents[section.key]; !ok {
+			changes = append(changes, SectionChange{Kind: ChangeRemoved, Path: section.path})
+		}
+	}
+	return changes
+}
+
+type sectionKey struct {
+	key  string
+	path []string
+}
+
+type sectionIndex struct {
+	order    []sectionKey
+	contents map[string]string
+}
+
+func sectionContents(source string) sectionIndex {
+	document := markdown.Parse(source)
+	index := sectionIndex{contents: make(map[string]string)}
+	for _, section := range document.Sections() {
+		key := strings.Join(section.Path, "\x00")
+		if _, ok := index.contents[key]; ok {
+			// sections with the same path are compared together
+			index.contents[key] += "\n"
+		} else {
+			index.order = append(index.order, sectionKey{key: key, path: section.Path})
+		}
+		end := section.End
+		if len(section.Children) > 0 {
+			end = section.Children[0].Start
+		}
+		index.contents[key] += strings.TrimSpace(source[section.Start:end])
+	}
+	return index
+}
@@ -0,0 +1,119 @@
+package linkup
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiffLines(t *testing.T) {
+	previous := "# Title\n\nkeep\nold line\nkeep too\n\nend"
+	current := "# Title\n\nkeep\nnew line\nkeep too\n\nadded\n\nend"
+	changes := DiffLines(previous, current)
+	expected := []LineChange{
+		{Kind: ChangeAdded, Line: 4, Text: "new line"},
+		{Kind: ChangeRemoved, Line: 4, Text: "old line"},
+		{Kind: ChangeAdded, Line: 7, Text: "added"},
+	}
+	if !slices.Equal(changes, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, changes)
+	}
+	if changes := DiffLines("same", "same"); len(changes) != 0 {
+		t.Fatalf("Expected no changes, got %+v", changes)
+	}
+}
+
+func TestDiffSections(t *testing.T) {
+	previous := "Intro\n\n# Pricing\n\nPro costs $10\n\n## Enterprise\n\nContact us\n\n# Legacy\n\nOld plan"
+	current := "Intro\n\n# Pricing\n\nPro costs $12\n\n## Enterprise\n\nContact us\n\n# New\n\nNew plan"
+	changes := DiffSections(previous, current)
+	expected := []SectionChange{
+		{Kind: ChangeModified, Path: []string{"Pricing"}},
+		{Kind: ChangeAdded, Path: []string{"New"}},
+		{Kind: ChangeRemoved, Path: []string{"Legacy"}},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i].Kind != expected[i].Kind || !slices.Equal(changes[i].Path, expected[i].Path) {
+			t.Fatalf("Expected %+v, got %+v", expected[i], changes[i])
+		}
+	}
+}
+
+func TestNormalizeMarkdown(t *testing.T) {
+	got := NormalizeMarkdown("# Title  \r\n\r\n\r\n\r\nUpdated 2024-05-01  text\n", regexp.MustCompile(`Updated \d{4}-\d{2}-\d{2}`))
+	if got != "# Title\n\n  text" {
+		t.Fatalf("Unexpected normalized markdown: %q", got)
+	}
+}
+
+func TestPageWatcherCheck(t *testing.T) {
+	store, err := NewFileSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &SiteMockClient{pages: map[string]string{
+		"https://example.com/pricing": "# Pricing\n\nPro costs $10\n\nViews: 100",
+	}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	options := DefaultWatchOptions()
+	options.Store = store
+	options.IgnorePatterns = []*regexp.Regexp{regexp.MustCompile(`Views: \d+`)}
+	watcher, err := NewPageWatcher(client, []string{"https://example.com/pricing", "https://example.com/missing"}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := watcher.Check(context.Background())
+	if len(changes) != 1 || changes[0].Url != "https://example.com/missing" || changes[0].Err == nil {
+		t.Fatalf("Expected only the fetch error on the first check, got %+v", changes)
+	}
+	snapshot, err := store.Load("https://example.com/pricing")
+	if err != nil || snapshot == nil || snapshot.Markdown != "# Pricing\n\nPro costs $10" {
+		t.Fatalf("Unexpected snapshot: %+v, %v", snapshot, err)
+	}
+
+	watcher.urls = watcher.urls[:1]
+	mock.pages["https://example.com/pricing"] = "# Pricing\n\nPro costs $10\n\nViews: 250"
+	if changes := watcher.Check(context.Background()); len(changes) != 0 {
+		t.Fatalf("Expected ignored content not to be reported, got %+v", changes)
+	}
+
+	mock.pages["https://example.com/pricing"] = "# Pricing\n\nPro costs $12"
+	changes = watcher.Check(context.Background())
+	if len(changes) != 1 || changes[0].Previous == nil || changes[0].Current == nil {
+		t.Fatalf("Expected a change, got %+v", changes)
+	}
+	if len(changes[0].Lines) != 2 || len(changes[0].Sections) != 1 || changes[0].Sections[0].Kind != ChangeModified {
+		t.Fatalf("Unexpected change: %+v", changes[0])
+	}
+}
+
+func TestPageWatcherWatch(t *testing.T) {
+	store, err := NewFileSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &SiteMockClient{pages: map[string]string{"https://example.com": "first"}}
+	client := &LinkupClient{apiKey: "test", client: mock}
+	watcher, err := NewPageWatcher(client, []string{"https://example.com"}, WatchOptions{Store: store, Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	// the first check stores the page, which is modified before the next check
+	store.Save(&PageSnapshot{Url: "https://example.com", Markdown: "zeroth", Hash: "outdated"})
+	changes := watcher.Watch(ctx)
+	change, ok := <-changes
+	if !ok || change.Previous.Markdown != "zeroth" || change.Current.Markdown != "first" {
+		t.Fatalf("Unexpected change: %+v", change)
+	}
+	cancel()
+	for range changes {
+	}
+}